@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Built-in defaults, overridden by ~/.tsukuyo/config.yaml and then by CLI flags
+const (
+	defaultSSHUser = "ubuntu"
+	defaultDbType  = "postgres"
+	defaultDbPort  = 5432
+)
+
+// appConfig holds the settings read from the config file
+var appConfig = newAppConfig()
+
+// cfgFile is the --config flag value; empty means <tsukuyo dir>/config.yaml
+var cfgFile string
+
+func newAppConfig() *viper.Viper {
+	v := viper.New()
+	v.SetDefault("data_dir", "")
+	v.SetDefault("default_ssh_user", defaultSSHUser)
+	v.SetDefault("default_db_type", defaultDbType)
+	v.SetDefault("default_db_port", defaultDbPort)
+	v.SetDefault("ssh_timeout", 0)
+	v.SetDefault("max_inventory_size", "10MB")
+	v.SetDefault("log_level", "info")
+	return v
+}
+
+// configFilePath returns the config file to load
+func configFilePath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	return filepath.Join(getTsukuyoDir(), "config.yaml")
+}
+
+// loadConfig reads the config file into appConfig. A missing default config
+// file is not an error; a missing file given with --config is.
+func loadConfig() error {
+	appConfig = newAppConfig()
+
+	path := configFilePath()
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) && cfgFile == "" {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	appConfig.SetConfigFile(path)
+	appConfig.SetConfigType("yaml")
+	if err := appConfig.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if appConfig.GetString("log_level") == "debug" {
+		fmt.Fprintln(os.Stderr, "Loaded config from", path)
+	}
+	return nil
+}
+
+// configDataDir returns the data_dir setting with a leading ~ expanded
+func configDataDir() string {
+	dir := appConfig.GetString("data_dir")
+	if dir == "~" || (len(dir) > 1 && dir[:2] == "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	return dir
+}
+
+func initConfig(cmd *cobra.Command, args []string) error {
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	return setupLogFile(cmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalGetTsukuyoDir := getTsukuyoDir
+	getTsukuyoDir = func() string { return tmpDir }
+	defer func() {
+		getTsukuyoDir = originalGetTsukuyoDir
+		cfgFile = ""
+		appConfig = newAppConfig()
+	}()
+
+	// Built-in defaults apply without a config file
+	assert.NoError(t, loadConfig())
+	assert.Equal(t, "ubuntu", appConfig.GetString("default_ssh_user"))
+	assert.Equal(t, 5432, appConfig.GetInt("default_db_port"))
+	assert.Equal(t, "", configDataDir())
+
+	config := "default_ssh_user: admin\ndefault_db_type: mysql\ndefault_db_port: 3306\nssh_timeout: 10\ndata_dir: ~/tsukuyo-data\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(config), 0644))
+	assert.NoError(t, loadConfig())
+	assert.Equal(t, "admin", appConfig.GetString("default_ssh_user"))
+	assert.Equal(t, "mysql", appConfig.GetString("default_db_type"))
+	assert.Equal(t, 3306, appConfig.GetInt("default_db_port"))
+	assert.Equal(t, 10, appConfig.GetInt("ssh_timeout"))
+	assert.Equal(t, "info", appConfig.GetString("log_level"))
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, filepath.Join(home, "tsukuyo-data"), configDataDir())
+
+	// An explicit --config file must exist
+	cfgFile = filepath.Join(tmpDir, "missing.yaml")
+	assert.Error(t, loadConfig())
+}
+
+func TestIOTimeoutSetting(t *testing.T) {
+	defer func() { appConfig = newAppConfig() }()
+	flag := rootCmd.PersistentFlags().Lookup("timeout")
+	defer func() {
+		inventoryIOTimeout = inventory.DefaultIOTimeout
+		flag.Changed = false
+	}()
+
+	assert.Equal(t, inventory.DefaultIOTimeout, ioTimeout())
+
+	appConfig.Set("io_timeout", "5s")
+	assert.Equal(t, 5*time.Second, ioTimeout())
+
+	// --timeout wins over the config file
+	assert.NoError(t, rootCmd.PersistentFlags().Set("timeout", "2m"))
+	assert.Equal(t, 2*time.Minute, ioTimeout())
+}
+
+func TestDbSetUsesConfigDefaults(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { appConfig = newAppConfig() }()
+
+	appConfig.Set("default_db_type", "mysql")
+	appConfig.Set("default_db_port", 3306)
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, handleDbSet(rootCmd, hi, []string{"test-mysql", "mysql.example.com"}))
+
+	result, err := hi.Query("db.test-mysql")
+	assert.NoError(t, err)
+	entry, err := inventory.ParseDbEntry(result)
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql", entry.Type)
+	assert.Equal(t, 3306, entry.RemotePort)
+
+	// CLI flags still win over the config file
+	dbSetType = "postgres"
+	defer func() { dbSetType = "" }()
+	assert.NoError(t, handleDbSet(rootCmd, hi, []string{"test-pg", "pg.example.com"}))
+	result, err = hi.Query("db.test-pg")
+	assert.NoError(t, err)
+	entry, err = inventory.ParseDbEntry(result)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres", entry.Type)
+}
+
+func TestMaxInventorySizeSetting(t *testing.T) {
+	defer func() { appConfig = newAppConfig() }()
+
+	assert.Equal(t, inventory.DefaultMaxSize, maxInventorySize())
+
+	appConfig.Set("max_inventory_size", "512kb")
+	assert.Equal(t, int64(512<<10), maxInventorySize())
+
+	appConfig.Set("max_inventory_size", 0)
+	assert.Equal(t, int64(-1), maxInventorySize())
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,6 +22,7 @@ var (
 	dbSetRemotePort int
 	dbSetLocalPort  int
 	dbSetTags       string
+	dbEnv           string
 )
 
 // ensureDbInventoryInitialized ensures the db inventory is properly initialized
@@ -40,7 +43,7 @@ func ensureDbInventoryInitialized(hi *inventory.HierarchicalInventory) error {
 	// Validate existing entries follow the correct structure
 	dbMap := result.(map[string]interface{})
 	for entryName, entryValue := range dbMap {
-		if err := validateDbEntry(entryName, entryValue); err != nil {
+		if err := inventory.ValidateDbEntry(entryName, entryValue); err != nil {
 			fmt.Printf("Warning: DB entry '%s' has invalid structure: %v\n", entryName, err)
 			// Optionally, you could remove invalid entries or fix them here
 		}
@@ -49,61 +52,6 @@ func ensureDbInventoryInitialized(hi *inventory.HierarchicalInventory) error {
 	return nil
 }
 
-// validateDbEntry validates that a DB entry follows the correct structure
-func validateDbEntry(name string, entry interface{}) error {
-	entryMap, ok := entry.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("entry is not a map/object")
-	}
-
-	// Check required fields
-	if _, exists := entryMap["host"]; !exists {
-		return fmt.Errorf("missing required field 'host'")
-	}
-	if _, exists := entryMap["type"]; !exists {
-		return fmt.Errorf("missing required field 'type'")
-	}
-	if _, exists := entryMap["remote_port"]; !exists {
-		return fmt.Errorf("missing required field 'remote_port'")
-	}
-
-	// Validate field types
-	if _, ok := entryMap["host"].(string); !ok {
-		return fmt.Errorf("field 'host' must be a string")
-	}
-	if _, ok := entryMap["type"].(string); !ok {
-		return fmt.Errorf("field 'type' must be a string")
-	}
-
-	// remote_port can be stored as float64 in JSON
-	switch rp := entryMap["remote_port"].(type) {
-	case float64:
-		// Valid
-	case int:
-		// Valid
-	default:
-		return fmt.Errorf("field 'remote_port' must be a number, got %T", rp)
-	}
-
-	// Optional fields validation
-	if localPort, exists := entryMap["local_port"]; exists {
-		switch localPort.(type) {
-		case float64, int:
-			// Valid
-		default:
-			return fmt.Errorf("field 'local_port' must be a number, got %T", localPort)
-		}
-	}
-
-	if tags, exists := entryMap["tags"]; exists {
-		if _, ok := tags.([]interface{}); !ok {
-			return fmt.Errorf("field 'tags' must be an array")
-		}
-	}
-
-	return nil
-}
-
 // inventoryCmd represents the inventory command
 var inventoryCmd = &cobra.Command{
 	Use:   "inventory",
@@ -124,8 +72,9 @@ var inventoryCmd = &cobra.Command{
 			return nil
 		}
 
-		// Known inventory types that should always be available, even if empty/deleted
-		knownTypes := []string{"db", "node", "script"}
+		// Known inventory types that should always be available, even if empty/deleted.
+		// Scripts are not stored in the inventory; they are managed by 'tsukuyo script'.
+		knownTypes := []string{"db", "node"}
 		isKnownType := false
 		for _, knownType := range knownTypes {
 			if typeName == knownType {
@@ -143,13 +92,18 @@ var inventoryCmd = &cobra.Command{
 		keys, err := hi.List("")
 		if err == nil {
 			for _, key := range keys {
-				if key == typeName {
+				if key == typeName && !isReservedKey(key) {
 					// This is a dynamic type command
 					return handleDynamicTypeCommand(cmd, hi, args)
 				}
 			}
 		}
 
+		if typeName == "script" {
+			fmt.Fprintln(cmd.OutOrStdout(), "Scripts are not part of the inventory. Use 'tsukuyo script list' instead.")
+			return nil
+		}
+
 		// Not a dynamic type, show help
 		showInventoryHelp(cmd)
 		return nil
@@ -163,6 +117,10 @@ var (
 
 var getDataDir = func() string {
 	dataDirOnce.Do(func() {
+		if dir := configDataDir(); dir != "" {
+			cachedDataDir = dir
+			return
+		}
 		home, err := os.UserHomeDir()
 		if err != nil {
 			home = "." // fallback
@@ -172,41 +130,194 @@ var getDataDir = func() string {
 	return cachedDataDir
 }
 
-// Migration command: copy .data inventory files to ~/.tsukuyo
+// legacyDataDir is where older versions of tsukuyo kept their inventory files
+var legacyDataDir = ".data"
+
+// Migration command: merge .data inventory files into the hierarchical inventory
 var inventoryMigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Migrate inventory data from .data to ~/.tsukuyo",
+	Long: `Merge the legacy .data/db-inventory.json and .data/node-inventory.json files
+into the hierarchical inventory. Entries that already exist with a different value
+are handled according to --on-conflict (prompts if empty).
+
+With --verify each legacy file is re-read afterwards and compared with the
+migrated entries; a mismatch counts as a failed file.
+
+Once every file has been migrated you are asked whether to delete the .data
+directory; --yes deletes it without asking.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		home, err := os.UserHomeDir()
+		hi, err := getHierarchicalInventory()
 		if err != nil {
-			fmt.Fprintln(cmd.OutOrStdout(), "Could not determine home directory:", err)
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to initialize hierarchical inventory:", err)
 			return
 		}
-		oldDir := ".data"
-		newDir := home + "/.tsukuyo"
+
 		files := []string{"db-inventory.json", "node-inventory.json"}
+		found, failed := 0, 0
 		for _, f := range files {
-			oldPath := oldDir + "/" + f
-			newPath := newDir + "/" + f
-			if _, err := os.Stat(oldPath); err == nil {
-				b, err := os.ReadFile(oldPath)
-				if err != nil {
-					fmt.Fprintln(cmd.OutOrStdout(), "Failed to read", oldPath, ":", err)
-					continue
-				}
-				err = os.WriteFile(newPath, b, 0644)
-				if err != nil {
-					fmt.Fprintln(cmd.OutOrStdout(), "Failed to write", newPath, ":", err)
-					continue
-				}
-				fmt.Fprintln(cmd.OutOrStdout(), "Migrated", f, "to", newPath)
+			oldPath := filepath.Join(legacyDataDir, f)
+			if _, err := os.Stat(oldPath); err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "No", f, "found in", legacyDataDir)
+				continue
+			}
+			found++
+
+			b, err := os.ReadFile(oldPath)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to read", oldPath, ":", err)
+				failed++
+				continue
+			}
+			var parsed interface{}
+			if err := json.Unmarshal(b, &parsed); err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to parse", oldPath, ":", err)
+				failed++
+				continue
+			}
+
+			typeName := strings.TrimSuffix(f, "-inventory.json")
+			entries := importEntries(map[string]interface{}{typeName: parsed})
+			entries, err = resolveImportConflicts(cmd, hi, entries, onConflict)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to migrate", f, ":", err)
+				failed++
+				continue
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Nothing to migrate from", f)
+			} else if err := hi.SetBulk(entries); err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to migrate", f, ":", err)
+				failed++
+				continue
 			} else {
-				fmt.Fprintln(cmd.OutOrStdout(), "No", f, "found in", oldDir)
+				fmt.Fprintf(cmd.OutOrStdout(), "Migrated %d entries from %s into %s\n", len(entries), f, getDataDir())
+			}
+
+			if migrateVerify && !verifyMigration(cmd, hi, typeName, oldPath) {
+				failed++
+			}
+		}
+
+		if found == 0 {
+			return
+		}
+		if failed > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d of %d file(s) failed to migrate; keeping %s\n", failed, found, legacyDataDir)
+			return
+		}
+		if !migrateYes {
+			ok, err := migrateCleanupConfirmer(legacyDataDir)
+			if err != nil || !ok {
+				fmt.Fprintln(cmd.OutOrStdout(), "Keeping", legacyDataDir)
+				return
 			}
 		}
+		removeLegacyDataDir(cmd, legacyDataDir)
 	},
 }
 
+var (
+	migrateYes    bool
+	migrateVerify bool
+)
+
+// verifyMigration re-reads a legacy file and compares each of its entries
+// with the hierarchical entry of the same name, reporting any differences.
+// Entries that only exist in the hierarchical inventory are not compared.
+func verifyMigration(cmd *cobra.Command, hi *inventory.HierarchicalInventory, typeName, oldPath string) bool {
+	out := cmd.OutOrStdout()
+	name := filepath.Base(oldPath)
+
+	b, err := os.ReadFile(oldPath)
+	if err != nil {
+		fmt.Fprintln(out, "Failed to verify", name, ":", err)
+		return false
+	}
+	var source interface{}
+	if err := json.Unmarshal(b, &source); err != nil {
+		fmt.Fprintln(out, "Failed to verify", name, ":", err)
+		return false
+	}
+
+	migrated, _ := hi.Query(typeName)
+	migrated, err = inventory.NormalizeValue(migrated)
+	if err != nil {
+		fmt.Fprintln(out, "Failed to verify", name, ":", err)
+		return false
+	}
+	sourceMap, sourceIsMap := source.(map[string]interface{})
+	migratedMap, migratedIsMap := migrated.(map[string]interface{})
+	if sourceIsMap && migratedIsMap {
+		compared := make(map[string]interface{}, len(sourceMap))
+		for key := range sourceMap {
+			if value, ok := migratedMap[key]; ok {
+				compared[key] = value
+			}
+		}
+		migrated = compared
+	}
+
+	diffs := inventory.DiffData(map[string]interface{}{typeName: source}, map[string]interface{}{typeName: migrated})
+	if len(diffs) == 0 {
+		fmt.Fprintf(out, "Migration verified: %s ✓\n", name)
+		return true
+	}
+	fmt.Fprintf(out, "Mismatch detected for %s: %d differences\n", name, len(diffs))
+	for _, diff := range diffs {
+		fmt.Fprintf(out, "  %s %s\n", diff.Type, diff.Path)
+	}
+	return false
+}
+
+// migrateCleanupConfirmer asks whether to delete the migrated legacy data directory
+var migrateCleanupConfirmer = func(dir string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Migration complete. Delete %s", dir),
+		IsConfirm: true,
+	}
+	if _, err := prompt.Run(); err != nil {
+		if err == promptui.ErrAbort {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// removeLegacyDataDir deletes every file in dir and then dir itself,
+// printing each removed path and a summary of anything left behind
+func removeLegacyDataDir(cmd *cobra.Command, dir string) {
+	out := cmd.OutOrStdout()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Fprintln(out, "Failed to read", dir, ":", err)
+		return
+	}
+
+	var failures []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		fmt.Fprintln(out, "Deleted", path)
+	}
+	if len(failures) > 0 {
+		fmt.Fprintf(out, "Failed to delete %d path(s), keeping %s:\n", len(failures), dir)
+		for _, failure := range failures {
+			fmt.Fprintln(out, "  -", failure)
+		}
+		return
+	}
+	if err := os.Remove(dir); err != nil {
+		fmt.Fprintln(out, "Failed to delete", dir, ":", err)
+		return
+	}
+	fmt.Fprintln(out, "Deleted", dir)
+}
+
 func init() {
 	// Add flags for db set command
 	inventoryCmd.PersistentFlags().StringVar(&dbSetType, "type", "", "Database type (e.g., postgres, redis, mongodb)")
@@ -214,6 +325,19 @@ func init() {
 	inventoryCmd.PersistentFlags().IntVar(&dbSetLocalPort, "local-port", 0, "Local port number (optional)")
 	inventoryCmd.PersistentFlags().StringVar(&dbSetTags, "tags", "", "Comma-separated tags")
 
+	// '<type> list/get/set' run on inventoryCmd itself, so their filters are
+	// local flags rather than persistent ones inherited by query, set, export and so on
+	inventoryCmd.Flags().StringVar(&dbEnv, "env", "", "Environment of db entries: filters db list/get, and is stored by db set (e.g., prod, staging, dev)")
+	inventoryCmd.Flags().BoolVar(&nodeListExtended, "extended", false, "With node list, show a table with host, user, port and when each node was last connected to")
+	inventoryCmd.Flags().StringVar(&nodeOSFilter, "os-filter", "", "Only list node entries with this OS: linux, darwin or windows")
+	inventoryCmd.Flags().StringSliceVar(&listAllTags, "tag", nil, "With list, only show entries that have all of these tags (repeatable)")
+	inventoryCmd.Flags().StringSliceVar(&listAnyTags, "tag-or", nil, "With list, only show entries that have at least one of these tags")
+	inventoryCmd.Flags().StringSliceVar(&listExcludeTags, "exclude-tag", nil, "With list, hide entries that have any of these tags")
+	inventoryCmd.Flags().StringSliceVar(&listExcludeTags, "not-tag", nil, "Alias for --exclude-tag")
+
+	inventoryMigrateCmd.Flags().StringVar(&onConflict, "on-conflict", "", "How to handle entries that already exist: skip, overwrite or error (prompts if empty)")
+	inventoryMigrateCmd.Flags().BoolVarP(&migrateYes, "yes", "y", false, "Delete the legacy .data directory after a successful migration without asking")
+	inventoryMigrateCmd.Flags().BoolVar(&migrateVerify, "verify", false, "Re-read each legacy file after migrating and check its entries match the hierarchical inventory")
 	inventoryCmd.AddCommand(inventoryMigrateCmd)
 
 	rootCmd.AddCommand(inventoryCmd)
@@ -230,13 +354,24 @@ func showInventoryHelp(cmd *cobra.Command) {
 		return
 	}
 
-	// Get top-level keys (inventory types)
-	keys, err := hi.List("")
+	// Get top-level keys (inventory types), skipping reserved keys like _aliases
+	allKeys, err := hi.List("")
+	var keys []string
+	for _, key := range allKeys {
+		if !isReservedKey(key) {
+			keys = append(keys, key)
+		}
+	}
 	if err != nil || len(keys) == 0 {
-		fmt.Fprintln(out, "No inventory data found.")
+		fmt.Fprintln(out, "No inventory data found. Run 'tsukuyo inventory init' to set up a skeleton inventory.")
 		fmt.Fprintln(out, "\nQuick start:")
-		fmt.Fprintln(out, "  tsukuyo inventory set db.server1.host \"example.com\"")
+		for _, typeName := range hi.RegisteredTypes() {
+			if fields := hi.RequiredFields(typeName); len(fields) > 0 {
+				fmt.Fprintf(out, "  tsukuyo inventory set %s.<name> '%s'\n", typeName, requiredFieldsExample(fields))
+			}
+		}
 		fmt.Fprintln(out, "  tsukuyo inventory set node.web1.host \"192.168.1.10\"")
+		printRegisteredTypes(out, hi)
 		return
 	}
 
@@ -245,6 +380,9 @@ func showInventoryHelp(cmd *cobra.Command) {
 	fmt.Fprintln(out, "Available inventory types:")
 	for _, key := range keys {
 		fmt.Fprintf(out, "  - %-10s (tsukuyo inventory %s list)\n", key, key)
+		if fields := hi.RequiredFields(key); len(fields) > 0 {
+			fmt.Fprintf(out, "    %-10s Required fields: %s\n", "", strings.Join(fields, ", "))
+		}
 	}
 
 	fmt.Fprintln(out)
@@ -257,6 +395,7 @@ func showInventoryHelp(cmd *cobra.Command) {
 	fmt.Fprintln(out, "  tsukuyo inventory set <path> <value>    # Set a value")
 	fmt.Fprintln(out, "  tsukuyo inventory delete <path>         # Delete a value")
 	fmt.Fprintln(out, "  tsukuyo inventory list [path]           # List keys at path")
+	fmt.Fprintln(out, "  tsukuyo inventory alias set <a> <path>  # Alias a long path")
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "🏷️  Type-specific Commands:")
 	for _, key := range keys {
@@ -266,6 +405,32 @@ func showInventoryHelp(cmd *cobra.Command) {
 	}
 }
 
+// printRegisteredTypes describes the types that have a validator or schema
+func printRegisteredTypes(out io.Writer, hi *inventory.HierarchicalInventory) {
+	types := hi.RegisteredTypes()
+	if len(types) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\nRegistered types:")
+	for _, typeName := range types {
+		fields := hi.RequiredFields(typeName)
+		if len(fields) == 0 {
+			fmt.Fprintf(out, "  - %s\n", typeName)
+			continue
+		}
+		fmt.Fprintf(out, "  - %-10s Required fields: %s\n", typeName, strings.Join(fields, ", "))
+	}
+}
+
+// requiredFieldsExample renders a JSON object with a placeholder for each field
+func requiredFieldsExample(fields []string) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = fmt.Sprintf("%q: \"<%s>\"", field, field)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
 // handleDynamicTypeCommand handles commands for dynamically discovered inventory types
 func handleDynamicTypeCommand(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args []string) error {
 	out := cmd.OutOrStdout()
@@ -286,8 +451,19 @@ func handleDynamicTypeCommand(cmd *cobra.Command, hi *inventory.HierarchicalInve
 		fmt.Fprintf(out, "  list                    # List all %s entries\n", typeName)
 		fmt.Fprintf(out, "  get <n>              # Get specific %s entry\n", typeName)
 		fmt.Fprintf(out, "  set <n> <value>      # Set %s entry\n", typeName)
+		if typeName == "db" {
+			fmt.Fprintf(out, "  copy <src> <dst>     # Clone a %s entry\n", typeName)
+		}
+		if typeName == "node" {
+			fmt.Fprintf(out, "  ssh-fingerprint <n>  # Capture and store the SSH host key\n")
+			fmt.Fprintf(out, "  port-scan <n>        # Probe the node's host for open ports\n")
+			fmt.Fprintf(out, "  detect-os <n>        # Store the node's OS type and version (uname -sr)\n")
+		}
 		fmt.Fprintf(out, "\nOr use hierarchical queries:\n")
 		fmt.Fprintf(out, "  tsukuyo inventory query %s.<n>.<field>\n", typeName)
+		if fields := hi.RequiredFields(typeName); len(fields) > 0 {
+			fmt.Fprintf(out, "\nRequired fields: %s\n", strings.Join(fields, ", "))
+		}
 		return nil
 	}
 
@@ -301,6 +477,22 @@ func handleDynamicTypeCommand(cmd *cobra.Command, hi *inventory.HierarchicalInve
 		return handleTypeGet(cmd, hi, typeName, subSubArgs)
 	case "set":
 		return handleTypeSet(cmd, hi, typeName, subSubArgs)
+	case "copy":
+		if typeName == "db" {
+			return handleDbCopy(cmd, hi, subSubArgs)
+		}
+		fallthrough
+	case "ssh-fingerprint", "port-scan", "detect-os":
+		if typeName == "node" {
+			switch subCommand {
+			case "port-scan":
+				return handleNodePortScan(cmd, hi, subSubArgs)
+			case "detect-os":
+				return handleNodeDetectOS(cmd, hi, subSubArgs)
+			}
+			return handleNodeSSHFingerprint(cmd, hi, subSubArgs)
+		}
+		fallthrough
 	default:
 		errorMsg := fmt.Sprintf("unknown subcommand '%s'. Available: list, get, set", subCommand)
 		fmt.Fprintln(out, errorMsg)
@@ -331,6 +523,48 @@ func handleTypeList(cmd *cobra.Command, hi *inventory.HierarchicalInventory, typ
 		return nil
 	}
 
+	if typeName == "db" && dbEnv != "" {
+		keys = filterDbKeysByEnv(hi, keys, dbEnv)
+		if len(keys) == 0 {
+			fmt.Fprintf(out, "No db entries found in environment '%s'.\n", dbEnv)
+			return nil
+		}
+	}
+
+	if typeName == "node" && nodeOSFilter != "" {
+		if err := validateNodeOSFilter(nodeOSFilter); err != nil {
+			return err
+		}
+		keys = filterNodeKeysByOS(hi, keys, nodeOSFilter)
+		if len(keys) == 0 {
+			fmt.Fprintf(out, "No node entries found with OS '%s'.\n", nodeOSFilter)
+			return nil
+		}
+	}
+
+	if len(listAllTags) > 0 || len(listAnyTags) > 0 {
+		keys = filterKeysByTags(hi, typeName, keys, listAllTags, listAnyTags)
+		if len(keys) == 0 {
+			fmt.Fprintf(out, "No %s entries found with the requested tags.\n", typeName)
+			return nil
+		}
+	}
+
+	if len(listExcludeTags) > 0 {
+		keys = filterKeysExcludingTags(hi, typeName, keys, listExcludeTags)
+		if len(keys) == 0 {
+			fmt.Fprintf(out, "No %s entries found without tags %s.\n", typeName, strings.Join(listExcludeTags, ", "))
+			return nil
+		}
+	}
+
+	if listCheckConnectivity {
+		return handleTypeListConnectivity(cmd, hi, typeName, keys)
+	}
+	if typeName == "node" && nodeListExtended {
+		return printNodeListExtended(cmd, hi, keys)
+	}
+
 	fmt.Fprintf(out, "Available %s entries:\n", typeName)
 	for _, key := range keys {
 		fmt.Fprintf(out, "  - %s\n", key)
@@ -338,6 +572,22 @@ func handleTypeList(cmd *cobra.Command, hi *inventory.HierarchicalInventory, typ
 	return nil
 }
 
+// filterDbKeysByEnv returns the db entry names whose environment is env (case-insensitive)
+func filterDbKeysByEnv(hi *inventory.HierarchicalInventory, keys []string, env string) []string {
+	var filtered []string
+	for _, key := range keys {
+		result, err := hi.Query("db." + key)
+		if err != nil {
+			continue
+		}
+		entry, err := inventory.ParseDbEntry(result)
+		if err == nil && strings.EqualFold(entry.Environment, env) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
+
 func handleTypeGet(cmd *cobra.Command, hi *inventory.HierarchicalInventory, typeName string, args []string) error {
 	out := cmd.OutOrStdout()
 
@@ -356,6 +606,9 @@ func handleTypeGet(cmd *cobra.Command, hi *inventory.HierarchicalInventory, type
 	} else {
 		// Interactive selection
 		keys, err := hi.List(typeName)
+		if typeName == "db" && dbEnv != "" {
+			keys = filterDbKeysByEnv(hi, keys, dbEnv)
+		}
 		if err != nil || len(keys) == 0 {
 			fmt.Fprintf(out, "No %s entries found.\n", typeName)
 			return nil
@@ -376,6 +629,19 @@ func handleTypeGet(cmd *cobra.Command, hi *inventory.HierarchicalInventory, type
 		fmt.Fprintf(out, "Entry '%s' not found in %s inventory.\n", name, typeName)
 		return nil
 	}
+	if typeName == "db" && dbEnv != "" && len(filterDbKeysByEnv(hi, []string{name}, dbEnv)) == 0 {
+		fmt.Fprintf(out, "Entry '%s' not found in %s inventory for environment '%s'.\n", name, typeName, dbEnv)
+		return nil
+	}
+
+	// Entries set in this process may still be a DbInventoryEntry struct;
+	// print them the same as the generic form they are saved as
+	switch result.(type) {
+	case inventory.DbInventoryEntry, *inventory.DbInventoryEntry:
+		if normalized, err := inventory.NormalizeValue(result); err == nil {
+			result = normalized
+		}
+	}
 
 	fmt.Fprintf(out, "%s.%s:\n", typeName, name)
 	switch v := result.(type) {
@@ -490,12 +756,12 @@ func handleDbSet(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args [
 	// Get values from flags or defaults
 	dbType := dbSetType
 	if dbType == "" {
-		dbType = "postgres" // default
+		dbType = appConfig.GetString("default_db_type")
 	}
 
 	remotePort := dbSetRemotePort
 	if remotePort == 0 {
-		remotePort = 5432 // default
+		remotePort = appConfig.GetInt("default_db_port")
 	}
 
 	localPort := dbSetLocalPort
@@ -512,12 +778,12 @@ func handleDbSet(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args [
 	// If we're missing critical values and not provided via flags, go interactive for the rest
 	if (!hasName || !hasHost) && (dbSetType == "" || dbSetRemotePort == 0) {
 		if dbSetType == "" {
-			prompt := promptui.Prompt{Label: "Type (e.g., postgres, redis)", Default: "postgres"}
+			prompt := promptui.Prompt{Label: "Type (e.g., postgres, redis)", Default: appConfig.GetString("default_db_type")}
 			dbType, _ = prompt.Run()
 		}
 
 		if dbSetRemotePort == 0 {
-			prompt := promptui.Prompt{Label: "Remote Port", Default: "5432"}
+			prompt := promptui.Prompt{Label: "Remote Port", Default: strconv.Itoa(appConfig.GetInt("default_db_port"))}
 			remotePortStr, _ := prompt.Run()
 			remotePort, _ = strconv.Atoi(remotePortStr)
 		}
@@ -543,11 +809,12 @@ func handleDbSet(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args [
 	}
 
 	entry := DbInventoryEntry{
-		Host:       host,
-		Type:       dbType,
-		RemotePort: remotePort,
-		LocalPort:  localPort,
-		Tags:       tags,
+		Host:        host,
+		Type:        dbType,
+		RemotePort:  remotePort,
+		LocalPort:   localPort,
+		Tags:        tags,
+		Environment: dbEnv,
 	}
 
 	path := fmt.Sprintf("db.%s", name)
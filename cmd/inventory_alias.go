@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// aliasesKey is the reserved top-level key that stores path aliases
+const aliasesKey = "_aliases"
+
+// isReservedKey reports whether a top-level key is used internally by tsukuyo
+// rather than being an inventory type
+func isReservedKey(key string) bool {
+	return strings.HasPrefix(key, "_")
+}
+
+// withoutReservedKeys drops reserved keys such as _meta from top-level keys
+func withoutReservedKeys(keys []string) []string {
+	var filtered []string
+	for _, key := range keys {
+		if !isReservedKey(key) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
+
+// loadAliases returns the alias map stored in the inventory, or an empty map
+func loadAliases(hi *inventory.HierarchicalInventory) map[string]string {
+	aliases := make(map[string]string)
+	result, err := hi.Query(aliasesKey)
+	if err != nil {
+		return aliases
+	}
+	if m, ok := result.(map[string]interface{}); ok {
+		for name, target := range m {
+			if s, ok := target.(string); ok {
+				aliases[name] = s
+			}
+		}
+	}
+	return aliases
+}
+
+// expandAlias replaces the first segment of a path with its alias target, if any
+func expandAlias(hi *inventory.HierarchicalInventory, path string) string {
+	if path == "" || isReservedKey(path) {
+		return path
+	}
+	head, rest, hasRest := strings.Cut(path, ".")
+	target, ok := loadAliases(hi)[head]
+	if !ok {
+		return path
+	}
+	if hasRest {
+		return target + "." + rest
+	}
+	return target
+}
+
+var inventoryAliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage short aliases for long inventory paths",
+	Long: `Manage aliases for frequently used inventory paths. An alias can be used
+as the first segment of any query or set path.
+
+Examples:
+  tsukuyo inventory alias set prod-db db.prod-postgres-master
+  tsukuyo inventory query prod-db.host
+  tsukuyo inventory alias list
+  tsukuyo inventory alias delete prod-db`,
+}
+
+var inventoryAliasSetCmd = &cobra.Command{
+	Use:   "set <alias> <path>",
+	Short: "Create or update an alias",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, target := args[0], args[1]
+		if name == "" || strings.ContainsAny(name, ".[]") || isReservedKey(name) {
+			return fmt.Errorf("invalid alias name '%s'", name)
+		}
+		if target == "" {
+			return fmt.Errorf("alias target must not be empty")
+		}
+
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		if err := hi.Set(aliasesKey+"."+name, target); err != nil {
+			return fmt.Errorf("failed to set alias: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Alias %s -> %s\n", name, target)
+		return nil
+	},
+}
+
+var inventoryAliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all aliases",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		aliases := loadAliases(hi)
+		if len(aliases) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No aliases defined.")
+			return nil
+		}
+
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n", name, aliases[name])
+		}
+		return nil
+	},
+}
+
+var inventoryAliasDeleteCmd = &cobra.Command{
+	Use:   "delete <alias>",
+	Short: "Delete an alias",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		name := args[0]
+		if _, ok := loadAliases(hi)[name]; !ok {
+			return fmt.Errorf("alias '%s' not found", name)
+		}
+		if err := hi.Delete(aliasesKey + "." + name); err != nil {
+			return fmt.Errorf("failed to delete alias: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted alias %s\n", name)
+		return nil
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryAliasCmd)
+	inventoryAliasCmd.AddCommand(inventoryAliasSetCmd)
+	inventoryAliasCmd.AddCommand(inventoryAliasListCmd)
+	inventoryAliasCmd.AddCommand(inventoryAliasDeleteCmd)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// commentsKey is the reserved top-level key that stores comments, keyed by
+// the full path they describe
+const commentsKey = "_comments"
+
+// commentPath returns the inventory path of the comment for path
+func commentPath(path string) string {
+	return commentsKey + `["` + path + `"]`
+}
+
+// loadComments returns the comment map stored in the inventory, or an empty map
+func loadComments(hi *inventory.HierarchicalInventory) map[string]string {
+	comments := make(map[string]string)
+	result, err := hi.Query(commentsKey)
+	if err != nil {
+		return comments
+	}
+	if m, ok := result.(map[string]interface{}); ok {
+		for path, text := range m {
+			if s, ok := text.(string); ok {
+				comments[path] = s
+			}
+		}
+	}
+	return comments
+}
+
+var inventoryCommentCmd = &cobra.Command{
+	Use:   "comment",
+	Short: "Attach human-readable comments to inventory paths",
+	Long: `Manage comments for inventory paths. Comments are stored under _comments
+and shown inline by query and list with --verbose.
+
+Examples:
+  tsukuyo inventory comment set db.server1 "Primary production database, do not touch"
+  tsukuyo inventory comment get db.server1
+  tsukuyo inventory comment delete db.server1
+  tsukuyo inventory list db --verbose`,
+}
+
+var inventoryCommentSetCmd = &cobra.Command{
+	Use:   "set <path> <text>",
+	Short: "Set the comment of a path",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		path := expandAlias(hi, args[0])
+		if path == "" || isReservedKey(path) || strings.Contains(path, `"`) {
+			return fmt.Errorf("invalid path '%s'", args[0])
+		}
+		text := strings.Join(args[1:], " ")
+		if err := hi.Set(commentPath(path), text); err != nil {
+			return fmt.Errorf("failed to set comment: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Comment set for %s\n", path)
+		return nil
+	},
+}
+
+var inventoryCommentGetCmd = &cobra.Command{
+	Use:   "get <path>",
+	Short: "Show the comment of a path",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		path := expandAlias(hi, args[0])
+		text, ok := loadComments(hi)[path]
+		if !ok {
+			return fmt.Errorf("no comment for '%s'", path)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), text)
+		return nil
+	},
+}
+
+var inventoryCommentDeleteCmd = &cobra.Command{
+	Use:   "delete <path>",
+	Short: "Delete the comment of a path",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		path := expandAlias(hi, args[0])
+		if _, ok := loadComments(hi)[path]; !ok {
+			return fmt.Errorf("no comment for '%s'", path)
+		}
+		if err := hi.Delete(commentPath(path)); err != nil {
+			return fmt.Errorf("failed to delete comment: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted comment for %s\n", path)
+		return nil
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryCommentCmd)
+	inventoryCommentCmd.AddCommand(inventoryCommentSetCmd)
+	inventoryCommentCmd.AddCommand(inventoryCommentGetCmd)
+	inventoryCommentCmd.AddCommand(inventoryCommentDeleteCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryComments(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		listVerbose = false
+		queryVerbose = false
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1"}))
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	assert.NoError(t, inventoryCommentSetCmd.RunE(cmd, []string{"servers.web1", "Primary", "web", "server"}))
+	assert.Contains(t, buf.String(), "Comment set for servers.web1")
+
+	// Comments are kept apart from the data, keyed by the full path
+	comments, err := hi.Query(commentsKey)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"servers.web1": "Primary web server"}, comments)
+	result, err := hi.Query("servers.web1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"host": "10.0.0.1"}, result)
+
+	buf.Reset()
+	assert.NoError(t, inventoryCommentGetCmd.RunE(cmd, []string{"servers.web1"}))
+	assert.Equal(t, "Primary web server\n", buf.String())
+
+	// --verbose shows comments inline
+	var listBuf bytes.Buffer
+	inventoryListCmd.SetOut(&listBuf)
+	defer inventoryListCmd.SetOut(nil)
+	listVerbose = true
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Equal(t, "Keys at 'servers':\n- web1 (1 field)  # Primary web server\n", listBuf.String())
+
+	queryVerbose = true
+	output := runQueryCmd(t, nil, "servers.web1.host")
+	assert.Equal(t, "10.0.0.1\n", output)
+	output = runQueryCmd(t, nil, "servers.web1")
+	assert.Contains(t, output, "# Primary web server\n{")
+
+	buf.Reset()
+	assert.NoError(t, inventoryCommentDeleteCmd.RunE(cmd, []string{"servers.web1"}))
+	assert.Contains(t, buf.String(), "Deleted comment for servers.web1")
+	assert.EqualError(t, inventoryCommentGetCmd.RunE(cmd, []string{"servers.web1"}), "no comment for 'servers.web1'")
+	assert.EqualError(t, inventoryCommentDeleteCmd.RunE(cmd, []string{"servers.web1"}), "no comment for 'servers.web1'")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var compareOutput string
+
+var inventoryCompareCmd = &cobra.Command{
+	Use:   "compare <file1> <file2>",
+	Short: "Compare two inventory snapshots",
+	Long: `Compare two inventory snapshot files and show the paths that were added,
+modified or removed going from file1 to file2. Files ending in .gob are read
+as gob, anything else as JSON.
+
+Examples:
+  tsukuyo inventory compare backup-1700000000.json hierarchical-inventory.json
+  tsukuyo inventory compare staging.json prod.json --output json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if compareOutput != "text" && compareOutput != "json" {
+			return fmt.Errorf("unsupported output format: %s", compareOutput)
+		}
+
+		oldInv, err := loadInventorySnapshot(args[0])
+		if err != nil {
+			return err
+		}
+		newInv, err := loadInventorySnapshot(args[1])
+		if err != nil {
+			return err
+		}
+
+		entries, err := oldInv.Diff(newInv)
+		if err != nil {
+			return fmt.Errorf("failed to compare inventories: %w", err)
+		}
+
+		if compareOutput == "json" {
+			if entries == nil {
+				entries = []inventory.DiffEntry{}
+			}
+			out, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(out))
+			return nil
+		}
+
+		printDiff(cmd.OutOrStdout(), entries)
+		return nil
+	},
+}
+
+// loadInventorySnapshot loads a standalone inventory file
+func loadInventorySnapshot(path string) (*inventory.HierarchicalInventory, error) {
+	format := "json"
+	if strings.EqualFold(filepath.Ext(path), ".gob") {
+		format = "gob"
+	}
+
+	hi, err := inventory.NewHierarchicalInventory(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	if err := hi.LoadFromFile(path, format); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return hi, nil
+}
+
+// printDiff writes diff entries in a human-readable form
+func printDiff(out io.Writer, entries []inventory.DiffEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No differences found.")
+		return
+	}
+
+	for _, e := range entries {
+		switch e.Type {
+		case inventory.DiffAdded:
+			fmt.Fprintf(out, "+ %s: %s\n", e.Path, formatDiffValue(e.New))
+		case inventory.DiffRemoved:
+			fmt.Fprintf(out, "- %s: %s\n", e.Path, formatDiffValue(e.Old))
+		case inventory.DiffModified:
+			fmt.Fprintf(out, "~ %s: %s -> %s\n", e.Path, formatDiffValue(e.Old), formatDiffValue(e.New))
+		}
+	}
+}
+
+func formatDiffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryCompareCmd)
+	inventoryCompareCmd.Flags().StringVar(&compareOutput, "output", "text", "Output format: text or json")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryCompare(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldFile := filepath.Join(tmpDir, "old.json")
+	newFile := filepath.Join(tmpDir, "new.json")
+	assert.NoError(t, os.WriteFile(oldFile, []byte(`{"db":{"a":{"port":5432},"b":{"host":"b"}}}`), 0644))
+	assert.NoError(t, os.WriteFile(newFile, []byte(`{"db":{"a":{"port":5433},"c":{"host":"c"}}}`), 0644))
+
+	var buf bytes.Buffer
+	inventoryCompareCmd.SetOut(&buf)
+	defer inventoryCompareCmd.SetOut(nil)
+	defer func() { compareOutput = "text" }()
+
+	compareOutput = "text"
+	assert.NoError(t, inventoryCompareCmd.RunE(inventoryCompareCmd, []string{oldFile, newFile}))
+	assert.Equal(t, "~ db.a.port: 5432 -> 5433\n- db.b: {\"host\":\"b\"}\n+ db.c: {\"host\":\"c\"}\n", buf.String())
+
+	buf.Reset()
+	compareOutput = "json"
+	assert.NoError(t, inventoryCompareCmd.RunE(inventoryCompareCmd, []string{oldFile, newFile}))
+	var entries []inventory.DiffEntry
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+	assert.Len(t, entries, 3)
+	assert.Equal(t, inventory.DiffRemoved, entries[1].Type)
+
+	buf.Reset()
+	compareOutput = "text"
+	assert.NoError(t, inventoryCompareCmd.RunE(inventoryCompareCmd, []string{oldFile, oldFile}))
+	assert.Equal(t, "No differences found.\n", buf.String())
+
+	assert.Error(t, inventoryCompareCmd.RunE(inventoryCompareCmd, []string{oldFile, filepath.Join(tmpDir, "missing.json")}))
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// maxParallelDials bounds the number of concurrent connectivity checks in list
+const maxParallelDials = 20
+
+var (
+	listCheckConnectivity bool
+	connectivityTimeout   time.Duration
+)
+
+// tcpDialer checks whether addr accepts TCP connections
+var tcpDialer = func(addr string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// connTarget is an inventory entry and the address used to check it
+type connTarget struct {
+	Name string
+	Addr string
+}
+
+// checkTCPConnectivity dials every target in parallel and reports which ones
+// are reachable, in the order of targets. Targets without an address are
+// reported as unreachable.
+func checkTCPConnectivity(targets []connTarget, timeout time.Duration) []bool {
+	online := make([]bool, len(targets))
+	sem := make(chan struct{}, maxParallelDials)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		if target.Addr == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			online[i] = tcpDialer(addr, timeout) == nil
+		}(i, target.Addr)
+	}
+	wg.Wait()
+	return online
+}
+
+// nodeConnTarget returns the host:port a node is checked against
+func nodeConnTarget(hi *inventory.HierarchicalInventory, name string) connTarget {
+	target := connTarget{Name: name}
+	result, err := hi.Query("node." + name)
+	if err != nil {
+		return target
+	}
+	nodeData, ok := result.(map[string]interface{})
+	if !ok {
+		return target
+	}
+	if host, port := nodeHostPort(nodeData); host != "" {
+		target.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	return target
+}
+
+// dbConnTarget returns the host:remote_port a db entry is checked against
+func dbConnTarget(hi *inventory.HierarchicalInventory, name string) connTarget {
+	target := connTarget{Name: name}
+	result, err := hi.Query("db." + name)
+	if err != nil {
+		return target
+	}
+	entry, err := inventory.ParseDbEntry(result)
+	if err != nil || entry.Host == "" {
+		return target
+	}
+	target.Addr = net.JoinHostPort(entry.Host, strconv.Itoa(entry.RemotePort))
+	return target
+}
+
+// handleTypeListConnectivity lists entries of a type with a STATUS column
+func handleTypeListConnectivity(cmd *cobra.Command, hi *inventory.HierarchicalInventory, typeName string, keys []string) error {
+	var targetFor func(*inventory.HierarchicalInventory, string) connTarget
+	var label string
+	switch typeName {
+	case "node":
+		targetFor, label = nodeConnTarget, "nodes"
+	case "db":
+		targetFor, label = dbConnTarget, "databases"
+	default:
+		return fmt.Errorf("--check-connectivity is not supported for %s entries", typeName)
+	}
+
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	targets := make([]connTarget, len(sorted))
+	for i, key := range sorted {
+		targets[i] = targetFor(hi, key)
+	}
+	online := checkTCPConnectivity(targets, connectivityTimeout)
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%-20s %-30s %-10s\n", "NAME", "ADDRESS", "STATUS")
+	up := 0
+	for i, target := range targets {
+		status := "✗ offline"
+		if online[i] {
+			status = "✓ online"
+			up++
+		}
+		addr := target.Addr
+		if addr == "" {
+			addr = "-"
+		}
+		fmt.Fprintf(out, "%-20s %-30s %-10s\n", target.Name, addr, status)
+	}
+	fmt.Fprintf(out, "\n%d/%d %s online\n", up, len(targets), label)
+	return nil
+}
+
+func init() {
+	// '<type> list' runs on inventoryCmd itself, so these are local flags
+	// rather than persistent ones inherited by query, set, delete and so on
+	inventoryCmd.Flags().BoolVar(&listCheckConnectivity, "check-connectivity", false, "With <type> list, check whether each entry is reachable and add a STATUS column")
+	inventoryCmd.Flags().DurationVar(&connectivityTimeout, "connectivity-timeout", 2*time.Second, "Dial timeout for --check-connectivity")
+}
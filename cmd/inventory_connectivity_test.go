@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubTCPDialer makes only the given addresses reachable
+func stubTCPDialer(t *testing.T, reachable ...string) {
+	t.Helper()
+	original := tcpDialer
+	tcpDialer = func(addr string, timeout time.Duration) error {
+		for _, r := range reachable {
+			if addr == r {
+				return nil
+			}
+		}
+		return fmt.Errorf("connection refused")
+	}
+	t.Cleanup(func() { tcpDialer = original })
+}
+
+func TestCheckTCPConnectivity(t *testing.T) {
+	stubTCPDialer(t, "10.0.0.1:22")
+	online := checkTCPConnectivity([]connTarget{
+		{Name: "a", Addr: "10.0.0.1:22"},
+		{Name: "b", Addr: "10.0.0.2:22"},
+		{Name: "c"},
+	}, time.Second)
+	assert.Equal(t, []bool{true, false, false}, online)
+}
+
+func TestNodeListCheckConnectivity(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	stubTCPDialer(t, "10.0.0.1:22", "10.0.0.3:2222")
+	listCheckConnectivity = true
+	defer func() { listCheckConnectivity = false }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1"}))
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "10.0.0.2"}))
+	assert.NoError(t, hi.Set("node.web3", map[string]interface{}{"host": "10.0.0.3", "port": float64(2222)}))
+
+	var buf bytes.Buffer
+	inventoryCmd.SetOut(&buf)
+	defer inventoryCmd.SetOut(nil)
+
+	assert.NoError(t, handleTypeList(inventoryCmd, hi, "node"))
+	output := buf.String()
+	assert.Contains(t, output, "STATUS")
+	assert.Regexp(t, `web1\s+10\.0\.0\.1:22\s+✓ online`, output)
+	assert.Regexp(t, `web2\s+10\.0\.0\.2:22\s+✗ offline`, output)
+	assert.Regexp(t, `web3\s+10\.0\.0\.3:2222\s+✓ online`, output)
+	assert.Contains(t, output, "2/3 nodes online")
+}
+
+func TestDbListCheckConnectivity(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	stubTCPDialer(t, "pg.internal:5432")
+	listCheckConnectivity = true
+	defer func() { listCheckConnectivity = false }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.pg", DbInventoryEntry{Host: "pg.internal", Type: "postgres", RemotePort: 5432}))
+	assert.NoError(t, hi.Set("db.cache", DbInventoryEntry{Host: "redis.internal", Type: "redis", RemotePort: 6379}))
+
+	var buf bytes.Buffer
+	inventoryCmd.SetOut(&buf)
+	defer inventoryCmd.SetOut(nil)
+
+	assert.NoError(t, handleTypeList(inventoryCmd, hi, "db"))
+	output := buf.String()
+	assert.Regexp(t, `pg\s+pg\.internal:5432\s+✓ online`, output)
+	assert.Regexp(t, `cache\s+redis\.internal:6379\s+✗ offline`, output)
+	assert.Contains(t, output, "1/2 databases online")
+
+	// Types without an address are not supported
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1"}))
+	assert.Error(t, handleTypeList(inventoryCmd, hi, "servers"))
+}
+
+func TestConnectivityFlagsAreLocalToTypeList(t *testing.T) {
+	assert.NotNil(t, inventoryCmd.Flags().Lookup("check-connectivity"))
+	assert.NotNil(t, inventoryCmd.Flags().Lookup("connectivity-timeout"))
+	for _, sub := range []string{"check-connectivity", "connectivity-timeout"} {
+		assert.Nil(t, inventorySetCmd.InheritedFlags().Lookup(sub), "inventory set should not accept --%s", sub)
+		assert.Nil(t, inventoryExportCmd.InheritedFlags().Lookup(sub), "inventory export should not accept --%s", sub)
+	}
+	// --timeout is the global inventory I/O timeout, not the dial timeout
+	assert.Same(t, rootCmd.PersistentFlags().Lookup("timeout"), inventorySetCmd.InheritedFlags().Lookup("timeout"))
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dbCopyIncrementLocalPort bool
+	dbCopyForce              bool
+)
+
+// handleDbCopy deep-copies db.<src> to db.<dst> and prints what differs
+func handleDbCopy(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: tsukuyo inventory db copy <src> <dst> [--increment-local-port] [--force]")
+	}
+	src, dst := args[0], args[1]
+	if src == dst {
+		return fmt.Errorf("source and destination are the same entry '%s'", src)
+	}
+
+	data, err := hi.Query("db." + src)
+	if err != nil {
+		return fmt.Errorf("db entry '%s' not found", src)
+	}
+	if _, err := hi.Query("db." + dst); err == nil && !dbCopyForce {
+		return fmt.Errorf("db entry '%s' already exists (use --force to overwrite)", dst)
+	}
+
+	source, err := copyDbEntry(data)
+	if err != nil {
+		return fmt.Errorf("failed to copy db entry '%s': %v", src, err)
+	}
+	clone, err := copyDbEntry(data)
+	if err != nil {
+		return fmt.Errorf("failed to copy db entry '%s': %v", src, err)
+	}
+
+	if dbCopyIncrementLocalPort {
+		entry, err := inventory.ParseDbEntry(clone)
+		if err != nil {
+			return err
+		}
+		localPort := entry.LocalPort
+		if localPort == 0 {
+			localPort = entry.RemotePort // tunnels default to the remote port
+		}
+		clone["local_port"] = float64(localPort + 1)
+	}
+
+	if err := hi.Set("db."+dst, clone); err != nil {
+		return fmt.Errorf("failed to save db entry '%s': %v", dst, err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Copied db.%s to db.%s\n", src, dst)
+	printDiff(out, inventory.DiffData(source, clone))
+	return nil
+}
+
+// copyDbEntry returns a deep copy of a stored db entry in its generic JSON form,
+// keeping any fields beyond the DbInventoryEntry ones
+func copyDbEntry(data interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, fmt.Errorf("not an object: %v", err)
+	}
+	return entry, nil
+}
+
+func init() {
+	// 'db copy' is dispatched by inventoryCmd itself, so these stay local to it
+	inventoryCmd.Flags().BoolVar(&dbCopyIncrementLocalPort, "increment-local-port", false, "With db copy, set the new entry's local port to the source's plus one")
+	inventoryCmd.Flags().BoolVar(&dbCopyForce, "force", false, "With db copy, overwrite an existing destination entry")
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,9 +26,10 @@ func setupIsolatedInventory(t *testing.T) (string, func()) {
 	}
 
 	// Reset the global inventory cache to force using the new directory
-	// We can't copy sync.Once, so we just reset the cache to nil
+	// We can't copy sync.Once, so we just reset the cache to nil and start a new once
 	originalCache := globalInventoryCache
 	globalInventoryCache = nil
+	inventoryCacheOnce = sync.Once{}
 
 	// Return a cleanup function to be called via defer
 	cleanup := func() {
@@ -101,7 +106,7 @@ func TestInventoryDbCommand_StructuredData(t *testing.T) {
 		{
 			name:     "get structured db entry",
 			args:     []string{"db", "get", "mongo-dev"},
-			contains: []string{"mongo-dev.internal", "mongodb", "27017", "27018", "[dev document]"},
+			contains: []string{`"host": "mongo-dev.internal"`, `"type": "mongodb"`, `"remote_port": 27017`, `"local_port": 27018`, `"dev"`, `"document"`},
 		},
 		{
 			name:     "get non-existent entry",
@@ -222,85 +227,6 @@ func TestDbInventorySetDefaults(t *testing.T) {
 	assert.Equal(t, 0, entry.LocalPort)     // default (0)
 }
 
-func TestValidateDbEntry(t *testing.T) {
-	tests := []struct {
-		name        string
-		entry       interface{}
-		expectError bool
-	}{
-		{
-			name: "valid entry",
-			entry: map[string]interface{}{
-				"host":        "test.com",
-				"type":        "postgres",
-				"remote_port": float64(5432),
-				"local_port":  float64(5433),
-				"tags":        []interface{}{"dev", "test"},
-			},
-			expectError: false,
-		},
-		{
-			name:        "not a map",
-			entry:       "invalid",
-			expectError: true,
-		},
-		{
-			name: "missing host",
-			entry: map[string]interface{}{
-				"type":        "postgres",
-				"remote_port": float64(5432),
-			},
-			expectError: true,
-		},
-		{
-			name: "missing type",
-			entry: map[string]interface{}{
-				"host":        "test.com",
-				"remote_port": float64(5432),
-			},
-			expectError: true,
-		},
-		{
-			name: "missing remote_port",
-			entry: map[string]interface{}{
-				"host": "test.com",
-				"type": "postgres",
-			},
-			expectError: true,
-		},
-		{
-			name: "invalid host type",
-			entry: map[string]interface{}{
-				"host":        123,
-				"type":        "postgres",
-				"remote_port": float64(5432),
-			},
-			expectError: true,
-		},
-		{
-			name: "invalid tags type",
-			entry: map[string]interface{}{
-				"host":        "test.com",
-				"type":        "postgres",
-				"remote_port": float64(5432),
-				"tags":        "invalid",
-			},
-			expectError: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := validateDbEntry("test", tt.entry)
-			if tt.expectError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
-		})
-	}
-}
-
 func TestDebugDbStorage(t *testing.T) {
 	_, cleanup := setupIsolatedInventory(t)
 	defer cleanup()
@@ -390,3 +316,207 @@ func TestDbInventoryRecovery(t *testing.T) {
 	assert.True(t, ok, "db key should be a map after recovery from invalid type")
 	assert.Empty(t, dbMap, "db should be empty after recovery from invalid type")
 }
+
+func TestDbInventoryCopy(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		dbCopyIncrementLocalPort = false
+		dbCopyForce = false
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.pg", DbInventoryEntry{Host: "pg.internal", Type: "postgres", RemotePort: 5432, Tags: []string{"prod"}}))
+
+	var buf bytes.Buffer
+	inventoryCmd.SetOut(&buf)
+	defer inventoryCmd.SetOut(nil)
+
+	// Plain copy keeps every field
+	assert.NoError(t, handleDbCopy(inventoryCmd, hi, []string{"pg", "pg-copy"}))
+	assert.Contains(t, buf.String(), "Copied db.pg to db.pg-copy")
+	assert.Contains(t, buf.String(), "No differences found.")
+	result, err := hi.Query("db.pg-copy")
+	assert.NoError(t, err)
+	entry, err := inventory.ParseDbEntry(result)
+	assert.NoError(t, err)
+	assert.Equal(t, "pg.internal", entry.Host)
+	assert.Equal(t, []string{"prod"}, entry.Tags)
+
+	// Existing destination requires --force
+	assert.Error(t, handleDbCopy(inventoryCmd, hi, []string{"pg", "pg-copy"}))
+
+	// The local port defaults to the remote port before being incremented
+	buf.Reset()
+	dbCopyForce = true
+	dbCopyIncrementLocalPort = true
+	assert.NoError(t, handleDbCopy(inventoryCmd, hi, []string{"pg", "pg-copy"}))
+	assert.Contains(t, buf.String(), "+ local_port: 5433")
+	result, err = hi.Query("db.pg-copy")
+	assert.NoError(t, err)
+	entry, err = inventory.ParseDbEntry(result)
+	assert.NoError(t, err)
+	assert.Equal(t, 5433, entry.LocalPort)
+
+	// The source is left untouched
+	result, err = hi.Query("db.pg")
+	assert.NoError(t, err)
+	entry, err = inventory.ParseDbEntry(result)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, entry.LocalPort)
+
+	assert.Error(t, handleDbCopy(inventoryCmd, hi, []string{"missing", "other"}))
+}
+
+func TestDbCopyFlagsAreNotInherited(t *testing.T) {
+	assert.NotNil(t, inventoryCmd.Flags().Lookup("force"))
+	assert.NotNil(t, inventoryCmd.Flags().Lookup("increment-local-port"))
+	for _, name := range []string{"force", "increment-local-port"} {
+		assert.Nil(t, inventoryDeleteCmd.InheritedFlags().Lookup(name), "inventory delete should not accept --%s", name)
+	}
+}
+
+func TestInventoryHelpShowsRequiredFields(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	inventoryCmd.SetOut(&buf)
+	defer inventoryCmd.SetOut(nil)
+
+	// Registered types are described even before any data exists
+	showInventoryHelp(inventoryCmd)
+	assert.Contains(t, buf.String(), "No inventory data found.")
+	assert.Contains(t, buf.String(), "Required fields: host, type, remote_port")
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.pg", DbInventoryEntry{Host: "pg.internal", Type: "postgres", RemotePort: 5432}))
+
+	buf.Reset()
+	showInventoryHelp(inventoryCmd)
+	assert.Contains(t, buf.String(), "Available inventory types:")
+	assert.Contains(t, buf.String(), "Required fields: host, type, remote_port")
+
+	buf.Reset()
+	assert.NoError(t, handleDynamicTypeCommand(inventoryCmd, hi, []string{"db"}))
+	assert.Contains(t, buf.String(), "Required fields: host, type, remote_port")
+}
+
+func TestInventoryReadOnlyFlag(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	inventoryReadOnly = true
+	defer func() { inventoryReadOnly = false }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, hi.Set("servers.web1.host", "10.0.0.1"), inventory.ErrReadOnly)
+
+	_, err = os.Stat(filepath.Join(tmpDir, "hierarchical-inventory.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestInventoryHooks(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hookLog := filepath.Join(tmpDir, "hooks.log")
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("_hooks", map[string]interface{}{
+		"after-set:db.*": `echo "$TSUKUYO_HOOK_PATH=$TSUKUYO_HOOK_VALUE" >> ` + hookLog,
+	}))
+	assert.NoError(t, hi.Set("db.pg.host", "10.0.0.1"))
+
+	logged, err := os.ReadFile(hookLog)
+	assert.NoError(t, err)
+	assert.Equal(t, "db.pg.host=10.0.0.1\n", string(logged))
+
+	// --no-hooks skips them
+	globalInventoryCache = nil
+	inventoryCacheOnce = sync.Once{}
+	inventoryNoHooks = true
+	defer func() { inventoryNoHooks = false }()
+
+	hi, err = getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.pg.host", "10.0.0.2"))
+
+	logged, err = os.ReadFile(hookLog)
+	assert.NoError(t, err)
+	assert.Equal(t, "db.pg.host=10.0.0.1\n", string(logged))
+}
+
+func TestHandleTypeGetFormatsDbStruct(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	entry := DbInventoryEntry{Host: "10.0.0.5", Type: "postgres", RemotePort: 5432, Tags: []string{"prod"}}
+	assert.NoError(t, hi.Set("db.structured", entry))
+	assert.NoError(t, hi.Set("db.generic", map[string]interface{}{
+		"host": "10.0.0.5", "type": "postgres", "remote_port": 5432, "tags": []interface{}{"prod"},
+	}))
+
+	cmd := &cobra.Command{}
+	var structured, generic bytes.Buffer
+	cmd.SetOut(&structured)
+	assert.NoError(t, handleTypeGet(cmd, hi, "db", []string{"structured"}))
+	cmd.SetOut(&generic)
+	assert.NoError(t, handleTypeGet(cmd, hi, "db", []string{"generic"}))
+
+	assert.Contains(t, structured.String(), `"remote_port": 5432`)
+	assert.Equal(t,
+		strings.TrimPrefix(generic.String(), "db.generic:"),
+		strings.TrimPrefix(structured.String(), "db.structured:"))
+}
+
+func TestDbEnvFilter(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { dbEnv = "" }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.pg-prod", DbInventoryEntry{Host: "10.0.0.1", Type: "postgres", RemotePort: 5432, Environment: "prod"}))
+	assert.NoError(t, hi.Set("db.pg-dev", map[string]interface{}{"host": "10.0.1.1", "type": "postgres", "remote_port": 5432, "environment": "dev"}))
+	assert.NoError(t, hi.Set("db.pg-any", DbInventoryEntry{Host: "10.0.2.1", Type: "postgres", RemotePort: 5432}))
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	dbEnv = "PROD"
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Contains(t, buf.String(), "- pg-prod")
+	assert.NotContains(t, buf.String(), "pg-dev")
+	assert.NotContains(t, buf.String(), "pg-any")
+
+	buf.Reset()
+	assert.NoError(t, handleTypeGet(cmd, hi, "db", []string{"pg-dev"}))
+	assert.Contains(t, buf.String(), "Entry 'pg-dev' not found in db inventory for environment 'PROD'.")
+
+	buf.Reset()
+	dbEnv = "staging"
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Equal(t, "No db entries found in environment 'staging'.\n", buf.String())
+
+	// db set stores the environment
+	dbEnv = "staging"
+	dbSetType, dbSetRemotePort = "redis", 6379
+	defer func() { dbSetType, dbSetRemotePort = "", 0 }()
+	buf.Reset()
+	assert.NoError(t, handleDbSet(cmd, hi, []string{"cache", "10.0.3.1"}))
+	buf.Reset()
+	assert.NoError(t, handleTypeGet(cmd, hi, "db", []string{"cache"}))
+	assert.Contains(t, buf.String(), `"environment": "staging"`)
+}
+
+func TestDbEnvFlagIsNotInherited(t *testing.T) {
+	assert.NotNil(t, inventoryCmd.Flags().Lookup("env"))
+	assert.Nil(t, inventorySetCmd.InheritedFlags().Lookup("env"))
+	assert.Nil(t, inventoryExportCmd.InheritedFlags().Lookup("env"))
+}
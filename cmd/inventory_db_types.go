@@ -1,10 +1,6 @@
 package cmd
 
+import "github.com/arung-agamani/tsukuyo/internal/inventory"
+
 // DbInventoryEntry represents a database entry in the inventory.
-type DbInventoryEntry struct {
-	Host       string   `json:"host"`
-	Type       string   `json:"type"` // e.g., "postgres", "redis", "mongodb"
-	RemotePort int      `json:"remote_port"`
-	LocalPort  int      `json:"local_port,omitempty"` // Optional: if not set, a default will be used
-	Tags       []string `json:"tags,omitempty"`
-}
+type DbInventoryEntry = inventory.DbInventoryEntry
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var deleteNamesFile string
+
+// readNamesFile reads a JSON array of paths such as ["db.old-server","node.decom-web1"]
+func readNamesFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("%s must contain a JSON array of paths: %v", path, err)
+	}
+	return names, nil
+}
+
+// deleteFromNamesFile deletes every path listed in the --names-file in a
+// single batch, so the inventory is saved once. Paths that don't exist are
+// reported as a warning rather than failing the rest. With --dry-run it only
+// prints what would be deleted.
+func deleteFromNamesFile(cmd *cobra.Command, hi *inventory.HierarchicalInventory) {
+	names, err := readNamesFile(deleteNamesFile)
+	if err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Failed to read names file:", err)
+		return
+	}
+
+	var existing, notFound []string
+	for _, name := range names {
+		if _, err := hi.Query(name); err != nil {
+			notFound = append(notFound, name)
+			continue
+		}
+		existing = append(existing, name)
+	}
+
+	if deleteDryRun {
+		for _, name := range existing {
+			value, _ := hi.Query(name)
+			fmt.Fprintf(cmd.OutOrStdout(), "Would delete %s\n", name)
+			for _, path := range collectListPaths(name, value, math.MaxInt) {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", path)
+			}
+		}
+	} else {
+		deleted := 0
+		hi.BeginBatch()
+		for _, name := range existing {
+			// An earlier path in the file may have removed this one already
+			if _, err := hi.Query(name); err != nil {
+				notFound = append(notFound, name)
+				continue
+			}
+			if err := hi.Delete(name); err != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Failed to delete %s: %v\n", name, err)
+				continue
+			}
+			deleted++
+		}
+		if err := hi.CommitBatch(); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to commit deletes:", err)
+			return
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted %d of %d path(s)\n", deleted, len(names))
+	}
+
+	if len(notFound) > 0 {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %d path(s) not found: %s\n", len(notFound), strings.Join(notFound, ", "))
+	}
+}
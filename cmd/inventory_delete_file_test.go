@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryDeleteNamesFile(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { deleteNamesFile, deleteDryRun = "", false }()
+
+	var out, stderr bytes.Buffer
+	inventoryDeleteCmd.SetOut(&out)
+	inventoryDeleteCmd.SetErr(&stderr)
+	defer inventoryDeleteCmd.SetOut(nil)
+	defer inventoryDeleteCmd.SetErr(nil)
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.old", map[string]interface{}{"host": "10.0.0.1"}))
+	assert.NoError(t, hi.Set("servers.keep.host", "10.0.0.2"))
+	assert.NoError(t, hi.Set("node.decom-web1.host", "10.0.1.1"))
+
+	namesFile := filepath.Join(tmpDir, "names.json")
+	assert.NoError(t, os.WriteFile(namesFile, []byte(`["servers.old","node.decom-web1","db.missing"]`), 0644))
+	deleteNamesFile = namesFile
+
+	// --dry-run only lists what would go
+	deleteDryRun = true
+	inventoryDeleteCmd.Run(inventoryDeleteCmd, nil)
+	assert.Equal(t, "Would delete servers.old\n  servers.old.host\nWould delete node.decom-web1\n  node.decom-web1.host\n", out.String())
+	assert.Equal(t, "Warning: 1 path(s) not found: db.missing\n", stderr.String())
+	_, err = hi.Query("servers.old.host")
+	assert.NoError(t, err)
+	deleteDryRun = false
+
+	out.Reset()
+	stderr.Reset()
+	inventoryDeleteCmd.Run(inventoryDeleteCmd, nil)
+	assert.Equal(t, "Deleted 2 of 3 path(s)\n", out.String())
+	assert.Equal(t, "Warning: 1 path(s) not found: db.missing\n", stderr.String())
+
+	// The deletes were saved to disk
+	saved, err := inventory.NewNamespacedInventory(getDataDir(), inventoryNamespace)
+	assert.NoError(t, err)
+	_, err = saved.Query("servers.old")
+	assert.Error(t, err)
+	_, err = saved.Query("node.decom-web1")
+	assert.Error(t, err)
+	_, err = saved.Query("servers.keep.host")
+	assert.NoError(t, err)
+
+	// A file that isn't a JSON array of paths is rejected
+	assert.NoError(t, os.WriteFile(namesFile, []byte(`{"servers.keep": true}`), 0644))
+	out.Reset()
+	inventoryDeleteCmd.Run(inventoryDeleteCmd, nil)
+	assert.Contains(t, out.String(), "Failed to read names file:")
+	_, err = hi.Query("servers.keep.host")
+	assert.NoError(t, err)
+}
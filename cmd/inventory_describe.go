@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// descriptionsKey is the reserved top-level key that stores a human-readable
+// description of each inventory type, keyed by the type name
+const descriptionsKey = "_description"
+
+// describeSet is the --set flag of 'inventory describe'
+var describeSet bool
+
+// loadDescriptions returns the type descriptions stored in the inventory, or
+// an empty map
+func loadDescriptions(hi *inventory.HierarchicalInventory) map[string]string {
+	descriptions := make(map[string]string)
+	result, err := hi.Query(descriptionsKey)
+	if err != nil {
+		return descriptions
+	}
+	if m, ok := result.(map[string]interface{}); ok {
+		for typeName, text := range m {
+			if s, ok := text.(string); ok {
+				descriptions[typeName] = s
+			}
+		}
+	}
+	return descriptions
+}
+
+var inventoryDescribeCmd = &cobra.Command{
+	Use:   "describe <type> | --set <type> <text>",
+	Short: "Show or set the description of an inventory type",
+	Long: `Show or set the description of a top-level inventory type. Descriptions
+are stored under _description and shown next to each type by
+'tsukuyo inventory list'.
+
+Examples:
+  tsukuyo inventory describe db
+  tsukuyo inventory describe --set db "Database connection inventory"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if describeSet {
+			return cobra.MinimumNArgs(2)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		typeName := args[0]
+		if typeName == "" || isReservedKey(typeName) || strings.ContainsAny(typeName, `."[]`) {
+			return fmt.Errorf("invalid inventory type '%s'", typeName)
+		}
+
+		if !describeSet {
+			text, ok := loadDescriptions(hi)[typeName]
+			if !ok {
+				return fmt.Errorf("no description for '%s'", typeName)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), text)
+			return nil
+		}
+
+		text := strings.Join(args[1:], " ")
+		if err := hi.Set(descriptionsKey+"."+typeName, text); err != nil {
+			return fmt.Errorf("failed to set description: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Description set for %s\n", typeName)
+		return nil
+	},
+}
+
+func init() {
+	inventoryDescribeCmd.Flags().BoolVar(&describeSet, "set", false, "Set the description of <type> to <text>")
+	inventoryCmd.AddCommand(inventoryDescribeCmd)
+}
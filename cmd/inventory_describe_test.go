@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryDescribe(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { describeSet = false }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1"}))
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	assert.EqualError(t, inventoryDescribeCmd.RunE(cmd, []string{"servers"}), "no description for 'servers'")
+
+	describeSet = true
+	assert.Error(t, inventoryDescribeCmd.Args(cmd, []string{"servers"}))
+	assert.NoError(t, inventoryDescribeCmd.RunE(cmd, []string{"servers", "Web", "server", "inventory"}))
+	assert.Equal(t, "Description set for servers\n", buf.String())
+	assert.EqualError(t, inventoryDescribeCmd.RunE(cmd, []string{"_aliases", "x"}), "invalid inventory type '_aliases'")
+
+	result, err := hi.Query(descriptionsKey)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"servers": "Web server inventory"}, result)
+
+	describeSet = false
+	buf.Reset()
+	assert.NoError(t, inventoryDescribeCmd.RunE(cmd, []string{"servers"}))
+	assert.Equal(t, "Web server inventory\n", buf.String())
+
+	// list shows the description next to the type
+	var listBuf bytes.Buffer
+	inventoryListCmd.SetOut(&listBuf)
+	defer inventoryListCmd.SetOut(nil)
+	inventoryListCmd.Run(inventoryListCmd, nil)
+	assert.Contains(t, listBuf.String(), "- servers (1 field) - Web server inventory\n")
+
+	listBuf.Reset()
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Equal(t, "Keys at 'servers':\n- web1 (1 field)\n", listBuf.String())
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+)
+
+var (
+	queryOutputEnv bool
+	queryFlat      bool
+	queryEnvPrefix string
+)
+
+// envKeyInvalidChars matches the characters not allowed in env variable names
+var envKeyInvalidChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// envKey turns path segments into an env variable name, e.g. ["izuna-db", "host"] -> IZUNA_DB_HOST
+func envKey(segments ...string) string {
+	key := strings.ToUpper(strings.Join(segments, "_"))
+	return envKeyInvalidChars.ReplaceAllString(key, "_")
+}
+
+// envValue renders a primitive value for a KEY=value line. Arrays of
+// primitives are joined with commas; anything else is encoded as JSON.
+func envValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if !isEnvPrimitive(item) {
+				data, _ := json.Marshal(v)
+				return string(data)
+			}
+			parts = append(parts, envValue(item))
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// envNeedsQuoting matches values a shell would split or expand when unquoted
+var envNeedsQuoting = regexp.MustCompile("[\\s$\"'#`\\\\!&|;<>()*?\\[\\]{}~]")
+
+// quoteEnvValue double-quotes values containing whitespace or shell-special
+// characters, escaping backslashes, double quotes, dollar signs, backticks
+// and newlines. Plain values are returned unchanged.
+func quoteEnvValue(value string) string {
+	if !envNeedsQuoting.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"`", "\\`",
+		"\n", `\n`,
+	)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// quoteAllEnv double-quotes the value of every KEY=value line in env that
+// quoteEnvValue left bare. Bare values have no characters that need escaping.
+func quoteAllEnv(env string) string {
+	if env == "" {
+		return env
+	}
+	lines := strings.Split(env, "\n")
+	for i, line := range lines {
+		key, value, _ := strings.Cut(line, "=")
+		if !strings.HasPrefix(value, `"`) {
+			lines[i] = key + `="` + value + `"`
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// envLine renders a single KEY=value line with the value quoted if needed
+func envLine(key string, value interface{}) string {
+	return fmt.Sprintf("%s=%s", key, quoteEnvValue(envValue(value)))
+}
+
+func isEnvPrimitive(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+// extractPrimitivesToEnv returns KEY=value lines for the primitive fields of
+// data, skipping nested objects. Names start with the prefix segments, if
+// any. Lines are sorted so output is reproducible.
+func extractPrimitivesToEnv(prefix []string, data map[string]interface{}) []string {
+	var lines []string
+	for key, value := range data {
+		if _, nested := value.(map[string]interface{}); nested {
+			continue
+		}
+		lines = append(lines, envLine(envKey(append(append([]string{}, prefix...), key)...), value))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// flattenToEnv returns KEY=value lines for every leaf below data, joining
+// nested keys with underscores (db.server1.host -> SERVER1_HOST for data = db).
+// Lines are sorted so output is reproducible.
+func flattenToEnv(prefix []string, data map[string]interface{}) []string {
+	var lines []string
+	for key, value := range data {
+		path := append(append([]string{}, prefix...), key)
+		if nested, ok := value.(map[string]interface{}); ok {
+			lines = append(lines, flattenToEnv(path, nested)...)
+			continue
+		}
+		lines = append(lines, envLine(envKey(path...), value))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// formatAsEnv renders a query result as .env lines. Objects emit their
+// primitive fields, or every nested leaf when flat is set; a single value is
+// named after the last segment of the query. A non-empty prefix is prepended
+// to every name, e.g. DB -> DB_HOST.
+func formatAsEnv(query string, result interface{}, flat bool, prefix string) string {
+	var segments []string
+	if prefix != "" {
+		segments = []string{prefix}
+	}
+
+	var envVars []string
+	if data, ok := result.(map[string]interface{}); ok {
+		if flat {
+			envVars = flattenToEnv(segments, data)
+		} else {
+			envVars = extractPrimitivesToEnv(segments, data)
+		}
+	} else {
+		name := query
+		if i := strings.LastIndex(query, "."); i >= 0 {
+			name = query[i+1:]
+		}
+		envVars = []string{envLine(envKey(append(segments, name)...), result)}
+	}
+	sort.Strings(envVars)
+	return strings.Join(envVars, "\n")
+}
+
+// formatPathsAsEnv renders several query results as one sorted set of .env
+// lines, fetching them with a single QueryMultiple call. queries are the paths
+// as given and paths the ones to look up (e.g. with aliases expanded). Each
+// name includes its query path so entries don't collide: db.a gives DB_A_HOST
+// and DB_A_PORT, and db.b.host gives DB_B_HOST. A non-empty prefix is prepended as in
+// formatAsEnv. The first path that fails to resolve is returned as an error.
+func formatPathsAsEnv(hi *inventory.HierarchicalInventory, queries, paths []string, flat bool, prefix string) (string, error) {
+	results, errs := hi.QueryMultiple(paths)
+	for i, path := range paths {
+		if err, ok := errs[path]; ok {
+			return "", fmt.Errorf("%s: %v", queries[i], err)
+		}
+	}
+
+	var lines []string
+	for i, path := range paths {
+		query := queries[i]
+		result := results[path]
+
+		// Objects are named after their own path, single values after their parent's
+		scope := query
+		if _, ok := result.(map[string]interface{}); !ok {
+			scope = ""
+			if j := strings.LastIndex(query, "."); j >= 0 {
+				scope = query[:j]
+			}
+		}
+		scopePrefix := prefix
+		if scope = strings.TrimPrefix(scope, "."); scope != "" {
+			if prefix != "" {
+				scope = prefix + "_" + scope
+			}
+			scopePrefix = envKey(scope)
+		}
+
+		if env := formatAsEnv(query, result, flat, scopePrefix); env != "" {
+			lines = append(lines, strings.Split(env, "\n")...)
+		}
+	}
+	// Overlapping paths such as db.a and db.a.host yield the same line twice
+	sort.Strings(lines)
+	unique := lines[:0]
+	for i, line := range lines {
+		if i == 0 || line != lines[i-1] {
+			unique = append(unique, line)
+		}
+	}
+	return strings.Join(unique, "\n"), nil
+}
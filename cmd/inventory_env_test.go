@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAsEnvIsSorted(t *testing.T) {
+	data := map[string]interface{}{
+		"type":        "postgres",
+		"host":        "db1.internal",
+		"remote_port": float64(5432),
+		"tags":        []interface{}{"prod", "sql"},
+		"local-port":  nil,
+		"options":     map[string]interface{}{"ssl": true, "pool": float64(10)},
+	}
+
+	// Map iteration order is random, so run a few times to catch unsorted output
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []string{
+			"HOST=db1.internal",
+			"LOCAL_PORT=",
+			"REMOTE_PORT=5432",
+			"TAGS=prod,sql",
+			"TYPE=postgres",
+		}, extractPrimitivesToEnv(nil, data))
+
+		assert.Equal(t, []string{
+			"HOST=db1.internal",
+			"LOCAL_PORT=",
+			"OPTIONS_POOL=10",
+			"OPTIONS_SSL=true",
+			"REMOTE_PORT=5432",
+			"TAGS=prod,sql",
+			"TYPE=postgres",
+		}, flattenToEnv(nil, data))
+	}
+
+	assert.Equal(t, "HOST=db1.internal\nLOCAL_PORT=\nREMOTE_PORT=5432\nTAGS=prod,sql\nTYPE=postgres", formatAsEnv("db.db1", data, false, ""))
+	assert.Equal(t, "REMOTE_PORT=5432", formatAsEnv("db.db1.remote_port", float64(5432), false, ""))
+}
+
+func TestFormatAsEnvPrefix(t *testing.T) {
+	data := map[string]interface{}{
+		"server1": map[string]interface{}{"host": "db1.internal", "port": float64(5432)},
+		"region":  "eu-west",
+	}
+
+	assert.Equal(t, "DB_REGION=eu-west", formatAsEnv("db", data, false, "DB"))
+	assert.Equal(t, "DB_REGION=eu-west\nDB_SERVER1_HOST=db1.internal\nDB_SERVER1_PORT=5432", formatAsEnv("db", data, true, "DB"))
+	assert.Equal(t, "DB_PORT=5432", formatAsEnv("db.server1.port", float64(5432), false, "DB"))
+	assert.Equal(t, "MY_APP_PORT=5432", formatAsEnv("db.server1.port", float64(5432), false, "my-app"))
+}
+
+func TestInventoryQueryOutputEnv(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1", "port": 80}))
+	assert.NoError(t, hi.Set("servers.api-1", map[string]interface{}{"host": "10.0.0.2"}))
+
+	output := runQueryCmd(t, map[string]string{"output-env": "true"}, "servers.web1")
+	assert.Equal(t, "HOST=10.0.0.1\nPORT=80\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output-env": "true", "flat": "true"}, "servers")
+	assert.Equal(t, "API_1_HOST=10.0.0.2\nWEB1_HOST=10.0.0.1\nWEB1_PORT=80\n", output)
+
+	// Several paths are fetched together and named after their path
+	output = runQueryCmd(t, map[string]string{"output-env": "true"}, "servers.web1", "servers.api-1.host")
+	assert.Equal(t, "SERVERS_API_1_HOST=10.0.0.2\nSERVERS_WEB1_HOST=10.0.0.1\nSERVERS_WEB1_PORT=80\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output-env": "true", "env-prefix": "APP"}, "servers.web1.port", "servers.api-1")
+	assert.Equal(t, "APP_SERVERS_API_1_HOST=10.0.0.2\nAPP_SERVERS_WEB1_PORT=80\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output-env": "true"}, "servers.web1", "servers.missing")
+	assert.Contains(t, output, "Query failed: servers.missing:")
+}
+
+func TestFormatPathsAsEnvUsesQueryMultiple(t *testing.T) {
+	hi := inventory.NewMemoryInventory(map[string]interface{}{
+		"db": map[string]interface{}{
+			"a": map[string]interface{}{"host": "a.internal"},
+			"b": map[string]interface{}{"host": "b.internal"},
+		},
+	})
+
+	// Lookups use the resolved paths while names follow the queries as given
+	env, err := formatPathsAsEnv(hi, []string{"primary", "db.b.host"}, []string{"db.a", "db.b.host"}, false, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "DB_B_HOST=b.internal\nPRIMARY_HOST=a.internal", env)
+
+	env, err = formatPathsAsEnv(hi, []string{"db.a", "db.a.host"}, []string{"db.a", "db.a.host"}, false, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "DB_A_HOST=a.internal", env)
+}
+
+func TestQuoteEnvValue(t *testing.T) {
+	tests := map[string]string{
+		"plain":                  "plain",
+		"10.0.0.1":               "10.0.0.1",
+		"user@host:5432/db":      "user@host:5432/db",
+		"some value with spaces": `"some value with spaces"`,
+		"tab\there":              "\"tab\there\"",
+		`say "hi"`:               `"say \"hi\""`,
+		"line1\nline2":           `"line1\nline2"`,
+		"$HOME":                  `"\$HOME"`,
+		"pa$$word":               `"pa\$\$word"`,
+		"#not-a-comment":         `"#not-a-comment"`,
+		"`whoami`":               "\"\\`whoami\\`\"",
+		`C:\path`:                `"C:\\path"`,
+		"it's":                   `"it's"`,
+		"a;b&c|d":                `"a;b&c|d"`,
+		"<in>(sub)":              `"<in>(sub)"`,
+		"glob*?[x]":              `"glob*?[x]"`,
+		"{brace}~!":              `"{brace}~!"`,
+		"":                       "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, quoteEnvValue(input), "quoting %q", input)
+	}
+
+	assert.Equal(t, []string{`NOTE="say \"hi\" to \$USER"`}, extractPrimitivesToEnv(nil, map[string]interface{}{"note": `say "hi" to $USER`}))
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	exportFormat     string
+	exportOutputFile string
+	exportAppend     bool
+	exportType       string
+	exportTags       []string
+	exportAnyTags    []string
+	exportPretty     bool
+	exportCompact    bool
+	exportQuoteAll   bool
+)
+
+var inventoryExportCmd = &cobra.Command{
+	Use:   "export [query]",
+	Short: "Export inventory data to stdout or a file",
+	Long: `Export the whole inventory, or the data at a query path, in the given format.
+
+Examples:
+  tsukuyo inventory export
+  tsukuyo inventory export db --format yaml
+  tsukuyo inventory export db --compact
+  tsukuyo inventory export db.server1 --format dotenv --quote-all
+  tsukuyo inventory export --output-file backups/inventory.json
+  tsukuyo inventory export --format json --output s3://my-bucket/tsukuyo/backup.json
+  tsukuyo inventory export --format ssh-known-hosts --append
+  tsukuyo inventory export --format ssh-config --type node --tag prod --append
+  tsukuyo inventory export db --format grafana-annotations -o annotations.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %v", err)
+		}
+
+		if exportAppend && isS3URI(exportOutputFile) {
+			return fmt.Errorf("--append cannot be used with an S3 destination")
+		}
+		if exportFormat == "ssh-known-hosts" {
+			return exportKnownHosts(cmd, hi)
+		}
+		if exportFormat == "ssh-config" {
+			return exportSSHConfig(cmd, hi)
+		}
+		if exportAppend {
+			return fmt.Errorf("--append is only supported with --format ssh-known-hosts or ssh-config")
+		}
+		if cmd.Flags().Changed("type") || len(exportTags) > 0 || len(exportAnyTags) > 0 {
+			return fmt.Errorf("--type, --tag and --tag-or are only supported with --format ssh-config")
+		}
+		if exportQuoteAll && exportFormat != "dotenv" {
+			return fmt.Errorf("--quote-all is only supported with --format dotenv")
+		}
+		if exportCompact && cmd.Flags().Changed("pretty") && exportPretty {
+			return fmt.Errorf("--pretty and --compact cannot be used together")
+		}
+
+		var query string
+		if len(args) > 0 {
+			query = args[0]
+		}
+
+		var output []byte
+		if exportFormat == "grafana-annotations" {
+			output, err = renderGrafanaAnnotations(hi, query)
+		} else {
+			var data interface{}
+			if data, err = hi.Query(query); err != nil {
+				return fmt.Errorf("query failed: %v", err)
+			}
+			if exportFormat == "dotenv" {
+				output = renderDotenvExport(query, data, exportQuoteAll)
+			} else {
+				output, err = renderExport(data, exportFormat, exportCompact || !exportPretty)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if exportOutputFile == "" {
+			_, err = cmd.OutOrStdout().Write(output)
+			return err
+		}
+
+		if err := writeExportFile(exportOutputFile, output); err != nil {
+			return fmt.Errorf("failed to write %s: %v", exportOutputFile, err)
+		}
+		// Status goes to stderr so stdout stays clean for the exported data
+		fmt.Fprintln(cmd.ErrOrStderr(), "Exported to", exportOutputFile)
+		return nil
+	},
+}
+
+// exportKnownHosts writes the stored node fingerprints in known_hosts format.
+// With --append they are merged into --output-file, or ~/.ssh/known_hosts.
+func exportKnownHosts(cmd *cobra.Command, hi *inventory.HierarchicalInventory) error {
+	lines := knownHostsLines(hi)
+	if len(lines) == 0 {
+		return fmt.Errorf("no nodes with a stored ssh_fingerprint found")
+	}
+
+	if exportAppend {
+		path := exportOutputFile
+		if path == "" {
+			var err error
+			if path, err = defaultKnownHostsPath(); err != nil {
+				return err
+			}
+		}
+		if err := appendKnownHosts(path, lines); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Merged %d host key(s) into %s\n", len(lines), path)
+		return nil
+	}
+
+	output := []byte(strings.Join(lines, "\n") + "\n")
+	if exportOutputFile == "" {
+		_, err := cmd.OutOrStdout().Write(output)
+		return err
+	}
+	if err := writeExportFile(exportOutputFile, output); err != nil {
+		return fmt.Errorf("failed to write %s: %v", exportOutputFile, err)
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), "Exported to", exportOutputFile)
+	return nil
+}
+
+// exportSSHConfig writes a ~/.ssh/config Host block per entry of --type.
+// With --append they are merged into --output-file, or ~/.ssh/config,
+// skipping hosts that are already declared there.
+func exportSSHConfig(cmd *cobra.Command, hi *inventory.HierarchicalInventory) error {
+	hosts := sshConfigHosts(hi, exportType, exportTags, exportAnyTags)
+	if len(hosts) == 0 {
+		return fmt.Errorf("no %s entries with a host found", exportType)
+	}
+
+	if exportAppend {
+		path := exportOutputFile
+		if path == "" {
+			var err error
+			if path, err = defaultSSHConfigPath(); err != nil {
+				return err
+			}
+		}
+		added, err := appendSSHConfig(path, hosts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Added %d Host block(s) to %s (%d already present)\n", added, path, len(hosts)-added)
+		return nil
+	}
+
+	output := []byte(renderSSHConfig(hosts))
+	if exportOutputFile == "" {
+		_, err := cmd.OutOrStdout().Write(output)
+		return err
+	}
+	if err := writeExportFile(exportOutputFile, output); err != nil {
+		return fmt.Errorf("failed to write %s: %v", exportOutputFile, err)
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), "Exported to", exportOutputFile)
+	return nil
+}
+
+// renderExport serializes exported data in the requested format. With compact,
+// JSON is written on a single line.
+func renderExport(data interface{}, format string, compact bool) ([]byte, error) {
+	switch format {
+	case "json":
+		var output []byte
+		var err error
+		if compact {
+			output, err = json.Marshal(data)
+		} else {
+			output, err = json.MarshalIndent(data, "", "  ")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return append(output, '\n'), nil
+	case "yaml":
+		return yaml.Marshal(data)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// renderDotenvExport renders exported data as sorted KEY=value lines, naming
+// nested fields PARENT_CHILD. Reserved root keys such as _meta are left out of
+// a whole-inventory export. With quoteAll every value is double-quoted, not
+// just those with whitespace or shell-special characters.
+func renderDotenvExport(query string, data interface{}, quoteAll bool) []byte {
+	if root, ok := data.(map[string]interface{}); ok && query == "" {
+		entries := make(map[string]interface{}, len(root))
+		for key, value := range root {
+			if !isReservedKey(key) {
+				entries[key] = value
+			}
+		}
+		data = entries
+	}
+
+	env := formatAsEnv(query, data, true, "")
+	if quoteAll {
+		env = quoteAllEnv(env)
+	}
+	if env == "" {
+		return nil
+	}
+	return []byte(env + "\n")
+}
+
+// writeExportFile writes exported data to path, creating parent directories as
+// needed. An s3://bucket/key path uploads the data to S3 instead.
+func writeExportFile(path string, data []byte) error {
+	if isS3URI(path) {
+		return uploadToS3(context.Background(), path, data)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func init() {
+	inventoryExportCmd.Flags().StringVar(&exportFormat, "format", "json", "Export format: json, yaml, dotenv, ssh-known-hosts, ssh-config or grafana-annotations (a snapshot of entry created/last-modified times, not a change log)")
+	inventoryExportCmd.Flags().BoolVar(&exportAppend, "append", false, "Merge into an existing file (~/.ssh/known_hosts or ~/.ssh/config by default) without duplicating hosts")
+	inventoryExportCmd.Flags().StringVar(&exportType, "type", "node", "Inventory type whose entries become Host blocks (ssh-config only)")
+	inventoryExportCmd.Flags().StringSliceVar(&exportTags, "tag", nil, "Only export entries that have all of these tags (repeatable, ssh-config only)")
+	inventoryExportCmd.Flags().StringSliceVar(&exportAnyTags, "tag-or", nil, "Only export entries that have at least one of these tags (ssh-config only)")
+	inventoryExportCmd.Flags().BoolVar(&exportPretty, "pretty", true, "Indent JSON output with two spaces")
+	inventoryExportCmd.Flags().BoolVar(&exportCompact, "compact", false, "Write JSON on a single line (same as --pretty=false)")
+	inventoryExportCmd.Flags().BoolVar(&exportQuoteAll, "quote-all", false, "Double-quote every value, not just those with special characters (dotenv only)")
+	inventoryExportCmd.Flags().StringVarP(&exportOutputFile, "output-file", "o", "", "Write the export to this file, or an s3://bucket/key location, instead of stdout")
+	inventoryExportCmd.Flags().StringVar(&exportOutputFile, "output", "", "Alias for --output-file")
+
+	inventoryCmd.AddCommand(inventoryExportCmd)
+}
@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderExport(t *testing.T) {
+	data := map[string]interface{}{
+		"db": map[string]interface{}{
+			"server1": map[string]interface{}{"host": "db1.example.com", "remote_port": float64(5432)},
+		},
+	}
+
+	output, err := renderExport(data, "json", false)
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "\n  \"db\": {")
+	var fromJSON map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output, &fromJSON))
+	assert.Equal(t, data, fromJSON)
+
+	output, err = renderExport(data, "json", true)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"db":{"server1":{"host":"db1.example.com","remote_port":5432}}}`+"\n", string(output))
+
+	output, err = renderExport(data, "yaml", false)
+	assert.NoError(t, err)
+	var fromYAML map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(output, &fromYAML))
+	assert.Equal(t, "db1.example.com", fromYAML["db"].(map[string]interface{})["server1"].(map[string]interface{})["host"])
+
+	_, err = renderExport(data, "xml", false)
+	assert.Error(t, err)
+}
+
+func TestInventoryExportToFile(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		exportFormat = "json"
+		exportOutputFile = ""
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1.host", "10.0.0.1"))
+
+	// Parent directories are created on demand
+	outPath := filepath.Join(tmpDir, "exports", "nested", "node.json")
+	exportFormat = "json"
+	exportOutputFile = outPath
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, []string{"node"}))
+
+	content, err := os.ReadFile(outPath)
+	assert.NoError(t, err)
+	var exported map[string]interface{}
+	assert.NoError(t, json.Unmarshal(content, &exported))
+	assert.Equal(t, "10.0.0.1", exported["web1"].(map[string]interface{})["host"])
+}
+
+func TestParseS3URI(t *testing.T) {
+	bucket, key, err := parseS3URI("s3://my-bucket/tsukuyo/backup.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-bucket", bucket)
+	assert.Equal(t, "tsukuyo/backup.json", key)
+
+	for _, uri := range []string{"s3://", "s3://my-bucket", "s3://my-bucket/", "s3:///key", "s3://my-bucket/dir/"} {
+		_, _, err := parseS3URI(uri)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestInventoryExportToS3(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		exportFormat = "json"
+		exportOutputFile = ""
+		exportAppend = false
+	}()
+	originalPut := s3PutObject
+	defer func() { s3PutObject = originalPut }()
+
+	var gotBucket, gotKey string
+	var gotData []byte
+	s3PutObject = func(ctx context.Context, bucket, key string, data []byte) error {
+		gotBucket, gotKey, gotData = bucket, key, data
+		return nil
+	}
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1.host", "10.0.0.1"))
+
+	var stderr bytes.Buffer
+	inventoryExportCmd.SetErr(&stderr)
+	defer inventoryExportCmd.SetErr(nil)
+
+	exportOutputFile = "s3://my-bucket/tsukuyo/backup.json"
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, []string{"node"}))
+	assert.Equal(t, "my-bucket", gotBucket)
+	assert.Equal(t, "tsukuyo/backup.json", gotKey)
+	var exported map[string]interface{}
+	assert.NoError(t, json.Unmarshal(gotData, &exported))
+	assert.Equal(t, "10.0.0.1", exported["web1"].(map[string]interface{})["host"])
+	assert.Contains(t, stderr.String(), "Exported to s3://my-bucket/tsukuyo/backup.json")
+
+	// Upload failures are reported
+	s3PutObject = func(ctx context.Context, bucket, key string, data []byte) error {
+		return errors.New("access denied")
+	}
+	assert.ErrorContains(t, inventoryExportCmd.RunE(inventoryExportCmd, []string{"node"}), "access denied")
+
+	exportAppend = true
+	exportFormat = "ssh-config"
+	assert.ErrorContains(t, inventoryExportCmd.RunE(inventoryExportCmd, nil), "--append cannot be used with an S3 destination")
+}
+
+func TestMergeKnownHosts(t *testing.T) {
+	existing := "# managed by hand\nold.example.com ssh-rsa OLD\nweb1,10.0.0.1 ssh-rsa STALE\n|1|x=|y= ssh-ed25519 HASHED\n"
+	merged := mergeKnownHosts(existing, []string{"10.0.0.1 ssh-ed25519 NEW"})
+	assert.Equal(t, "# managed by hand\nold.example.com ssh-rsa OLD\n|1|x=|y= ssh-ed25519 HASHED\n10.0.0.1 ssh-ed25519 NEW\n", merged)
+}
+
+func TestInventoryExportKnownHosts(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		exportFormat = "json"
+		exportOutputFile = ""
+		exportAppend = false
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1", "ssh_fingerprint": "|1|a=|b= ssh-ed25519 KEY1\n"}))
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "10.0.0.2", "port": 2222, "ssh_fingerprint": "|1|c=|d= ssh-rsa KEY2\n"}))
+	assert.NoError(t, hi.Set("node.web3", map[string]interface{}{"host": "10.0.0.3"}))
+
+	var buf bytes.Buffer
+	inventoryExportCmd.SetOut(&buf)
+	inventoryExportCmd.SetErr(&buf)
+	defer inventoryExportCmd.SetOut(nil)
+	defer inventoryExportCmd.SetErr(nil)
+
+	exportFormat = "ssh-known-hosts"
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	assert.Equal(t, "10.0.0.1 ssh-ed25519 KEY1\n[10.0.0.2]:2222 ssh-rsa KEY2\n", buf.String())
+
+	// --append merges into the target file, replacing entries for the same host
+	knownHosts := filepath.Join(tmpDir, "known_hosts")
+	assert.NoError(t, os.WriteFile(knownHosts, []byte("10.0.0.1 ssh-rsa OLD\nother ssh-rsa KEEP\n"), 0600))
+	exportOutputFile = knownHosts
+	exportAppend = true
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	content, err := os.ReadFile(knownHosts)
+	assert.NoError(t, err)
+	assert.Equal(t, "other ssh-rsa KEEP\n10.0.0.1 ssh-ed25519 KEY1\n[10.0.0.2]:2222 ssh-rsa KEY2\n", string(content))
+
+	exportFormat = "json"
+	assert.Error(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+}
+
+func TestMergeSSHConfig(t *testing.T) {
+	hosts := []sshConfigHost{
+		{Alias: "web1", Block: "Host web1\n    HostName 10.0.0.1\n"},
+		{Alias: "web2", Block: "Host web2\n    HostName 10.0.0.2\n"},
+	}
+	existing := "Host web1 web1-alias\n    HostName old.example.com\n"
+
+	merged, added := mergeSSHConfig(existing, hosts)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, existing+"\nHost web2\n    HostName 10.0.0.2\n", merged)
+
+	merged, added = mergeSSHConfig(merged, hosts)
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 1, bytes.Count([]byte(merged), []byte("Host web2")))
+}
+
+func TestInventoryExportSSHConfig(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		exportFormat = "json"
+		exportOutputFile = ""
+		exportAppend = false
+		exportTags = nil
+		exportAnyTags = nil
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1", "user": "ubuntu", "tags": []interface{}{"prod", "web"}}))
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "10.0.0.2", "port": float64(2222), "tags": []interface{}{"dev", "web"}}))
+
+	var buf bytes.Buffer
+	inventoryExportCmd.SetOut(&buf)
+	defer inventoryExportCmd.SetOut(nil)
+
+	exportFormat = "ssh-config"
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	assert.Equal(t, "Host web1\n    HostName 10.0.0.1\n    User ubuntu\n\nHost web2\n    HostName 10.0.0.2\n    Port 2222\n", buf.String())
+
+	// Tag filtering
+	buf.Reset()
+	exportTags = []string{"prod"}
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	assert.Contains(t, buf.String(), "Host web1")
+	assert.NotContains(t, buf.String(), "Host web2")
+
+	// Repeated --tag needs every tag, like list --tag
+	buf.Reset()
+	exportTags = []string{"prod", "dev"}
+	assert.EqualError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil), "no node entries with a host found")
+	buf.Reset()
+	exportTags = []string{"web", "dev"}
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	assert.NotContains(t, buf.String(), "Host web1")
+	assert.Contains(t, buf.String(), "Host web2")
+
+	// --tag-or needs any one of them
+	buf.Reset()
+	exportTags = nil
+	exportAnyTags = []string{"prod", "dev"}
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	assert.Contains(t, buf.String(), "Host web1")
+	assert.Contains(t, buf.String(), "Host web2")
+	exportAnyTags = nil
+
+	// Appending twice does not duplicate Host blocks
+	configPath := filepath.Join(tmpDir, "ssh", "config")
+	exportTags = nil
+	exportAppend = true
+	exportOutputFile = configPath
+	inventoryExportCmd.SetErr(&bytes.Buffer{})
+	defer inventoryExportCmd.SetErr(nil)
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	content, err := os.ReadFile(configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(content, []byte("Host web1\n")))
+	assert.Equal(t, 1, bytes.Count(content, []byte("Host web2\n")))
+}
+
+func TestInventoryExportGrafanaAnnotations(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { exportFormat = "json" }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1.host", "10.0.0.1"))
+	assert.NoError(t, hi.Set("node.bastion.host", "10.0.1.1"))
+	assert.NoError(t, hi.Set("_meta", map[string]interface{}{
+		"servers": map[string]interface{}{
+			"web1": map[string]interface{}{"created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-02T00:00:00Z"},
+		},
+		"node": map[string]interface{}{
+			"bastion": map[string]interface{}{"created_at": "2024-01-01T12:00:00Z", "updated_at": "2024-01-01T12:00:00Z"},
+		},
+	}))
+
+	var buf bytes.Buffer
+	inventoryExportCmd.SetOut(&buf)
+	defer inventoryExportCmd.SetOut(nil)
+	exportFormat = "grafana-annotations"
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+
+	var annotations []grafanaAnnotation
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &annotations))
+	assert.Equal(t, []grafanaAnnotation{
+		{Time: 1704067200000, Text: "Created servers.web1", Tags: []string{"tsukuyo", "create", "servers"}},
+		{Time: 1704110400000, Text: "Created node.bastion", Tags: []string{"tsukuyo", "create", "node"}},
+		{Time: 1704153600000, Text: "Set servers.web1", Tags: []string{"tsukuyo", "set", "servers"}},
+	}, annotations)
+
+	// A query keeps only the changes below it
+	buf.Reset()
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, []string{"node"}))
+	annotations = nil
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &annotations))
+	assert.Len(t, annotations, 1)
+	assert.Equal(t, "Created node.bastion", annotations[0].Text)
+
+	// The exact body Grafana's annotations API accepts, with time in epoch milliseconds
+	assert.JSONEq(t, `[{"time": 1704110400000, "text": "Created node.bastion", "tags": ["tsukuyo", "create", "node"]}]`, buf.String())
+
+	assert.EqualError(t, inventoryExportCmd.RunE(inventoryExportCmd, []string{"db"}), "no created_at or updated_at timestamps found in entry metadata")
+}
+
+func TestInventoryExportDotenv(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		exportFormat = "json"
+		exportQuoteAll = false
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1.host", "10.0.0.1"))
+	assert.NoError(t, hi.Set("servers.web1.note", "primary host"))
+	assert.NoError(t, hi.Set("servers.web1.port", float64(8080)))
+
+	var buf bytes.Buffer
+	inventoryExportCmd.SetOut(&buf)
+	defer inventoryExportCmd.SetOut(nil)
+	exportFormat = "dotenv"
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, []string{"servers"}))
+	assert.Equal(t, "WEB1_HOST=10.0.0.1\nWEB1_NOTE=\"primary host\"\nWEB1_PORT=8080\n", buf.String())
+
+	// Reserved keys such as _meta stay out of a whole-inventory export
+	buf.Reset()
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil))
+	assert.NotContains(t, buf.String(), "_META")
+	assert.Contains(t, buf.String(), "SERVERS_WEB1_HOST=10.0.0.1\n")
+
+	buf.Reset()
+	exportQuoteAll = true
+	assert.NoError(t, inventoryExportCmd.RunE(inventoryExportCmd, []string{"servers.web1"}))
+	assert.Equal(t, "HOST=\"10.0.0.1\"\nNOTE=\"primary host\"\nPORT=\"8080\"\n", buf.String())
+
+	exportFormat = "json"
+	assert.EqualError(t, inventoryExportCmd.RunE(inventoryExportCmd, nil), "--quote-all is only supported with --format dotenv")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+)
+
+// grafanaAnnotation is one annotation in the body accepted by Grafana's
+// annotations HTTP API. Time is in milliseconds since the epoch.
+type grafanaAnnotation struct {
+	Time int64    `json:"time"`
+	Text string   `json:"text"`
+	Tags []string `json:"tags"`
+}
+
+// grafanaAnnotations builds annotations from a snapshot of the entry
+// metadata under _meta: one for when each entry was created and one for when
+// it was last modified. This is not a change log. Only the latest update of
+// an entry is known, and deleted entries take their metadata with them. A
+// non-empty query keeps only the entries at or below that path. Annotations
+// are sorted by time.
+func grafanaAnnotations(hi *inventory.HierarchicalInventory, query string) []grafanaAnnotation {
+	annotations := []grafanaAnnotation{}
+	result, err := hi.Query(inventory.MetaKey)
+	if err != nil {
+		return annotations
+	}
+	meta, _ := result.(map[string]interface{})
+	for typeName, value := range meta {
+		typeMeta, _ := value.(map[string]interface{})
+		for name, entry := range typeMeta {
+			path := typeName + "." + name
+			if query != "" && path != query && !strings.HasPrefix(path, query+".") && !strings.HasPrefix(query, path+".") {
+				continue
+			}
+			entryMeta, _ := entry.(map[string]interface{})
+			created, _ := entryMeta["created_at"].(string)
+			updated, _ := entryMeta["updated_at"].(string)
+			if createdAt, err := time.Parse(time.RFC3339, created); err == nil {
+				annotations = append(annotations, grafanaAnnotation{
+					Time: createdAt.UnixMilli(),
+					Text: "Created " + path,
+					Tags: []string{"tsukuyo", "create", typeName},
+				})
+			}
+			if updated == created {
+				continue
+			}
+			if updatedAt, err := time.Parse(time.RFC3339, updated); err == nil {
+				annotations = append(annotations, grafanaAnnotation{
+					Time: updatedAt.UnixMilli(),
+					Text: "Set " + path,
+					Tags: []string{"tsukuyo", "set", typeName},
+				})
+			}
+		}
+	}
+	sort.Slice(annotations, func(i, j int) bool {
+		if annotations[i].Time != annotations[j].Time {
+			return annotations[i].Time < annotations[j].Time
+		}
+		return annotations[i].Text < annotations[j].Text
+	})
+	return annotations
+}
+
+// renderGrafanaAnnotations serializes the annotations as an indented JSON array
+func renderGrafanaAnnotations(hi *inventory.HierarchicalInventory, query string) ([]byte, error) {
+	annotations := grafanaAnnotations(hi, query)
+	if len(annotations) == 0 {
+		return nil, fmt.Errorf("no created_at or updated_at timestamps found in entry metadata")
+	}
+	output, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(output, '\n'), nil
+}
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/arung-agamani/tsukuyo/internal/inventory"
 	"github.com/manifoldco/promptui"
@@ -15,17 +21,59 @@ import (
 var (
 	globalInventoryCache *inventory.HierarchicalInventory
 	inventoryCacheOnce   sync.Once
+	inventoryNamespace   string
+	inventoryReadOnly    bool
+	inventoryNoHooks     bool
+	inventoryIOTimeout   = inventory.DefaultIOTimeout
 )
 
 // getHierarchicalInventory returns a cached hierarchical inventory instance
 func getHierarchicalInventory() (*inventory.HierarchicalInventory, error) {
 	var err error
 	inventoryCacheOnce.Do(func() {
-		globalInventoryCache, err = inventory.NewHierarchicalInventory(getDataDir())
+		globalInventoryCache, err = inventory.NewNamespacedInventory(getDataDir(), inventoryNamespace)
+		if err == nil {
+			globalInventoryCache.RegisterTypeValidator("db", inventory.ValidateDbEntry, inventory.DbRequiredFields...)
+			globalInventoryCache.SetReadOnly(inventoryReadOnly)
+			globalInventoryCache.SetHooksEnabled(!inventoryNoHooks)
+			globalInventoryCache.SetIOTimeout(ioTimeout())
+			globalInventoryCache.SetMaxSize(maxInventorySize())
+		}
 	})
 	return globalInventoryCache, err
 }
 
+// ioTimeout returns the inventory I/O timeout: --timeout if given, else the
+// io_timeout config setting
+func ioTimeout() time.Duration {
+	if flag := rootCmd.PersistentFlags().Lookup("timeout"); flag != nil && flag.Changed {
+		return inventoryIOTimeout
+	}
+	if timeout := appConfig.GetDuration("io_timeout"); timeout > 0 {
+		return timeout
+	}
+	return inventoryIOTimeout
+}
+
+// maxInventorySize returns the max_inventory_size config setting in bytes.
+// A limit of 0 disables the size warning.
+func maxInventorySize() int64 {
+	limit := int64(appConfig.GetSizeInBytes("max_inventory_size"))
+	if limit == 0 {
+		return -1
+	}
+	return limit
+}
+
+var (
+	queryDefault     string
+	queryOutput      string
+	queryIndent      int
+	queryCompact     bool
+	queryVerbose     bool
+	queryCheckSyntax bool
+)
+
 // inventoryHierarchicalCmd represents the hierarchical inventory command
 var inventoryHierarchicalCmd = &cobra.Command{
 	Use:   "query",
@@ -35,18 +83,73 @@ var inventoryHierarchicalCmd = &cobra.Command{
 Examples:
   tsukuyo inventory query db.izuna-db.port
   tsukuyo inventory query db.izuna-db.[0].env
-  tsukuyo inventory query servers.[*].hostname`,
-	Args: cobra.MaximumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		hi, err := getHierarchicalInventory()
-		if err != nil {
+  tsukuyo inventory query servers.[*].hostname
+  tsukuyo inventory query 'keys(db)'
+  tsukuyo inventory query 'values(db.[*].host)'
+  tsukuyo inventory query 'length(db)'
+  tsukuyo inventory query db.missing --default '{"host":"localhost"}'
+  tsukuyo inventory query db --output json --compact
+  tsukuyo inventory query db --output table --columns host,type,remote_port
+  tsukuyo inventory query db.izuna-db --output-env > .env
+  tsukuyo inventory query db --output-env --flat
+  tsukuyo inventory query db.izuna-db.host node.web1 --output-env
+  tsukuyo inventory query --check-syntax 'db["a.b"].tags[0]'
+  tsukuyo inventory query servers.web --output json | tsukuyo inventory query --from-stdin '.[*].host'`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 && !queryOutputEnv {
+			return fmt.Errorf("accepts at most 1 query unless --output-env is set, received %d", len(args))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if queryCheckSyntax {
+			if len(args) == 0 {
+				return fmt.Errorf("--check-syntax requires a query")
+			}
+			cmd.SilenceUsage = true
+			if err := inventory.ValidateQuery(args[0]); err != nil {
+				return fmt.Errorf("invalid query syntax: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Syntax OK:", args[0])
+			return nil
+		}
+
+		var hi *inventory.HierarchicalInventory
+		var err error
+		if queryFromStdin {
+			cmd.SilenceUsage = true
+			if hi, err = stdinInventory(cmd.InOrStdin()); err != nil {
+				return err
+			}
+		} else if hi, err = getHierarchicalInventory(); err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Failed to initialize hierarchical inventory:", err)
-			return
+			return nil
+		}
+
+		if queryOutputEnv && len(args) > 1 {
+			paths := make([]string, len(args))
+			for i, arg := range args {
+				paths[i] = expandAlias(hi, arg)
+				if queryFromStdin {
+					paths[i] = stdinQueryPath(arg)
+				}
+			}
+			env, err := formatPathsAsEnv(hi, args, paths, queryFlat, queryEnvPrefix)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Query failed:", err)
+				return nil
+			}
+			if env != "" {
+				fmt.Fprintln(cmd.OutOrStdout(), env)
+			}
+			return nil
 		}
 
 		var query string
 		if len(args) > 0 {
 			query = args[0]
+		} else if queryFromStdin {
+			query = "."
 		} else {
 			// Interactive mode
 			prompt := promptui.Prompt{
@@ -55,37 +158,89 @@ Examples:
 			query, err = prompt.Run()
 			if err != nil {
 				fmt.Fprintln(cmd.OutOrStdout(), "Prompt failed:", err)
-				return
+				return nil
 			}
 		}
 
-		result, err := hi.Query(query)
+		path := expandAlias(hi, query)
+		if queryFromStdin {
+			path = stdinQueryPath(query)
+		}
+		result, err := hi.Query(path)
 		if err != nil {
-			fmt.Fprintln(cmd.OutOrStdout(), "Query failed:", err)
-			return
+			if !cmd.Flags().Changed("default") {
+				fmt.Fprintln(cmd.OutOrStdout(), "Query failed:", err)
+				return nil
+			}
+			result = parseJSONValue(queryDefault)
+		}
+
+		if queryOutput != "text" && queryOutput != "json" && queryOutput != "table" {
+			fmt.Fprintln(cmd.OutOrStdout(), "Unsupported output format:", queryOutput)
+			return nil
+		}
+		if len(queryColumns) > 0 && queryOutput != "table" {
+			fmt.Fprintln(cmd.OutOrStdout(), "--columns requires --output table")
+			return nil
+		}
+		if queryIndent < 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "--indent must not be negative")
+			return nil
+		}
+
+		if queryOutputEnv {
+			if env := formatAsEnv(query, result, queryFlat, queryEnvPrefix); env != "" {
+				fmt.Fprintln(cmd.OutOrStdout(), env)
+			}
+			return nil
+		}
+
+		if queryOutput == "table" {
+			if err := writeQueryTable(cmd.OutOrStdout(), result, queryColumns); err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to render table:", err)
+			}
+			return nil
 		}
 
 		// Format output
-		if query == "" {
+		if query == "" && queryOutput == "text" {
 			// Root query - show available top-level keys
 			keys, err := hi.List("")
 			if err != nil {
 				fmt.Fprintln(cmd.OutOrStdout(), "Failed to list keys:", err)
-				return
+				return nil
 			}
+			keys = withoutReservedKeys(keys)
+			sort.Strings(keys)
 			fmt.Fprintln(cmd.OutOrStdout(), "Available top-level keys:")
 			for _, key := range keys {
 				fmt.Fprintln(cmd.OutOrStdout(), "-", key)
 			}
-			return
+			return nil
+		}
+
+		if queryVerbose && queryOutput == "text" {
+			if comment, ok := loadComments(hi)[path]; ok {
+				fmt.Fprintln(cmd.OutOrStdout(), "#", comment)
+			}
 		}
 
 		// Format the result for display
+		if queryOutput == "json" {
+			jsonBytes, err := marshalQueryJSON(result)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to encode result:", err)
+				return nil
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(jsonBytes))
+			return nil
+		}
+
 		switch v := result.(type) {
 		case string:
 			fmt.Fprintln(cmd.OutOrStdout(), v)
 		case map[string]interface{}, []interface{}:
-			jsonBytes, err := json.MarshalIndent(v, "", "  ")
+			jsonBytes, err := marshalQueryJSON(v)
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStdout(), "%v\n", v)
 			} else {
@@ -94,9 +249,18 @@ Examples:
 		default:
 			fmt.Fprintf(cmd.OutOrStdout(), "%v\n", v)
 		}
+		return nil
 	},
 }
 
+// marshalQueryJSON encodes a query result honouring --indent and --compact
+func marshalQueryJSON(v interface{}) ([]byte, error) {
+	if queryCompact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", strings.Repeat(" ", queryIndent))
+}
+
 var inventorySetCmd = &cobra.Command{
 	Use:   "set [query] [value]",
 	Short: "Set a value in hierarchical inventory",
@@ -105,7 +269,11 @@ var inventorySetCmd = &cobra.Command{
 Examples:
   tsukuyo inventory set db.izuna-db.host "kureya.howlingmoon.dev"
   tsukuyo inventory set db.izuna-db.port 2333
-  tsukuyo inventory set servers.web.enabled true`,
+  tsukuyo inventory set servers.web.enabled true
+  tsukuyo inventory set servers.web.zip 01234 --as-string
+  tsukuyo inventory set servers.web.weight 007 --type-hint number
+  tsukuyo inventory set db.prod.host "x" --watch inv.json
+  tsukuyo inventory set --watch-dir ./inventory.d`,
 	Args: cobra.MaximumNArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		hi, err := getHierarchicalInventory()
@@ -114,6 +282,14 @@ Examples:
 			return
 		}
 
+		if setWatchDir != "" && len(args) == 0 {
+			// Only keep the directory imported
+			importDir := func() { importWatchDirVerbose(cmd, hi) }
+			importDir()
+			runSetWatch(cmd, importDir)
+			return
+		}
+
 		var query, valueStr string
 		if len(args) > 0 {
 			query = args[0]
@@ -146,23 +322,123 @@ Examples:
 			return
 		}
 
-		// Try to parse value as JSON first, then fall back to string
-		var value interface{}
-		if err := json.Unmarshal([]byte(valueStr), &value); err != nil {
-			// Not valid JSON, treat as string
-			value = valueStr
+		hint := setTypeHint
+		if setAsString {
+			if hint != "" && hint != "string" {
+				fmt.Fprintf(cmd.OutOrStdout(), "--as-string cannot be combined with --type-hint %s\n", hint)
+				return
+			}
+			hint = "string"
 		}
-
-		err = hi.Set(query, value)
+		value, err := parseSetValue(valueStr, hint)
 		if err != nil {
-			fmt.Fprintln(cmd.OutOrStdout(), "Failed to set value:", err)
+			fmt.Fprintln(cmd.OutOrStdout(), "Invalid value:", err)
 			return
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Set %s = %v\n", query, value)
+		warnShadowedKey(cmd, cmd.Parent(), topLevelKey(expandAlias(hi, query)))
+
+		apply := func() {
+			if setWatchDir != "" && !importWatchDirVerbose(cmd, hi) {
+				return
+			}
+			if err := hi.Set(expandAlias(hi, query), value); err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to set value:", err)
+				var conflict *inventory.PathConflictError
+				if errors.As(err, &conflict) {
+					fmt.Fprintf(cmd.OutOrStdout(), "Run 'tsukuyo inventory delete %s' first to replace it.\n", conflict.Conflict)
+				}
+				return
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Set %s = %v\n", query, value)
+		}
+
+		apply()
+		if setWatchFile != "" || setWatchDir != "" {
+			runSetWatch(cmd, apply)
+		}
 	},
 }
 
+var (
+	setAsString bool
+	setTypeHint string
+)
+
+// parseSetValue converts a command-line value according to a --type-hint:
+// "string" keeps it as is, "number", "bool" and "json" require it to parse as
+// that type, and an empty hint infers the type with inferSetValue
+func parseSetValue(valueStr, hint string) (interface{}, error) {
+	switch hint {
+	case "":
+		return inferSetValue(valueStr), nil
+	case "string":
+		return valueStr, nil
+	case "number":
+		n, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a number", valueStr)
+		}
+		return n, nil
+	case "bool":
+		b, err := strconv.ParseBool(valueStr)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a bool", valueStr)
+		}
+		return b, nil
+	case "json":
+		var value interface{}
+		if err := json.Unmarshal([]byte(valueStr), &value); err != nil {
+			return nil, fmt.Errorf("'%s' is not valid JSON: %v", valueStr, err)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unsupported --type-hint '%s' (use string, number, bool or json)", hint)
+	}
+}
+
+// inferSetValue converts a command-line value to the type it looks like:
+// integers become int64 and true/false become bool. Anything else is parsed
+// as JSON, falling back to the raw string. Integers are only inferred in
+// their canonical form, so values such as "007" stay strings.
+func inferSetValue(valueStr string) interface{} {
+	if n, err := strconv.ParseInt(valueStr, 10, 64); err == nil && strconv.FormatInt(n, 10) == valueStr {
+		return n
+	}
+	switch valueStr {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(valueStr), &value); err != nil {
+		return valueStr
+	}
+	return value
+}
+
+var (
+	deleteRecursive bool
+	deleteDryRun    bool
+)
+
+// deleteConfirmer asks whether a path with children should be deleted
+var deleteConfirmer = func(query string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Delete %s and everything below it?", query),
+		IsConfirm: true,
+	}
+	if _, err := prompt.Run(); err != nil {
+		if err == promptui.ErrAbort {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 var inventoryDeleteCmd = &cobra.Command{
 	Use:   "delete [query]",
 	Short: "Delete a value from hierarchical inventory",
@@ -170,7 +446,10 @@ var inventoryDeleteCmd = &cobra.Command{
 	
 Examples:
   tsukuyo inventory delete db.izuna-db.port
-  tsukuyo inventory delete servers.web`,
+  tsukuyo inventory delete servers.web
+  tsukuyo inventory delete db --recursive   # Delete a subtree without asking
+  tsukuyo inventory delete db --dry-run     # Show what would be deleted
+  tsukuyo inventory delete --names-file decommissioned.json`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		hi, err := getHierarchicalInventory()
@@ -179,6 +458,15 @@ Examples:
 			return
 		}
 
+		if deleteNamesFile != "" {
+			if len(args) > 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "--names-file cannot be combined with a query argument")
+				return
+			}
+			deleteFromNamesFile(cmd, hi)
+			return
+		}
+
 		var query string
 		if len(args) > 0 {
 			query = args[0]
@@ -198,6 +486,37 @@ Examples:
 			return
 		}
 
+		value, err := hi.Query(query)
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to delete:", err)
+			return
+		}
+		children := collectListPaths(query, value, math.MaxInt)
+
+		if deleteDryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Would delete %s\n", query)
+			for _, path := range children {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", path)
+			}
+			return
+		}
+
+		if len(children) > 0 && !deleteRecursive {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s contains %d path(s):\n", query, len(children))
+			for _, path := range children {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", path)
+			}
+			ok, err := deleteConfirmer(query)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Prompt failed:", err)
+				return
+			}
+			if !ok {
+				fmt.Fprintln(cmd.OutOrStdout(), "Aborted.")
+				return
+			}
+		}
+
 		err = hi.Delete(query)
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Failed to delete:", err)
@@ -208,15 +527,28 @@ Examples:
 	},
 }
 
+var (
+	listDepth       int
+	listVerbose     bool
+	listFormat      string
+	listSince       string
+	listSortByMtime bool
+	listNewestFirst bool
+)
+
 var inventoryListCmd = &cobra.Command{
 	Use:   "list [query]",
 	Short: "List keys at a specific path in hierarchical inventory",
 	Long: `List available keys at a specific path in the hierarchical inventory.
 	
 Examples:
-  tsukuyo inventory list           # List top-level keys
+  tsukuyo inventory list           # List top-level keys with type descriptions
   tsukuyo inventory list db        # List keys under 'db'
-  tsukuyo inventory list db.izuna-db  # List keys under 'db.izuna-db'`,
+  tsukuyo inventory list db.izuna-db  # List keys under 'db.izuna-db'
+  tsukuyo inventory list --depth 2 db # List full paths two levels below 'db'
+  tsukuyo inventory list --format json-paths db  # Every leaf path below 'db', one per line
+  tsukuyo inventory list db --since 24h          # Entries in 'db' modified in the last 24 hours
+  tsukuyo inventory list db --sort-by-mtime      # Entries in 'db', least recently modified first`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		hi, err := getHierarchicalInventory()
@@ -230,11 +562,51 @@ Examples:
 			query = args[0]
 		}
 
+		switch listFormat {
+		case "text", "json-paths":
+		default:
+			fmt.Fprintln(cmd.OutOrStdout(), "Unsupported list format:", listFormat)
+			return
+		}
+
+		if listSince != "" {
+			printModifiedSince(cmd, hi, query, listSince)
+			return
+		}
+		if listFormat == "json-paths" {
+			printLeafPaths(cmd, hi, query)
+			return
+		}
+
+		if listDepth < 1 {
+			fmt.Fprintln(cmd.OutOrStdout(), "--depth must be at least 1")
+			return
+		}
+		if listDepth > 1 {
+			printListTree(cmd, hi, query, listDepth, listVerbose)
+			return
+		}
+
 		keys, err := hi.List(query)
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Failed to list keys:", err)
 			return
 		}
+		if query == "" {
+			keys = withoutReservedKeys(keys)
+			for _, key := range keys {
+				warnShadowedKey(cmd, cmd.Parent(), key)
+			}
+		} else {
+			warnShadowedKey(cmd, cmd.Parent(), topLevelKey(query))
+		}
+		if listSortByMtime || listNewestFirst {
+			if strings.ContainsAny(query, ".[") {
+				fmt.Fprintln(cmd.OutOrStdout(), "--sort-by-mtime sorts whole entries: pass a type name such as 'db', or no path")
+				return
+			}
+			sortKeysByMtime(hi, query, keys, listNewestFirst)
+		}
 
 		if len(keys) == 0 {
 			fmt.Fprintf(cmd.OutOrStdout(), "No keys found at path '%s'\n", query)
@@ -246,22 +618,259 @@ Examples:
 		} else {
 			fmt.Fprintf(cmd.OutOrStdout(), "Keys at '%s':\n", query)
 		}
+		comments := map[string]string{}
+		if listVerbose {
+			comments = loadComments(hi)
+		}
+		descriptions := map[string]string{}
+		if query == "" {
+			descriptions = loadDescriptions(hi)
+		}
 		for _, key := range keys {
-			fmt.Fprintln(cmd.OutOrStdout(), "-", key)
+			path := key
+			if query != "" {
+				path = query + "." + key
+			}
+			if strings.Contains(key, ".") {
+				path = query + `["` + key + `"]`
+			}
+			value, _ := hi.Query(path)
+			line := fmt.Sprintf("- %s (%s)", key, describeListValue(value))
+			if description, ok := descriptions[key]; ok {
+				line += " - " + description
+			}
+			if comment, ok := comments[strings.TrimPrefix(query+"."+key, ".")]; ok {
+				line += "  # " + comment
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), line)
 		}
 	},
 }
 
+// describeListValue summarises the shape of a listed value: the number of
+// fields of a map, the length of an array or the scalar type
+func describeListValue(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			return "1 field"
+		}
+		return fmt.Sprintf("%d fields", len(v))
+	case []interface{}:
+		return fmt.Sprintf("array[%d]", len(v))
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int64, float64:
+		return "number"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// printListTree prints the full paths found up to depth levels below query
+func printListTree(cmd *cobra.Command, hi *inventory.HierarchicalInventory, query string, depth int, verbose bool) {
+	result, err := hi.Query(query)
+	if err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Failed to list keys:", err)
+		return
+	}
+
+	if root, ok := result.(map[string]interface{}); ok && query == "" {
+		entries := make(map[string]interface{}, len(root))
+		for key, value := range root {
+			if !isReservedKey(key) {
+				entries[key] = value
+			}
+		}
+		result = entries
+	}
+	paths := collectListPaths(query, result, depth)
+	if len(paths) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No keys found at path '%s'\n", query)
+		return
+	}
+	comments := map[string]string{}
+	if verbose {
+		comments = loadComments(hi)
+	}
+	for _, path := range paths {
+		if comment, ok := comments[path]; ok {
+			fmt.Fprintf(cmd.OutOrStdout(), "- %s  # %s\n", path, comment)
+			continue
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "-", path)
+	}
+}
+
+// printModifiedSince lists the entries of query, a type name or empty for
+// every type, whose _meta updated_at falls within the last since. Entries
+// written before metadata was recorded are not shown.
+func printModifiedSince(cmd *cobra.Command, hi *inventory.HierarchicalInventory, query, since string) {
+	window, err := time.ParseDuration(since)
+	if err != nil || window <= 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "Invalid --since duration '%s': use a positive duration such as 30m or 24h\n", since)
+		return
+	}
+	if strings.ContainsAny(query, ".[") {
+		fmt.Fprintln(cmd.OutOrStdout(), "--since lists whole entries: pass a type name such as 'db', or no path")
+		return
+	}
+
+	paths, err := hi.ModifiedSince(query, time.Now().Add(-window))
+	if err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Failed to list keys:", err)
+		return
+	}
+	if listFormat == "json-paths" {
+		for _, path := range paths {
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+		}
+		return
+	}
+	if len(paths) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No entries modified in the last %s\n", since)
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Entries modified in the last %s:\n", since)
+	for _, path := range paths {
+		updated, _ := hi.Query(inventory.MetaKey + "." + path + ".updated_at")
+		fmt.Fprintf(cmd.OutOrStdout(), "- %s  (updated %v)\n", path, updated)
+	}
+}
+
+// sortKeysByMtime orders the keys listed under query by the updated_at in
+// their _meta, oldest first unless newestFirst. At the root a type counts as
+// modified when its newest entry was. Keys without metadata sort as oldest,
+// and ties keep alphabetical order.
+func sortKeysByMtime(hi *inventory.HierarchicalInventory, query string, keys []string, newestFirst bool) {
+	meta, _ := hi.Query(inventory.MetaKey)
+	metaMap, _ := meta.(map[string]interface{})
+
+	updatedAt := func(entryMeta interface{}) time.Time {
+		fields, _ := entryMeta.(map[string]interface{})
+		updated, _ := fields["updated_at"].(string)
+		stamp, _ := time.Parse(time.RFC3339, updated)
+		return stamp
+	}
+	mtimes := make(map[string]time.Time, len(keys))
+	for _, key := range keys {
+		if query != "" {
+			typeMeta, _ := metaMap[query].(map[string]interface{})
+			mtimes[key] = updatedAt(typeMeta[key])
+			continue
+		}
+		typeMeta, _ := metaMap[key].(map[string]interface{})
+		for _, entryMeta := range typeMeta {
+			if stamp := updatedAt(entryMeta); stamp.After(mtimes[key]) {
+				mtimes[key] = stamp
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	sort.SliceStable(keys, func(i, j int) bool {
+		if newestFirst {
+			return mtimes[keys[i]].After(mtimes[keys[j]])
+		}
+		return mtimes[keys[i]].Before(mtimes[keys[j]])
+	})
+}
+
+// printLeafPaths prints the full path of every leaf below query, one per line
+// and without decoration, so the output can feed shell loops. Reserved keys
+// such as _meta are skipped when listing from the root.
+func printLeafPaths(cmd *cobra.Command, hi *inventory.HierarchicalInventory, query string) {
+	paths, err := hi.ListRecursive(query)
+	if err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), "Failed to list keys:", err)
+		return
+	}
+	for _, path := range paths {
+		fmt.Fprintln(cmd.OutOrStdout(), path)
+	}
+}
+
+// collectListPaths returns the paths below prefix down to depth levels.
+// Values that cannot be descended into end their branch early. Array
+// elements use the [N] index syntax.
+func collectListPaths(prefix string, value interface{}, depth int) []string {
+	join := func(segment string) string {
+		if strings.Contains(segment, ".") {
+			return prefix + `["` + segment + `"]`
+		}
+		if prefix == "" {
+			return segment
+		}
+		return prefix + "." + segment
+	}
+
+	var paths []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			paths = append(paths, collectListChild(join(key), v[key], depth)...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			paths = append(paths, collectListChild(join(fmt.Sprintf("[%d]", i)), item, depth)...)
+		}
+	}
+	return paths
+}
+
+func collectListChild(path string, value interface{}, depth int) []string {
+	if depth > 1 {
+		if children := collectListPaths(path, value, depth-1); len(children) > 0 {
+			return children
+		}
+	}
+	return []string{path}
+}
+
+var (
+	importFormat string
+	importDryRun bool
+)
+
 var inventoryImportCmd = &cobra.Command{
-	Use:   "import",
-	Short: "Import legacy inventory files into hierarchical format",
-	Long: `Import existing *-inventory.json files into the new hierarchical format.
-This will migrate db-inventory.json, node-inventory.json, etc. into a unified structure.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:   "import [file]",
+	Short: "Import inventory data from a file or legacy inventory files",
+	Long: `Import inventory data from a JSON, YAML, dotenv or CSV file into the hierarchical inventory.
+Without --format the format is detected from the file extension (defaulting to JSON).
+
+Without a file, existing *-inventory.json files are imported into the new hierarchical format.
+This will migrate db-inventory.json, node-inventory.json, etc. into a unified structure.
+
+Examples:
+  tsukuyo inventory import inventory.yaml
+  tsukuyo inventory import hosts.txt --format csv
+  tsukuyo inventory import inventory.yaml --on-conflict skip
+  tsukuyo inventory import inventory.yaml --dry-run
+  tsukuyo inventory import s3://my-bucket/tsukuyo/backup.json
+  tsukuyo inventory import`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		hi, err := getHierarchicalInventory()
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Failed to initialize hierarchical inventory:", err)
-			return
+			return nil
+		}
+
+		if len(args) > 0 {
+			return importFromFile(cmd, hi, args[0])
+		}
+		if importDryRun {
+			fmt.Fprintln(cmd.OutOrStdout(), "--dry-run requires an import file")
+			return nil
 		}
 
 		// The inventory will automatically load from existing files during initialization
@@ -270,12 +879,12 @@ This will migrate db-inventory.json, node-inventory.json, etc. into a unified st
 		files, err := os.ReadDir(dataDir)
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Failed to read data directory:", err)
-			return
+			return nil
 		}
 
 		imported := 0
 		for _, file := range files {
-			if strings.HasSuffix(file.Name(), "-inventory.json") && file.Name() != "hierarchical-inventory.json" {
+			if strings.HasSuffix(file.Name(), "-inventory.json") && !strings.HasPrefix(file.Name(), "hierarchical-inventory") {
 				fmt.Fprintf(cmd.OutOrStdout(), "Found legacy inventory file: %s\n", file.Name())
 				imported++
 			}
@@ -283,7 +892,7 @@ This will migrate db-inventory.json, node-inventory.json, etc. into a unified st
 
 		if imported == 0 {
 			fmt.Fprintln(cmd.OutOrStdout(), "No legacy inventory files found.")
-			return
+			return nil
 		}
 
 		fmt.Fprintf(cmd.OutOrStdout(), "Imported %d legacy inventory files into hierarchical format.\n", imported)
@@ -291,19 +900,163 @@ This will migrate db-inventory.json, node-inventory.json, etc. into a unified st
 
 		// Show available top-level keys
 		keys, err := hi.List("")
+		keys = withoutReservedKeys(keys)
 		if err == nil && len(keys) > 0 {
 			fmt.Fprintln(cmd.OutOrStdout(), "\nAvailable top-level keys:")
 			for _, key := range keys {
 				fmt.Fprintln(cmd.OutOrStdout(), "-", key)
 			}
 		}
+		return nil
 	},
 }
 
+// importFromFile imports the entries of a single file into the inventory.
+// With --dry-run the changes are only printed, and an error is returned if
+// there are any so that the command exits non-zero.
+func importFromFile(cmd *cobra.Command, hi *inventory.HierarchicalInventory, path string) error {
+	format := importFormat
+	if format == "" {
+		format = detectImportFormat(path)
+		fmt.Fprintln(cmd.OutOrStdout(), "Detected format:", format)
+	}
+
+	localPath := path
+	if isS3URI(path) {
+		downloaded, cleanup, err := downloadFromS3(context.Background(), path)
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to download import file:", err)
+			return nil
+		}
+		defer cleanup()
+		localPath = downloaded
+	}
+
+	entries, err := parseImportFile(localPath, format)
+	if err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Failed to read import file:", err)
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No entries found in", path)
+		return nil
+	}
+
+	policy := onConflict
+	if importDryRun && policy == "" {
+		// Preview what overwriting would do rather than prompting
+		policy = conflictOverwrite
+	}
+	entries, err = resolveImportConflicts(cmd, hi, entries, policy)
+	if err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Failed to import:", err)
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Nothing to import from", path)
+		return nil
+	}
+
+	if importDryRun {
+		return previewImport(cmd, hi, entries)
+	}
+
+	imported := len(entries)
+	if err := hi.SetBulk(entries); err != nil {
+		bulkErr, ok := err.(inventory.BulkSetError)
+		if !ok {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to import:", err)
+			return nil
+		}
+		for _, pathErr := range bulkErr {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to import", pathErr.Path+":", pathErr.Err)
+		}
+		imported -= len(bulkErr)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Imported %d entries from %s\n", imported, path)
+	return nil
+}
+
+// previewImport applies entries to a scratch copy of the inventory and prints
+// the resulting diff. Metadata updates under _meta are not shown.
+func previewImport(cmd *cobra.Command, hi *inventory.HierarchicalInventory, entries map[string]interface{}) error {
+	scratchDir, err := os.MkdirTemp("", "tsukuyo-import-dry-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratchDir)
+
+	scratch, err := hi.Clone(scratchDir)
+	if err != nil {
+		return err
+	}
+	if err := scratch.SetBulk(entries); err != nil {
+		if bulkErr, ok := err.(inventory.BulkSetError); ok {
+			for _, pathErr := range bulkErr {
+				fmt.Fprintln(cmd.OutOrStdout(), "Would fail to import", pathErr.Path+":", pathErr.Err)
+			}
+		} else {
+			return err
+		}
+	}
+
+	diff, err := hi.Diff(scratch)
+	if err != nil {
+		return fmt.Errorf("failed to compare inventories: %w", err)
+	}
+	var changes []inventory.DiffEntry
+	for _, entry := range diff {
+		if !strings.HasPrefix(entry.Path, inventory.MetaKey+".") {
+			changes = append(changes, entry)
+		}
+	}
+
+	printDiff(cmd.OutOrStdout(), changes)
+	if len(changes) > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("import would change %d path(s)", len(changes))
+	}
+	return nil
+}
+
 func init() {
 	inventoryCmd.AddCommand(inventoryHierarchicalCmd)
 	inventoryCmd.AddCommand(inventorySetCmd)
 	inventoryCmd.AddCommand(inventoryDeleteCmd)
 	inventoryCmd.AddCommand(inventoryListCmd)
 	inventoryCmd.AddCommand(inventoryImportCmd)
+
+	inventoryHierarchicalCmd.Flags().StringVar(&queryOutput, "output", "text", "Output format: text, json or table")
+	inventoryHierarchicalCmd.Flags().StringSliceVar(&queryColumns, "columns", nil, "With --output table, the fields to show as columns ('*' for every field)")
+	inventoryHierarchicalCmd.Flags().IntVar(&queryIndent, "indent", 2, "Number of spaces to indent JSON output")
+	inventoryHierarchicalCmd.Flags().BoolVar(&queryCompact, "compact", false, "Emit JSON on a single line")
+	inventoryHierarchicalCmd.Flags().BoolVar(&queryCheckSyntax, "check-syntax", false, "Only check that the query parses, without reading the inventory; exits 1 on a syntax error")
+	inventoryHierarchicalCmd.Flags().BoolVar(&queryFromStdin, "from-stdin", false, "Query a JSON value read from stdin instead of the inventory; a leading '.' is the piped value")
+	inventoryHierarchicalCmd.Flags().BoolVarP(&queryVerbose, "verbose", "v", false, "Show the path's comment above the result")
+	inventoryHierarchicalCmd.Flags().BoolVar(&queryOutputEnv, "output-env", false, "Print the result as sorted KEY=value lines for a .env file")
+	inventoryHierarchicalCmd.Flags().BoolVar(&queryFlat, "flat", false, "With --output-env, include nested fields as PARENT_CHILD=value")
+	inventoryHierarchicalCmd.Flags().StringVar(&queryEnvPrefix, "env-prefix", "", "With --output-env, prepend PREFIX_ to every variable name")
+	inventoryHierarchicalCmd.Flags().StringVar(&queryDefault, "default", "", "Value (JSON or string) to print when the path does not exist")
+
+	inventorySetCmd.Flags().StringVar(&setWatchFile, "watch", "", "Keep running and re-apply the set whenever this file changes (Ctrl-C to stop)")
+	inventorySetCmd.Flags().StringVar(&setWatchDir, "watch-dir", "", "Keep running and re-import every JSON/YAML file in this directory whenever one changes")
+	inventorySetCmd.Flags().BoolVar(&setAsString, "as-string", false, "Store the value as a string without inferring numbers, booleans or JSON")
+	inventorySetCmd.Flags().StringVar(&setTypeHint, "type-hint", "", "Parse the value as string, number, bool or json instead of inferring its type")
+
+	inventoryDeleteCmd.Flags().BoolVarP(&deleteRecursive, "recursive", "r", false, "Delete a path that has children without asking for confirmation")
+	inventoryDeleteCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "Print the paths that would be deleted without deleting them")
+	inventoryDeleteCmd.Flags().StringVar(&deleteNamesFile, "names-file", "", "Delete every path in this JSON array file (e.g. [\"db.old-server\"]) and save once")
+
+	inventoryListCmd.Flags().IntVar(&listDepth, "depth", 1, "Number of levels to list below the path")
+	inventoryListCmd.Flags().BoolVarP(&listVerbose, "verbose", "v", false, "Show path comments inline")
+	inventoryListCmd.Flags().StringVar(&listFormat, "format", "text", "Output format: text, or json-paths for every leaf path, one per line")
+	inventoryListCmd.Flags().StringVar(&listSince, "since", "", "Only list entries whose metadata shows a change within this duration (e.g. 24h)")
+	inventoryListCmd.Flags().BoolVar(&listSortByMtime, "sort-by-mtime", false, "Order entries by the updated_at in their metadata, least recently modified first")
+	inventoryListCmd.Flags().BoolVar(&listNewestFirst, "newest-first", false, "With --sort-by-mtime, list the most recently modified entries first")
+
+	inventoryImportCmd.Flags().StringVar(&importFormat, "format", "", "Import format: json, yaml, dotenv or csv (detected from the file extension if empty)")
+	inventoryImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Print the changes the import would make without writing them; exits 1 if there are any")
+	inventoryImportCmd.Flags().StringVar(&onConflict, "on-conflict", "", "How to handle paths that already exist: skip, overwrite or error (prompts if empty)")
 }
@@ -0,0 +1,678 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runQueryCmd runs the query command directly with its output captured.
+// The command may have been re-parented by simpleCommandTest, so it is not
+// driven through rootCmd.
+func runQueryCmd(t *testing.T, flags map[string]string, args ...string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	inventoryHierarchicalCmd.SetOut(&buf)
+	defer inventoryHierarchicalCmd.SetOut(nil)
+
+	for name, value := range flags {
+		assert.NoError(t, inventoryHierarchicalCmd.Flags().Set(name, value))
+	}
+	defer func() {
+		for name := range flags {
+			flag := inventoryHierarchicalCmd.Flags().Lookup(name)
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	}()
+
+	assert.NoError(t, inventoryHierarchicalCmd.RunE(inventoryHierarchicalCmd, args))
+	return buf.String()
+}
+
+func TestInventoryQueryDefault(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.server1.host", "db1.example.com"))
+
+	// Existing paths ignore the default
+	output := runQueryCmd(t, map[string]string{"default": "fallback"}, "db.server1.host")
+	assert.Equal(t, "db1.example.com\n", output)
+
+	// Missing paths return the default, parsed as JSON when possible
+	output = runQueryCmd(t, map[string]string{"default": `{"host":"localhost"}`}, "db.missing")
+	assert.NotContains(t, output, "Query failed")
+	assert.Contains(t, output, `"host": "localhost"`)
+
+	output = runQueryCmd(t, map[string]string{"default": "localhost"}, "db.missing.host")
+	assert.Equal(t, "localhost\n", output)
+
+	// Without --default a missing path is still an error
+	output = runQueryCmd(t, nil, "db.missing")
+	assert.Contains(t, output, "Query failed")
+}
+
+func TestInventoryAliases(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.prod-postgres-master.host", "pg.example.com"))
+
+	var buf bytes.Buffer
+	inventoryAliasSetCmd.SetOut(&buf)
+	defer inventoryAliasSetCmd.SetOut(nil)
+	assert.NoError(t, inventoryAliasSetCmd.RunE(inventoryAliasSetCmd, []string{"prod-db", "db.prod-postgres-master"}))
+	assert.Error(t, inventoryAliasSetCmd.RunE(inventoryAliasSetCmd, []string{"bad.name", "db"}))
+
+	assert.Equal(t, "db.prod-postgres-master.host", expandAlias(hi, "prod-db.host"))
+	assert.Equal(t, "db.prod-postgres-master", expandAlias(hi, "prod-db"))
+	assert.Equal(t, "db.other", expandAlias(hi, "db.other"))
+
+	// Aliases work for both query and set paths
+	assert.Equal(t, "pg.example.com\n", runQueryCmd(t, nil, "prod-db.host"))
+	inventorySetCmd.SetOut(&buf)
+	defer inventorySetCmd.SetOut(nil)
+	inventorySetCmd.Run(inventorySetCmd, []string{"prod-db.port", "5432"})
+	port, err := hi.Query("db.prod-postgres-master.port")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5432, port)
+
+	buf.Reset()
+	inventoryAliasListCmd.SetOut(&buf)
+	defer inventoryAliasListCmd.SetOut(nil)
+	assert.NoError(t, inventoryAliasListCmd.RunE(inventoryAliasListCmd, nil))
+	assert.Equal(t, "prod-db -> db.prod-postgres-master\n", buf.String())
+
+	inventoryAliasDeleteCmd.SetOut(&buf)
+	defer inventoryAliasDeleteCmd.SetOut(nil)
+	assert.NoError(t, inventoryAliasDeleteCmd.RunE(inventoryAliasDeleteCmd, []string{"prod-db"}))
+	assert.Error(t, inventoryAliasDeleteCmd.RunE(inventoryAliasDeleteCmd, []string{"prod-db"}))
+	assert.Empty(t, loadAliases(hi))
+}
+
+func TestInventoryQueryJSONOutput(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.server1", map[string]interface{}{"host": "db1"}))
+
+	// Strings are quoted in JSON mode
+	output := runQueryCmd(t, map[string]string{"output": "json"}, "servers.server1.host")
+	assert.Equal(t, "\"db1\"\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output": "json"}, "servers")
+	assert.Equal(t, "{\n  \"server1\": {\n    \"host\": \"db1\"\n  }\n}\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output": "json", "indent": "4"}, "servers")
+	assert.Equal(t, "{\n    \"server1\": {\n        \"host\": \"db1\"\n    }\n}\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output": "json", "compact": "true"}, "servers")
+	assert.Equal(t, "{\"server1\":{\"host\":\"db1\"}}\n", output)
+
+	// Strings needing escapes and --default fallbacks are still valid JSON
+	assert.NoError(t, hi.Set("servers.server1.motd", "say \"hi\"\n\tbye"))
+	output = runQueryCmd(t, map[string]string{"output": "json"}, "servers.server1.motd")
+	var decoded string
+	assert.NoError(t, json.Unmarshal([]byte(output), &decoded))
+	assert.Equal(t, "say \"hi\"\n\tbye", decoded)
+
+	output = runQueryCmd(t, map[string]string{"output": "json", "default": "localhost"}, "servers.missing.host")
+	assert.Equal(t, "\"localhost\"\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output": "xml"}, "servers")
+	assert.Contains(t, output, "Unsupported output format")
+}
+
+func TestInventoryQueryTableOutput(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { queryColumns = nil }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1", "type": "nginx", "port": 443, "tags": []interface{}{"prod", "edge"}}))
+	assert.NoError(t, hi.Set("servers.db", map[string]interface{}{"host": "10.0.0.2", "type": "postgres"}))
+	assert.NoError(t, hi.Set("lists.hosts", []interface{}{
+		map[string]interface{}{"host": "a", "zone": "eu"},
+		map[string]interface{}{"host": "bb"},
+	}))
+
+	queryColumns = []string{"host", "port"}
+	output := runQueryCmd(t, map[string]string{"output": "table"}, "servers")
+	assert.Equal(t, "NAME  host      port\ndb    10.0.0.2  \nweb1  10.0.0.1  443\n", output)
+
+	// Every field, unioned across entries
+	queryColumns = []string{"*"}
+	output = runQueryCmd(t, map[string]string{"output": "table"}, "servers")
+	assert.Equal(t, "NAME  host      port  tags       type\ndb    10.0.0.2                   postgres\nweb1  10.0.0.1  443   prod,edge  nginx\n", output)
+
+	// Arrays have no NAME column
+	queryColumns = nil
+	output = runQueryCmd(t, map[string]string{"output": "table"}, "lists.hosts")
+	assert.Equal(t, "host  zone\na     eu\nbb    \n", output)
+
+	output = runQueryCmd(t, map[string]string{"output": "table"}, "servers.web1.host")
+	assert.Equal(t, "Failed to render table: table output needs a list or map of objects\n", output)
+
+	queryColumns = []string{"host"}
+	output = runQueryCmd(t, map[string]string{"output": "json"}, "servers")
+	assert.Equal(t, "--columns requires --output table\n", output)
+}
+
+func TestCollectListPaths(t *testing.T) {
+	data := map[string]interface{}{
+		"server1": map[string]interface{}{"host": "db1", "type": "postgres"},
+		"servers": []interface{}{"a", map[string]interface{}{"host": "b"}},
+		"empty":   map[string]interface{}{},
+		"version": "1.0",
+	}
+
+	assert.Equal(t, []string{"db.empty", "db.server1", "db.servers", "db.version"}, collectListPaths("db", data, 1))
+	assert.Equal(t, []string{
+		"db.empty",
+		"db.server1.host",
+		"db.server1.type",
+		"db.servers.[0]",
+		"db.servers.[1]",
+		"db.version",
+	}, collectListPaths("db", data, 2))
+	assert.Contains(t, collectListPaths("", map[string]interface{}{"db": data}, 4), "db.servers.[1].host")
+}
+
+func TestInventoryListDepth(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { listDepth = 1 }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web.host", "web1"))
+	assert.NoError(t, hi.Set("servers.web.port", 80))
+
+	var buf bytes.Buffer
+	inventoryListCmd.SetOut(&buf)
+	defer inventoryListCmd.SetOut(nil)
+
+	listDepth = 2
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Equal(t, "- servers.web.host\n- servers.web.port\n", buf.String())
+}
+
+func TestInventoryMeta(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1"}))
+
+	var buf bytes.Buffer
+	inventoryMetaCmd.SetOut(&buf)
+	defer inventoryMetaCmd.SetOut(nil)
+
+	assert.NoError(t, inventoryMetaCmd.RunE(inventoryMetaCmd, []string{"servers.web1"}))
+	assert.Contains(t, buf.String(), "created_at: ")
+	assert.Contains(t, buf.String(), "created_by: ")
+	assert.Contains(t, buf.String(), "updated_at: ")
+
+	assert.Error(t, inventoryMetaCmd.RunE(inventoryMetaCmd, []string{"servers.missing"}))
+}
+
+func TestInventoryNamespace(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		inventoryNamespace = ""
+		globalInventoryCache = nil
+		inventoryCacheOnce = sync.Once{}
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.home.host", "192.168.0.1"))
+
+	// Switch to the work namespace
+	inventoryNamespace = "work"
+	globalInventoryCache = nil
+	inventoryCacheOnce = sync.Once{}
+	work, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	_, err = work.Query("servers.home")
+	assert.Error(t, err, "default entries should not be visible in a namespace")
+	assert.NoError(t, work.Set("servers.office.host", "10.0.0.1"))
+	assert.FileExists(t, filepath.Join(tmpDir, "hierarchical-inventory-work.json"))
+
+	// Back in the default namespace the work entries are not visible
+	inventoryNamespace = ""
+	globalInventoryCache = nil
+	inventoryCacheOnce = sync.Once{}
+	def, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	_, err = def.Query("servers.office")
+	assert.Error(t, err)
+	result, err := def.Query("servers.home.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.0.1", result)
+}
+
+func TestInferSetValue(t *testing.T) {
+	assert.Equal(t, int64(5432), inferSetValue("5432"))
+	assert.Equal(t, int64(-1), inferSetValue("-1"))
+	assert.Equal(t, true, inferSetValue("true"))
+	assert.Equal(t, false, inferSetValue("false"))
+	assert.Equal(t, 1.5, inferSetValue("1.5"))
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, inferSetValue(`{"a":1}`))
+	assert.Equal(t, "007", inferSetValue("007"))
+	assert.Equal(t, "hello", inferSetValue("hello"))
+}
+
+func TestInventorySetAsString(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { setAsString = false }()
+
+	var buf bytes.Buffer
+	inventorySetCmd.SetOut(&buf)
+	defer inventorySetCmd.SetOut(nil)
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web.port", "5432"})
+	result, err := hi.Query("servers.web.port")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5432), result)
+
+	setAsString = true
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web.port", "5432"})
+	result, err = hi.Query("servers.web.port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", result)
+}
+
+func TestParseSetValue(t *testing.T) {
+	value, err := parseSetValue("007", "number")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(7), value)
+	value, err = parseSetValue("1", "bool")
+	assert.NoError(t, err)
+	assert.Equal(t, true, value)
+	value, err = parseSetValue("true", "string")
+	assert.NoError(t, err)
+	assert.Equal(t, "true", value)
+	value, err = parseSetValue(`["a"]`, "json")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a"}, value)
+	value, err = parseSetValue("5432", "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5432), value)
+
+	_, err = parseSetValue("abc", "number")
+	assert.EqualError(t, err, "'abc' is not a number")
+	_, err = parseSetValue("yes", "bool")
+	assert.EqualError(t, err, "'yes' is not a bool")
+	_, err = parseSetValue("{oops", "json")
+	assert.Error(t, err)
+	_, err = parseSetValue("1", "date")
+	assert.EqualError(t, err, "unsupported --type-hint 'date' (use string, number, bool or json)")
+}
+
+func TestInventorySetTypeHint(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		setTypeHint = ""
+		setAsString = false
+	}()
+
+	var buf bytes.Buffer
+	inventorySetCmd.SetOut(&buf)
+	defer inventorySetCmd.SetOut(nil)
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+
+	setTypeHint = "string"
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web.enabled", "true"})
+	result, err := hi.Query("servers.web.enabled")
+	assert.NoError(t, err)
+	assert.Equal(t, "true", result)
+
+	// A value that does not parse as the hinted type is rejected
+	buf.Reset()
+	setTypeHint = "json"
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web.enabled", "{oops"})
+	assert.Contains(t, buf.String(), "Invalid value: '{oops' is not valid JSON")
+	result, err = hi.Query("servers.web.enabled")
+	assert.NoError(t, err)
+	assert.Equal(t, "true", result)
+
+	buf.Reset()
+	setAsString = true
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web.enabled", "true"})
+	assert.Equal(t, "--as-string cannot be combined with --type-hint json\n", buf.String())
+}
+
+func TestInventorySetPathConflict(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	inventorySetCmd.SetOut(&buf)
+	defer inventorySetCmd.SetOut(nil)
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", "10.0.0.1"))
+
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web1.host", "x"})
+	assert.Contains(t, buf.String(), "cannot create path 'servers.web1.host': 'servers.web1' exists as a string value, not a map")
+	assert.Contains(t, buf.String(), "Run 'tsukuyo inventory delete servers.web1' first")
+
+	result, err := hi.Query("servers.web1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", result)
+}
+
+func TestInventoryDeleteRecursive(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { deleteRecursive, deleteDryRun = false, false }()
+	originalConfirmer := deleteConfirmer
+	defer func() { deleteConfirmer = originalConfirmer }()
+
+	var buf bytes.Buffer
+	inventoryDeleteCmd.SetOut(&buf)
+	defer inventoryDeleteCmd.SetOut(nil)
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1", "port": 22}))
+	assert.NoError(t, hi.Set("servers.web2.host", "10.0.0.2"))
+
+	// --dry-run lists the paths and leaves them in place
+	deleteDryRun = true
+	inventoryDeleteCmd.Run(inventoryDeleteCmd, []string{"servers.web1"})
+	assert.Equal(t, "Would delete servers.web1\n  servers.web1.host\n  servers.web1.port\n", buf.String())
+	_, err = hi.Query("servers.web1.host")
+	assert.NoError(t, err)
+	deleteDryRun = false
+
+	// a path with children asks first
+	asked := ""
+	deleteConfirmer = func(query string) (bool, error) {
+		asked = query
+		return false, nil
+	}
+	buf.Reset()
+	inventoryDeleteCmd.Run(inventoryDeleteCmd, []string{"servers.web1"})
+	assert.Equal(t, "servers.web1", asked)
+	assert.Contains(t, buf.String(), "servers.web1 contains 2 path(s):")
+	assert.Contains(t, buf.String(), "Aborted.")
+	_, err = hi.Query("servers.web1.host")
+	assert.NoError(t, err)
+
+	// leaves are deleted without asking
+	asked = ""
+	inventoryDeleteCmd.Run(inventoryDeleteCmd, []string{"servers.web1.port"})
+	assert.Empty(t, asked)
+	_, err = hi.Query("servers.web1.port")
+	assert.Error(t, err)
+
+	// --recursive skips the prompt
+	deleteRecursive = true
+	buf.Reset()
+	inventoryDeleteCmd.Run(inventoryDeleteCmd, []string{"servers"})
+	assert.Empty(t, asked)
+	assert.Equal(t, "Deleted servers\n", buf.String())
+	_, err = hi.Query("servers")
+	assert.Error(t, err)
+}
+
+func TestInventoryListSince(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { listSince = "" }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1.host", "10.0.0.1"))
+	assert.NoError(t, hi.Set("servers.old.host", "10.0.0.2"))
+	assert.NoError(t, hi.Set("apps.api.port", 8080))
+	assert.NoError(t, hi.Set("_meta.servers.old.updated_at", "2020-01-01T00:00:00Z"))
+
+	var buf bytes.Buffer
+	inventoryListCmd.SetOut(&buf)
+	defer inventoryListCmd.SetOut(nil)
+
+	listSince = "24h"
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Contains(t, buf.String(), "Entries modified in the last 24h:\n- servers.web1  (updated ")
+	assert.NotContains(t, buf.String(), "servers.old")
+	assert.NotContains(t, buf.String(), "apps.api")
+
+	buf.Reset()
+	inventoryListCmd.Run(inventoryListCmd, nil)
+	assert.Contains(t, buf.String(), "- apps.api  (updated ")
+	assert.Contains(t, buf.String(), "- servers.web1  (updated ")
+
+	buf.Reset()
+	listSince = "soon"
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Contains(t, buf.String(), "Invalid --since duration 'soon'")
+}
+
+func TestInventoryListSortByMtime(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { listSortByMtime, listNewestFirst = false, false }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1.host", "10.0.0.1"))
+	assert.NoError(t, hi.Set("servers.web2.host", "10.0.0.2"))
+	assert.NoError(t, hi.Set("servers.web3.host", "10.0.0.3"))
+	assert.NoError(t, hi.Set("apps.api.port", 8080))
+	assert.NoError(t, hi.Set("_meta.servers.web1.updated_at", "2024-03-01T00:00:00Z"))
+	assert.NoError(t, hi.Set("_meta.servers.web2.updated_at", "2024-01-01T00:00:00Z"))
+	assert.NoError(t, hi.Set("_meta.servers.web3.updated_at", "2024-02-01T00:00:00Z"))
+	assert.NoError(t, hi.Set("_meta.apps.api.updated_at", "2024-02-15T00:00:00Z"))
+
+	var buf bytes.Buffer
+	inventoryListCmd.SetOut(&buf)
+	defer inventoryListCmd.SetOut(nil)
+
+	listSortByMtime = true
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Regexp(t, `(?s)- web2 .*- web3 .*- web1 `, buf.String())
+
+	buf.Reset()
+	listNewestFirst = true
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Regexp(t, `(?s)- web1 .*- web3 .*- web2 `, buf.String())
+
+	// At the root a type sorts by its newest entry
+	buf.Reset()
+	listNewestFirst = false
+	inventoryListCmd.Run(inventoryListCmd, nil)
+	assert.Regexp(t, `(?s)- apps .*- servers `, buf.String())
+
+	buf.Reset()
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers.web1"})
+	assert.Contains(t, buf.String(), "--sort-by-mtime sorts whole entries")
+}
+
+func TestInventoryListShowsShape(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web", map[string]interface{}{"host": "web1", "port": 80}))
+	assert.NoError(t, hi.Set("servers.pool", []interface{}{"a", "b", "c"}))
+	assert.NoError(t, hi.Set("servers.owner", "ops"))
+	assert.NoError(t, hi.Set(`servers["web.internal"]`, map[string]interface{}{"host": "web2"}))
+
+	var buf bytes.Buffer
+	inventoryListCmd.SetOut(&buf)
+	defer inventoryListCmd.SetOut(nil)
+
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	output := buf.String()
+	assert.Contains(t, output, "- web (2 fields)\n")
+	assert.Contains(t, output, "- pool (array[3])\n")
+	assert.Contains(t, output, "- owner (string)\n")
+	assert.Contains(t, output, "- web.internal (1 field)\n")
+}
+
+func TestInventoryRootListingHidesReservedKeys(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	var setOut bytes.Buffer
+	inventorySetCmd.SetOut(&setOut)
+	defer inventorySetCmd.SetOut(nil)
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web.host", "10.0.0.1"})
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	_, err = hi.Query("_meta")
+	assert.NoError(t, err, "set should have recorded _meta")
+
+	output := runQueryCmd(t, nil, "")
+	assert.Contains(t, output, "- servers\n")
+	assert.NotContains(t, output, "_meta")
+
+	var buf bytes.Buffer
+	inventoryListCmd.SetOut(&buf)
+	defer inventoryListCmd.SetOut(nil)
+	inventoryListCmd.Run(inventoryListCmd, nil)
+	assert.Contains(t, buf.String(), "- servers (1 field)")
+	assert.NotContains(t, buf.String(), "_meta")
+
+	buf.Reset()
+	assert.NoError(t, inventoryListCmd.Flags().Set("depth", "2"))
+	defer func() { listDepth = 1 }()
+	inventoryListCmd.Run(inventoryListCmd, nil)
+	assert.Contains(t, buf.String(), "- servers.web\n")
+	assert.NotContains(t, buf.String(), "_meta")
+}
+
+func TestInventoryQueryCheckSyntax(t *testing.T) {
+	queryCheckSyntax = true
+	defer func() { queryCheckSyntax = false }()
+
+	var buf bytes.Buffer
+	inventoryHierarchicalCmd.SetOut(&buf)
+	defer inventoryHierarchicalCmd.SetOut(nil)
+
+	assert.NoError(t, inventoryHierarchicalCmd.RunE(inventoryHierarchicalCmd, []string{`db["a.b"].tags[0]`}))
+	assert.Equal(t, "Syntax OK: db[\"a.b\"].tags[0]\n", buf.String())
+
+	err := inventoryHierarchicalCmd.RunE(inventoryHierarchicalCmd, []string{"db..host"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid query syntax")
+
+	err = inventoryHierarchicalCmd.RunE(inventoryHierarchicalCmd, []string{"servers[0"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unclosed '['")
+
+	assert.Error(t, inventoryHierarchicalCmd.RunE(inventoryHierarchicalCmd, nil))
+}
+
+func TestInventoryQueryFromStdin(t *testing.T) {
+	defer inventoryHierarchicalCmd.SetIn(nil)
+	piped := `[{"name": "web1", "host": "10.0.0.1"}, {"name": "web2", "host": "10.0.0.2"}]`
+
+	inventoryHierarchicalCmd.SetIn(strings.NewReader(piped))
+	output := runQueryCmd(t, map[string]string{"from-stdin": "true", "compact": "true"}, ".[*].host")
+	assert.Equal(t, `["10.0.0.1","10.0.0.2"]`+"\n", output)
+
+	inventoryHierarchicalCmd.SetIn(strings.NewReader(piped))
+	assert.Equal(t, "web2\n", runQueryCmd(t, map[string]string{"from-stdin": "true"}, ".[1].name"))
+
+	inventoryHierarchicalCmd.SetIn(strings.NewReader(`{"server1": {"port": 5432}}`))
+	assert.Equal(t, "5432\n", runQueryCmd(t, map[string]string{"from-stdin": "true"}, "server1.port"))
+
+	inventoryHierarchicalCmd.SetIn(strings.NewReader(`{"server1": {"port": 5432}}`))
+	assert.Equal(t, `{"server1":{"port":5432}}`+"\n", runQueryCmd(t, map[string]string{"from-stdin": "true", "compact": "true"}, "."))
+
+	queryFromStdin = true
+	defer func() { queryFromStdin = false }()
+	inventoryHierarchicalCmd.SetIn(strings.NewReader("not json"))
+	err := inventoryHierarchicalCmd.RunE(inventoryHierarchicalCmd, []string{"."})
+	assert.ErrorContains(t, err, "invalid JSON on stdin")
+}
+
+func TestInventoryListJSONPaths(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1", "port": 5432, "tags": []interface{}{"prod"}}))
+	assert.NoError(t, hi.Set(`servers["web.internal"].host`, "10.0.0.2"))
+	assert.NoError(t, hi.Set("apps.owner", "ops"))
+
+	listFormat = "json-paths"
+	defer func() { listFormat = "text" }()
+
+	var buf bytes.Buffer
+	inventoryListCmd.SetOut(&buf)
+	defer inventoryListCmd.SetOut(nil)
+
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Equal(t, `servers["web.internal"].host
+servers.web1.host
+servers.web1.port
+servers.web1.tags.[0]
+`, buf.String())
+
+	// Every path is queryable
+	for _, path := range strings.Fields(buf.String()) {
+		_, err := hi.Query(path)
+		assert.NoError(t, err, path)
+	}
+
+	// From the root, reserved keys such as _meta are left out
+	buf.Reset()
+	inventoryListCmd.Run(inventoryListCmd, nil)
+	assert.Contains(t, buf.String(), "apps.owner\n")
+	assert.NotContains(t, buf.String(), "_meta")
+
+	buf.Reset()
+	listFormat = "xml"
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	assert.Equal(t, "Unsupported list format: xml\n", buf.String())
+}
+
+func TestInventoryQueryFunctions(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web2.host", "10.0.0.2"))
+	assert.NoError(t, hi.Set("servers.web1.host", "10.0.0.1"))
+
+	output := runQueryCmd(t, map[string]string{"output": "json", "compact": "true"}, "keys(servers)")
+	assert.Equal(t, "[\"web1\",\"web2\"]\n", output)
+
+	output = runQueryCmd(t, map[string]string{"output": "json", "compact": "true"}, "values(servers.[*].host)")
+	assert.Equal(t, "[\"10.0.0.1\",\"10.0.0.2\"]\n", output)
+
+	output = runQueryCmd(t, nil, "length(servers)")
+	assert.Equal(t, "2\n", output)
+}
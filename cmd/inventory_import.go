@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// detectImportFormat guesses the import format from a file extension,
+// defaulting to JSON when the extension is unknown
+func detectImportFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	case ".env":
+		return "dotenv"
+	case ".csv":
+		return "csv"
+	default:
+		// Files named just ".env" have no extension according to filepath.Ext
+		if strings.EqualFold(filepath.Base(path), ".env") {
+			return "dotenv"
+		}
+		return "json"
+	}
+}
+
+// parseImportFile reads an import file and returns the inventory paths it
+// defines along with their values
+func parseImportFile(path, format string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "json":
+		var parsed map[string]interface{}
+		if err := inventory.UnmarshalJSON5(data, &parsed); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		return importEntries(parsed), nil
+	case "yaml":
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		return importEntries(parsed), nil
+	case "dotenv":
+		// Each KEY=VALUE line sets the dot-notation path KEY
+		entries := make(map[string]interface{})
+		for key, value := range loadEnvFile(path) {
+			entries[key] = parseJSONValue(value)
+		}
+		return entries, nil
+	case "csv":
+		// Each "path,value" row sets a single path; a "path,value" header is skipped
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		entries := make(map[string]interface{})
+		for i, record := range records {
+			if len(record) != 2 {
+				return nil, fmt.Errorf("invalid CSV: line %d must have exactly 2 columns (path,value)", i+1)
+			}
+			if i == 0 && record[0] == "path" && record[1] == "value" {
+				continue
+			}
+			entries[strings.TrimSpace(record[0])] = parseJSONValue(record[1])
+		}
+		return entries, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+// importEntries splits imported documents into entry-level paths
+// (e.g. "db.server1") so importing merges into existing types instead of
+// replacing them wholesale
+func importEntries(parsed map[string]interface{}) map[string]interface{} {
+	entries := make(map[string]interface{})
+	for typeName, value := range parsed {
+		children, ok := value.(map[string]interface{})
+		if !ok || len(children) == 0 {
+			entries[typeName] = value
+			continue
+		}
+		for name, child := range children {
+			entries[typeName+"."+name] = child
+		}
+	}
+	return entries
+}
+
+// parseJSONValue parses a string value as JSON, falling back to the raw string
+func parseJSONValue(valueStr string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(valueStr), &value); err != nil {
+		return valueStr
+	}
+	return value
+}
+
+// Conflict policies for --on-conflict; an empty policy prompts for each conflict
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictError     = "error"
+)
+
+var onConflict string
+
+// conflictPrompter asks how to handle an existing path: "overwrite", "skip",
+// "overwrite all" or "skip all"
+var conflictPrompter = func(path string) (string, error) {
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("'%s' already exists in the inventory", path),
+		Items: []string{"overwrite", "skip", "overwrite all", "skip all"},
+	}
+	_, choice, err := prompt.Run()
+	return choice, err
+}
+
+// resolveImportConflicts removes or keeps entries whose paths already exist
+// with a different value, according to policy. Entries identical to the
+// stored value are dropped silently.
+func resolveImportConflicts(cmd *cobra.Command, hi *inventory.HierarchicalInventory, entries map[string]interface{}, policy string) (map[string]interface{}, error) {
+	switch policy {
+	case "", conflictSkip, conflictOverwrite, conflictError:
+	default:
+		return nil, fmt.Errorf("invalid --on-conflict value '%s' (use skip, overwrite or error)", policy)
+	}
+
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	resolved := make(map[string]interface{}, len(entries))
+	var conflicts []string
+	for _, path := range paths {
+		value := entries[path]
+		existing, err := hi.Query(path)
+		if err != nil {
+			resolved[path] = value
+			continue
+		}
+		if sameJSONValue(existing, value) {
+			continue
+		}
+
+		action := policy
+		if action == "" {
+			choice, err := conflictPrompter(path)
+			if err != nil {
+				return nil, fmt.Errorf("prompt failed: %v", err)
+			}
+			switch choice {
+			case "overwrite all":
+				policy, action = conflictOverwrite, conflictOverwrite
+			case "skip all":
+				policy, action = conflictSkip, conflictSkip
+			default:
+				action = choice
+			}
+		}
+
+		switch action {
+		case conflictOverwrite:
+			fmt.Fprintln(cmd.OutOrStdout(), "Overwriting existing path", path)
+			resolved[path] = value
+		case conflictSkip:
+			fmt.Fprintln(cmd.OutOrStdout(), "Skipping existing path", path)
+		case conflictError:
+			conflicts = append(conflicts, path)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%d path(s) already exist: %s", len(conflicts), strings.Join(conflicts, ", "))
+	}
+	return resolved, nil
+}
+
+// sameJSONValue reports whether two values have the same JSON encoding
+func sameJSONValue(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
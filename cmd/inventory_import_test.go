@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := map[string]string{
+		"inventory.json":    "json",
+		"inventory.YAML":    "yaml",
+		"inventory.yml":     "yaml",
+		"inventory.toml":    "toml",
+		"prod.env":          "dotenv",
+		".env":              "dotenv",
+		"hosts.csv":         "csv",
+		"inventory":         "json",
+		"inventory.unknown": "json",
+	}
+
+	for path, expected := range tests {
+		assert.Equal(t, expected, detectImportFormat(path), "format for %s", path)
+	}
+}
+
+func TestInventoryImportFromFile(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { importFormat = "" }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.existing.host", "existing.example.com"))
+
+	yamlPath := filepath.Join(tmpDir, "import.yaml")
+	yamlContent := `db:
+  imported:
+    host: imported.example.com
+    type: postgres
+    remote_port: 5432
+node:
+  web1:
+    host: 10.0.0.1
+`
+	assert.NoError(t, os.WriteFile(yamlPath, []byte(yamlContent), 0644))
+
+	// The inventory subcommands may have been re-parented by simpleCommandTest,
+	// so drive the import directly through a command we own
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	importFromFile(cmd, hi, yamlPath)
+	output := buf.String()
+	assert.Contains(t, output, "Detected format: yaml")
+	assert.Contains(t, output, "Imported 2 entries")
+
+	result, err := hi.Query("db.imported.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "imported.example.com", result)
+
+	result, err = hi.Query("node.web1.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", result)
+
+	// Importing merges into existing types instead of replacing them
+	result, err = hi.Query("db.existing.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "existing.example.com", result)
+
+	// An explicit --format skips detection
+	csvPath := filepath.Join(tmpDir, "paths.txt")
+	assert.NoError(t, os.WriteFile(csvPath, []byte("path,value\nconfig.debug,true\nconfig.name,tsukuyo\n"), 0644))
+
+	buf.Reset()
+	importFormat = "csv"
+	importFromFile(cmd, hi, csvPath)
+	assert.NotContains(t, buf.String(), "Detected format")
+	assert.Contains(t, buf.String(), "Imported 2 entries")
+
+	result, err = hi.Query("config.debug")
+	assert.NoError(t, err)
+	assert.Equal(t, true, result)
+
+	result, err = hi.Query("config.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "tsukuyo", result)
+}
+
+func TestInventoryImportFromS3(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	originalGet := s3GetObject
+	defer func() { s3GetObject = originalGet }()
+
+	var gotBucket, gotKey string
+	s3GetObject = func(ctx context.Context, bucket, key string) ([]byte, error) {
+		gotBucket, gotKey = bucket, key
+		return []byte("node:\n  web1:\n    host: 10.0.0.1\n"), nil
+	}
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	assert.NoError(t, importFromFile(cmd, hi, "s3://my-bucket/tsukuyo/backup.yaml"))
+	assert.Equal(t, "my-bucket", gotBucket)
+	assert.Equal(t, "tsukuyo/backup.yaml", gotKey)
+	assert.Contains(t, buf.String(), "Detected format: yaml")
+	assert.Contains(t, buf.String(), "Imported 1 entries")
+
+	result, err := hi.Query("node.web1.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", result)
+
+	buf.Reset()
+	s3GetObject = func(ctx context.Context, bucket, key string) ([]byte, error) {
+		return nil, errors.New("NoSuchKey")
+	}
+	assert.NoError(t, importFromFile(cmd, hi, "s3://my-bucket/missing.json"))
+	assert.Contains(t, buf.String(), "Failed to download import file: NoSuchKey")
+}
+
+func TestParseImportFileUnsupportedFormat(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tsukuyo-test-import-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "inventory.toml")
+	assert.NoError(t, os.WriteFile(path, []byte("[db]\n"), 0644))
+
+	_, err = parseImportFile(path, detectImportFormat(path))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported import format: toml")
+}
+
+func TestResolveImportConflicts(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.a", "old-a"))
+	assert.NoError(t, hi.Set("servers.b", "old-b"))
+	assert.NoError(t, hi.Set("servers.same", "same"))
+
+	entries := map[string]interface{}{
+		"servers.a":    "new-a",
+		"servers.b":    "new-b",
+		"servers.same": "same",
+		"servers.new":  "new",
+	}
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	resolved, err := resolveImportConflicts(cmd, hi, entries, "skip")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"servers.new": "new"}, resolved)
+	assert.Contains(t, buf.String(), "Skipping existing path servers.a")
+
+	resolved, err = resolveImportConflicts(cmd, hi, entries, "overwrite")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"servers.a": "new-a", "servers.b": "new-b", "servers.new": "new"}, resolved)
+
+	_, err = resolveImportConflicts(cmd, hi, entries, "error")
+	assert.EqualError(t, err, "2 path(s) already exist: servers.a, servers.b")
+
+	_, err = resolveImportConflicts(cmd, hi, entries, "merge")
+	assert.Error(t, err)
+
+	// Without a policy each conflict is prompted; "... all" answers the rest
+	originalPrompter := conflictPrompter
+	defer func() { conflictPrompter = originalPrompter }()
+	var prompted []string
+	conflictPrompter = func(path string) (string, error) {
+		prompted = append(prompted, path)
+		return "skip all", nil
+	}
+	resolved, err = resolveImportConflicts(cmd, hi, entries, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"servers.a"}, prompted)
+	assert.Equal(t, map[string]interface{}{"servers.new": "new"}, resolved)
+}
+
+func TestInventoryMigrateOnConflict(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	originalLegacyDir := legacyDataDir
+	legacyDataDir = filepath.Join(tmpDir, "legacy")
+	defer func() {
+		legacyDataDir = originalLegacyDir
+		onConflict = ""
+	}()
+	assert.NoError(t, os.MkdirAll(legacyDataDir, 0755))
+	legacy := `{"server1":{"host":"legacy1","type":"postgres","remote_port":5432},"server2":{"host":"legacy2","type":"redis","remote_port":6379}}`
+	assert.NoError(t, os.WriteFile(filepath.Join(legacyDataDir, "db-inventory.json"), []byte(legacy), 0644))
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.server1", map[string]interface{}{"host": "current", "type": "postgres", "remote_port": 5432}))
+
+	var buf bytes.Buffer
+	inventoryMigrateCmd.SetOut(&buf)
+	defer inventoryMigrateCmd.SetOut(nil)
+
+	onConflict = "skip"
+	inventoryMigrateCmd.Run(inventoryMigrateCmd, nil)
+	assert.Contains(t, buf.String(), "Skipping existing path db.server1")
+	assert.Contains(t, buf.String(), "Migrated 1 entries from db-inventory.json")
+
+	host, err := hi.Query("db.server1.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "current", host)
+	host, err = hi.Query("db.server2.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy2", host)
+
+	buf.Reset()
+	onConflict = "error"
+	inventoryMigrateCmd.Run(inventoryMigrateCmd, nil)
+	assert.Contains(t, buf.String(), "1 path(s) already exist: db.server1")
+}
+
+func TestInventoryImportDryRun(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { importDryRun = false }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1"}))
+
+	jsonPath := filepath.Join(tmpDir, "import.json")
+	assert.NoError(t, os.WriteFile(jsonPath, []byte(`{"servers": {"web1": {"host": "10.0.0.9"}, "web2": {"host": "10.0.0.2"}}}`), 0644))
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	importDryRun = true
+	err = importFromFile(cmd, hi, jsonPath)
+	assert.EqualError(t, err, "import would change 2 path(s)")
+	assert.Contains(t, buf.String(), `~ servers.web1.host: "10.0.0.1" -> "10.0.0.9"`)
+	assert.Contains(t, buf.String(), `+ servers.web2: {"host":"10.0.0.2"}`)
+	assert.NotContains(t, buf.String(), "_meta")
+
+	// Nothing was written
+	result, err := hi.Query("servers.web1.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", result)
+	_, err = hi.Query("servers.web2")
+	assert.Error(t, err)
+
+	// Applying the import and previewing it again reports no changes
+	importDryRun = false
+	onConflict = conflictOverwrite
+	defer func() { onConflict = "" }()
+	assert.NoError(t, importFromFile(cmd, hi, jsonPath))
+	buf.Reset()
+	importDryRun = true
+	assert.NoError(t, importFromFile(cmd, hi, jsonPath))
+	assert.Contains(t, buf.String(), "Nothing to import")
+}
+
+func TestInventoryMigrateCleanup(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	originalLegacyDir := legacyDataDir
+	originalConfirmer := migrateCleanupConfirmer
+	defer func() {
+		legacyDataDir = originalLegacyDir
+		migrateCleanupConfirmer = originalConfirmer
+		migrateYes = false
+	}()
+	legacyDataDir = filepath.Join(tmpDir, "legacy")
+	writeLegacy := func() {
+		assert.NoError(t, os.MkdirAll(legacyDataDir, 0755))
+		legacy := `{"server1":{"host":"legacy1","type":"postgres","remote_port":5432}}`
+		assert.NoError(t, os.WriteFile(filepath.Join(legacyDataDir, "db-inventory.json"), []byte(legacy), 0644))
+	}
+	writeLegacy()
+
+	var buf bytes.Buffer
+	inventoryMigrateCmd.SetOut(&buf)
+	defer inventoryMigrateCmd.SetOut(nil)
+
+	// Declining the prompt keeps the directory
+	var asked string
+	migrateCleanupConfirmer = func(dir string) (bool, error) {
+		asked = dir
+		return false, nil
+	}
+	inventoryMigrateCmd.Run(inventoryMigrateCmd, nil)
+	assert.Equal(t, legacyDataDir, asked)
+	assert.Contains(t, buf.String(), "Keeping "+legacyDataDir)
+	assert.DirExists(t, legacyDataDir)
+
+	// --yes deletes without asking
+	buf.Reset()
+	asked = ""
+	migrateYes = true
+	inventoryMigrateCmd.Run(inventoryMigrateCmd, nil)
+	assert.Empty(t, asked)
+	assert.Contains(t, buf.String(), "Deleted "+filepath.Join(legacyDataDir, "db-inventory.json"))
+	assert.Contains(t, buf.String(), "Deleted "+legacyDataDir+"\n")
+	assert.NoDirExists(t, legacyDataDir)
+
+	// A failed file keeps the directory even with --yes
+	writeLegacy()
+	assert.NoError(t, os.WriteFile(filepath.Join(legacyDataDir, "node-inventory.json"), []byte("{broken"), 0644))
+	buf.Reset()
+	inventoryMigrateCmd.Run(inventoryMigrateCmd, nil)
+	assert.Contains(t, buf.String(), "1 of 2 file(s) failed to migrate; keeping "+legacyDataDir)
+	assert.DirExists(t, legacyDataDir)
+}
+
+func TestInventoryMigrateVerify(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	originalLegacyDir := legacyDataDir
+	originalConfirmer := migrateCleanupConfirmer
+	defer func() {
+		legacyDataDir = originalLegacyDir
+		migrateCleanupConfirmer = originalConfirmer
+		migrateVerify = false
+		onConflict = ""
+	}()
+	legacyDataDir = filepath.Join(tmpDir, "legacy")
+	assert.NoError(t, os.MkdirAll(legacyDataDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(legacyDataDir, "db-inventory.json"),
+		[]byte(`{"server1":{"host":"legacy1","type":"postgres","remote_port":5432}}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(legacyDataDir, "node-inventory.json"),
+		[]byte(`{"web1":{"host":"10.0.0.1"},"web2":{"host":"10.0.0.2"}}`), 0644))
+
+	// An existing node that is kept with --on-conflict skip no longer matches
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "10.9.9.9"}))
+	assert.NoError(t, hi.Set("node.web3", map[string]interface{}{"host": "10.0.0.3"}))
+
+	migrateCleanupConfirmer = func(dir string) (bool, error) {
+		t.Error("Expected no cleanup prompt after a mismatch")
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	inventoryMigrateCmd.SetOut(&buf)
+	defer inventoryMigrateCmd.SetOut(nil)
+
+	migrateVerify = true
+	onConflict = conflictSkip
+	inventoryMigrateCmd.Run(inventoryMigrateCmd, nil)
+	assert.Contains(t, buf.String(), "Migration verified: db-inventory.json ✓")
+	assert.Contains(t, buf.String(), "Mismatch detected for node-inventory.json: 1 differences\n  modified node.web2.host\n")
+	assert.Contains(t, buf.String(), "1 of 2 file(s) failed to migrate; keeping "+legacyDataDir)
+}
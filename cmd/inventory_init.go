@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// initSkeletonTypes are the empty inventory types written by 'inventory init'.
+// Scripts are managed by 'tsukuyo script' and are not part of the skeleton.
+var initSkeletonTypes = []string{"db", "node"}
+
+var inventoryInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the data directory and a skeleton inventory for first-time setup",
+	Long: `Create ~/.tsukuyo and a hierarchical-inventory.json with empty db and node
+types, then print a quick-start guide. Running it again leaves an existing
+inventory untouched.
+
+Examples:
+  tsukuyo inventory init
+  tsukuyo inventory init --namespace work`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		out := cmd.OutOrStdout()
+		path := hi.StorePath()
+		if _, err := os.Stat(path); err == nil {
+			fmt.Fprintf(out, "Inventory already initialized at %s\n", path)
+			return nil
+		}
+
+		skeleton := make(map[string]interface{}, len(initSkeletonTypes))
+		for _, typeName := range initSkeletonTypes {
+			skeleton[typeName] = map[string]interface{}{}
+		}
+		if err := hi.SetBulk(skeleton); err != nil {
+			return fmt.Errorf("failed to write skeleton inventory: %w", err)
+		}
+
+		fmt.Fprintf(out, "Initialized inventory at %s\n", path)
+		printInitQuickStart(cmd)
+		return nil
+	},
+}
+
+// printInitQuickStart prints the first commands a new user is likely to need
+func printInitQuickStart(cmd *cobra.Command) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "\nQuick start:")
+	fmt.Fprintln(out, "  tsukuyo inventory db set my-db postgres.example.com   # Add a database")
+	fmt.Fprintln(out, "  tsukuyo inventory set node.web1.host 192.168.1.10     # Add a node")
+	fmt.Fprintln(out, "  tsukuyo inventory list --depth 2                      # See what is stored")
+	fmt.Fprintln(out, "  tsukuyo ssh                                           # Connect to a node")
+	fmt.Fprintln(out, "  tsukuyo script add                                    # Save a script")
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryInitCmd)
+}
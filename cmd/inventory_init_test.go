@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryInit(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	inventoryInitCmd.SetOut(&buf)
+	defer inventoryInitCmd.SetOut(nil)
+
+	storePath := filepath.Join(tmpDir, "hierarchical-inventory.json")
+	assert.NoError(t, inventoryInitCmd.RunE(inventoryInitCmd, nil))
+	assert.Contains(t, buf.String(), "Initialized inventory at "+storePath)
+	assert.Contains(t, buf.String(), "Quick start:")
+
+	_, err := os.Stat(storePath)
+	assert.NoError(t, err)
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	for _, typeName := range []string{"db", "node"} {
+		result, err := hi.Query(typeName)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{}, result)
+	}
+
+	// Running it again keeps existing data
+	assert.NoError(t, hi.Set("node.web1.host", "10.0.0.1"))
+	buf.Reset()
+	assert.NoError(t, inventoryInitCmd.RunE(inventoryInitCmd, nil))
+	assert.Equal(t, "Inventory already initialized at "+storePath+"\n", buf.String())
+	result, err := hi.Query("node.web1.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", result)
+}
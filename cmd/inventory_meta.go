@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var inventoryMetaCmd = &cobra.Command{
+	Use:   "meta <path>",
+	Short: "Show when and by whom an inventory entry was created and last updated",
+	Long: `Show the metadata recorded under _meta.<type>.<name> for an inventory entry.
+created_at and created_by are set on the first write to an entry, updated_at on
+every write to the entry or any field inside it.
+
+Examples:
+  tsukuyo inventory meta db.server1
+  tsukuyo inventory meta db`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		path := expandAlias(hi, args[0])
+		result, err := hi.Query(inventory.MetaKey + "." + path)
+		if err != nil {
+			return fmt.Errorf("no metadata recorded for '%s'", path)
+		}
+
+		out := cmd.OutOrStdout()
+		if meta, ok := result.(map[string]interface{}); ok {
+			if _, isEntry := meta["updated_at"].(string); isEntry {
+				for _, field := range []string{"created_at", "created_by", "updated_at"} {
+					fmt.Fprintf(out, "%s: %v\n", field, meta[field])
+				}
+				return nil
+			}
+		}
+
+		// A whole type: show the metadata of each of its entries
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(output))
+		return nil
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryMetaCmd)
+}
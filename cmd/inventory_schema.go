@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var inventorySchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Manage JSON Schemas that enforce the structure of inventory types",
+	Long: `Manage JSON Schema documents stored under _schemas.<type>. Once a schema is set,
+every "<type>.<name>" entry written with set is validated against it.
+
+Examples:
+  tsukuyo inventory schema set node node-schema.json
+  tsukuyo inventory schema show node
+  tsukuyo inventory schema validate node`,
+}
+
+var inventorySchemaSetCmd = &cobra.Command{
+	Use:   "set <type> <json-schema-file>",
+	Short: "Store a JSON Schema for an inventory type",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typeName, path := args[0], args[1]
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse schema file: %w", err)
+		}
+
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+		if err := hi.Set(inventory.SchemasKey+"."+typeName, doc); err != nil {
+			return fmt.Errorf("failed to set schema: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Schema set for type '%s'\n", typeName)
+		fmt.Fprintf(cmd.OutOrStdout(), "Run 'tsukuyo inventory schema validate %s' to check existing entries.\n", typeName)
+		return nil
+	},
+}
+
+var inventorySchemaValidateCmd = &cobra.Command{
+	Use:   "validate <type>",
+	Short: "Validate all existing entries of a type against its schema",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typeName := args[0]
+
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		failures, err := hi.ValidateType(typeName)
+		if err != nil {
+			return err
+		}
+		for _, failure := range failures {
+			fmt.Fprintf(cmd.OutOrStdout(), "✗ %s: %v\n", failure.Path, failure.Err)
+		}
+		if len(failures) > 0 {
+			return fmt.Errorf("%d %s entries do not match the schema", len(failures), typeName)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "All %s entries match the schema\n", typeName)
+		return nil
+	},
+}
+
+var inventorySchemaShowCmd = &cobra.Command{
+	Use:   "show <type>",
+	Short: "Print the stored schema of a type",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typeName := args[0]
+
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+
+		doc, err := hi.Query(inventory.SchemasKey + "." + typeName)
+		if err != nil {
+			return fmt.Errorf("no schema defined for type '%s'", typeName)
+		}
+		out, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return nil
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventorySchemaCmd)
+	inventorySchemaCmd.AddCommand(inventorySchemaSetCmd)
+	inventorySchemaCmd.AddCommand(inventorySchemaValidateCmd)
+	inventorySchemaCmd.AddCommand(inventorySchemaShowCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventorySchemaCommands(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1.host", "web1.example.com"))
+	assert.NoError(t, hi.Set("node.web2.port", 22))
+
+	schemaFile := filepath.Join(tmpDir, "node-schema.json")
+	assert.NoError(t, os.WriteFile(schemaFile, []byte(`{"type":"object","required":["host"]}`), 0644))
+
+	var buf bytes.Buffer
+	for _, c := range []*cobra.Command{inventorySchemaSetCmd, inventorySchemaValidateCmd, inventorySchemaShowCmd} {
+		c.SetOut(&buf)
+		defer c.SetOut(nil)
+	}
+
+	assert.NoError(t, inventorySchemaSetCmd.RunE(inventorySchemaSetCmd, []string{"node", schemaFile}))
+	assert.Contains(t, buf.String(), "Schema set for type 'node'")
+
+	buf.Reset()
+	assert.NoError(t, inventorySchemaShowCmd.RunE(inventorySchemaShowCmd, []string{"node"}))
+	assert.Contains(t, buf.String(), `"required"`)
+
+	buf.Reset()
+	err = inventorySchemaValidateCmd.RunE(inventorySchemaValidateCmd, []string{"node"})
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "node.web2")
+	assert.NotContains(t, buf.String(), "node.web1")
+
+	// New entries are validated on set
+	assert.Error(t, hi.Set("node.web3", map[string]interface{}{"port": 22}))
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "web2.example.com"}))
+
+	buf.Reset()
+	assert.NoError(t, inventorySchemaValidateCmd.RunE(inventorySchemaValidateCmd, []string{"node"}))
+	assert.Contains(t, buf.String(), "All node entries match the schema")
+
+	assert.Error(t, inventorySchemaShowCmd.RunE(inventorySchemaShowCmd, []string{"db"}))
+}
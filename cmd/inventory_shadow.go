@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// shadowedSubcommand returns the name of the subcommand of parent (the
+// inventory command) that a top-level key collides with, or "" if there is
+// none. Such a key can't be reached as 'tsukuyo inventory <type>' because
+// cobra routes to the subcommand.
+func shadowedSubcommand(parent *cobra.Command, key string) string {
+	if parent == nil {
+		return ""
+	}
+	for _, sub := range parent.Commands() {
+		if sub.Name() == key || sub.HasAlias(key) {
+			return sub.Name()
+		}
+	}
+	return ""
+}
+
+// topLevelKey returns the first key of a query path, e.g. "query" for
+// "query.something" or "db" for "db[0]"
+func topLevelKey(query string) string {
+	query = strings.TrimPrefix(query, ".")
+	if i := strings.IndexAny(query, ".["); i >= 0 {
+		query = query[:i]
+	}
+	return strings.Trim(query, `"`)
+}
+
+// warnShadowedKey prints a warning to stderr if key shadows a subcommand of
+// parent
+func warnShadowedKey(cmd, parent *cobra.Command, key string) {
+	if sub := shadowedSubcommand(parent, key); sub != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: key '%s' shadows the built-in '%s' subcommand\n", key, sub)
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+)
+
+// queryFromStdin is the --from-stdin flag value
+var queryFromStdin bool
+
+// stdinRootKey holds the value read from stdin, since an inventory root must
+// be an object while piped values may be arrays or scalars
+const stdinRootKey = "stdin"
+
+// stdinInventory reads a JSON value from r into an in-memory inventory
+func stdinInventory(r io.Reader) (*inventory.HierarchicalInventory, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, fmt.Errorf("no JSON on stdin")
+	}
+	var value interface{}
+	if err := inventory.UnmarshalJSON5(data, &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON on stdin: %w", err)
+	}
+	return inventory.NewMemoryInventory(map[string]interface{}{stdinRootKey: value}), nil
+}
+
+// stdinQueryPath maps a query on the piped value to its path in the
+// stdinInventory. A leading "." refers to the piped value itself, so ".",
+// ".[*].host" and "server1.host" are all accepted.
+func stdinQueryPath(query string) string {
+	query = strings.TrimPrefix(query, ".")
+	if query == "" {
+		return stdinRootKey
+	}
+	return stdinRootKey + "." + query
+}
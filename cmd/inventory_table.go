@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// queryColumns selects the fields shown by query --output table; "*" or no
+// columns shows every field found in any entry
+var queryColumns []string
+
+// writeQueryTable renders a list or map of objects as an aligned table with
+// one row per entry. Map entries get a leading NAME column holding their key.
+// Missing fields are left blank; arrays of primitives are joined with commas
+// and other nested values are shown as JSON.
+func writeQueryTable(out io.Writer, result interface{}, columns []string) error {
+	var names []string
+	var rows []map[string]interface{}
+	switch v := result.(type) {
+	case map[string]interface{}:
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			row, ok := v[name].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("table output needs objects, but '%s' is not one", name)
+			}
+			rows = append(rows, row)
+		}
+	case []interface{}:
+		for i, item := range v {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("table output needs objects, but [%d] is not one", i)
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return fmt.Errorf("table output needs a list or map of objects")
+	}
+
+	if len(columns) == 0 || (len(columns) == 1 && columns[0] == "*") {
+		columns = tableColumns(rows)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := columns
+	if names != nil {
+		header = append([]string{"NAME"}, columns...)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for i, row := range rows {
+		cells := make([]string, 0, len(header))
+		if names != nil {
+			cells = append(cells, names[i])
+		}
+		for _, column := range columns {
+			if value, ok := row[column]; ok {
+				cells = append(cells, envValue(value))
+			} else {
+				cells = append(cells, "")
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+// tableColumns returns the union of the rows' field names, sorted
+func tableColumns(rows []map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range rows {
+		for field := range row {
+			if !seen[field] {
+				seen[field] = true
+				columns = append(columns, field)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
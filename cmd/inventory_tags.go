@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+)
+
+// Tag filters of '<type> list': --tag entries must have every tag, --tag-or
+// entries at least one, and --exclude-tag entries none of them
+var (
+	listAllTags     []string
+	listAnyTags     []string
+	listExcludeTags []string
+)
+
+// filterKeysByTags returns the entry names of typeName that have every tag in
+// all and, if any is not empty, at least one tag in any
+func filterKeysByTags(hi *inventory.HierarchicalInventory, typeName string, keys, all, any []string) []string {
+	var filtered []string
+	for _, key := range keys {
+		result, err := hi.Query(typeName + "." + key)
+		if err != nil {
+			continue
+		}
+		entry, _ := result.(map[string]interface{})
+		tags := getNodeTags(entry)
+		if hasAllTags(tags, all) && (len(any) == 0 || hasAnyTag(tags, any)) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
+
+func hasAllTags(tags, wanted []string) bool {
+	for _, w := range wanted {
+		if !hasAnyTag(tags, []string{w}) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterKeysExcludingTags returns the entry names of typeName that have none
+// of the excluded tags. Entries without tags are always kept.
+func filterKeysExcludingTags(hi *inventory.HierarchicalInventory, typeName string, keys, excluded []string) []string {
+	var filtered []string
+	for _, key := range keys {
+		result, err := hi.Query(typeName + "." + key)
+		if err != nil {
+			continue
+		}
+		entry, _ := result.(map[string]interface{})
+		if !hasAnyTag(getNodeTags(entry), excluded) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeListExcludeTag(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { listExcludeTags = nil }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.prod-pg", map[string]interface{}{"type": "postgres", "host": "10.0.0.1", "remote_port": 5432, "tags": []interface{}{"prod"}}))
+	assert.NoError(t, hi.Set("db.dev-pg", map[string]interface{}{"type": "postgres", "host": "10.0.0.2", "remote_port": 5432, "tags": []interface{}{"dev", "scratch"}}))
+	assert.NoError(t, hi.Set("db.legacy", map[string]interface{}{"type": "mysql", "host": "10.0.0.3", "remote_port": 3306}))
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	listExcludeTags = []string{"dev"}
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Contains(t, buf.String(), "- prod-pg")
+	assert.Contains(t, buf.String(), "- legacy")
+	assert.NotContains(t, buf.String(), "dev-pg")
+
+	buf.Reset()
+	listExcludeTags = []string{"scratch", "prod"}
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Contains(t, buf.String(), "- legacy")
+	assert.NotContains(t, buf.String(), "prod-pg")
+	assert.NotContains(t, buf.String(), "dev-pg")
+
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.1.1", "tags": []interface{}{"dev"}}))
+	buf.Reset()
+	listExcludeTags = []string{"dev"}
+	assert.NoError(t, handleTypeList(cmd, hi, "node"))
+	assert.Equal(t, "No node entries found without tags dev.\n", buf.String())
+}
+
+func TestTypeListTagFilters(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		listAllTags = nil
+		listAnyTags = nil
+		listExcludeTags = nil
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.prod-pg", map[string]interface{}{"type": "postgres", "host": "10.0.0.1", "remote_port": 5432, "tags": []interface{}{"prod", "primary"}}))
+	assert.NoError(t, hi.Set("db.prod-replica", map[string]interface{}{"type": "postgres", "host": "10.0.0.2", "remote_port": 5432, "tags": []interface{}{"prod", "replica"}}))
+	assert.NoError(t, hi.Set("db.dev-pg", map[string]interface{}{"type": "postgres", "host": "10.0.0.3", "remote_port": 5432, "tags": []interface{}{"dev", "primary"}}))
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	// --tag a --tag b needs both tags
+	listAllTags = []string{"prod", "primary"}
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Contains(t, buf.String(), "- prod-pg")
+	assert.NotContains(t, buf.String(), "prod-replica")
+	assert.NotContains(t, buf.String(), "dev-pg")
+
+	// --tag-or needs any of them
+	buf.Reset()
+	listAllTags = nil
+	listAnyTags = []string{"replica", "dev"}
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Contains(t, buf.String(), "- prod-replica")
+	assert.Contains(t, buf.String(), "- dev-pg")
+	assert.NotContains(t, buf.String(), "prod-pg\n")
+
+	// Combined with --exclude-tag
+	buf.Reset()
+	listAnyTags = nil
+	listAllTags = []string{"primary"}
+	listExcludeTags = []string{"dev"}
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Contains(t, buf.String(), "- prod-pg")
+	assert.NotContains(t, buf.String(), "dev-pg")
+
+	buf.Reset()
+	listAllTags = []string{"staging"}
+	assert.NoError(t, handleTypeList(cmd, hi, "db"))
+	assert.Equal(t, "No db entries found with the requested tags.\n", buf.String())
+}
+
+func TestTagFlagsAreLocalToTypeList(t *testing.T) {
+	for _, name := range []string{"tag", "tag-or", "exclude-tag", "not-tag"} {
+		assert.NotNil(t, inventoryCmd.Flags().Lookup(name))
+		assert.Nil(t, inventorySetCmd.InheritedFlags().Lookup(name), "inventory set should not accept --%s", name)
+		assert.Nil(t, inventoryExportCmd.InheritedFlags().Lookup(name), "inventory export should not accept --%s", name)
+	}
+	// export keeps its own --tag and --tag-or
+	assert.Same(t, inventoryExportCmd.Flags().Lookup("tag"), inventoryExportCmd.LocalFlags().Lookup("tag"))
+	assert.Same(t, inventoryExportCmd.Flags().Lookup("tag-or"), inventoryExportCmd.LocalFlags().Lookup("tag-or"))
+}
@@ -376,3 +376,93 @@ func TestInventoryCommand_Performance(t *testing.T) {
 		})
 	}
 }
+
+func TestInventoryCommand_ScriptIsNotAType(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	inventoryCmd.SetOut(&buf)
+	defer inventoryCmd.SetOut(nil)
+
+	if err := inventoryCmd.RunE(inventoryCmd, []string{"script", "list"}); err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "tsukuyo script list") {
+		t.Errorf("Expected pointer to 'tsukuyo script list', got:\n%s", buf.String())
+	}
+
+	// The script key must not be created in the inventory
+	hi, _ := getHierarchicalInventory()
+	if _, err := hi.Query("script"); err == nil {
+		t.Error("Expected no 'script' key in the inventory")
+	}
+}
+
+func TestInventoryCommand_ShadowedSubcommandWarning(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	var out, stderr bytes.Buffer
+	inventorySetCmd.SetOut(&out)
+	inventorySetCmd.SetErr(&stderr)
+	defer inventorySetCmd.SetOut(nil)
+	defer inventorySetCmd.SetErr(nil)
+
+	inventorySetCmd.Run(inventorySetCmd, []string{"query.something", "value"})
+	if !strings.Contains(stderr.String(), "Warning: key 'query' shadows the built-in 'query' subcommand") {
+		t.Errorf("Expected shadow warning from set, got:\n%s", stderr.String())
+	}
+	if !strings.Contains(out.String(), "Set query.something = value") {
+		t.Errorf("Expected the value to be set anyway, got:\n%s", out.String())
+	}
+
+	stderr.Reset()
+	inventorySetCmd.Run(inventorySetCmd, []string{"servers.web1.host", "10.0.0.1"})
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no warning for a regular key, got:\n%s", stderr.String())
+	}
+
+	// Commands that don't touch the shadowed key stay quiet
+	inventoryCmd.SetOut(&out)
+	inventoryCmd.SetErr(&stderr)
+	defer inventoryCmd.SetOut(nil)
+	defer inventoryCmd.SetErr(nil)
+	if err := inventoryCmd.RunE(inventoryCmd, []string{"servers", "list"}); err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no warning from 'inventory servers list', got:\n%s", stderr.String())
+	}
+
+	// Listing the root shows the key, so it warns
+	inventoryListCmd.SetOut(&out)
+	inventoryListCmd.SetErr(&stderr)
+	defer inventoryListCmd.SetOut(nil)
+	defer inventoryListCmd.SetErr(nil)
+	inventoryListCmd.Run(inventoryListCmd, nil)
+	if !strings.Contains(stderr.String(), "Warning: key 'query' shadows the built-in 'query' subcommand") {
+		t.Errorf("Expected shadow warning from list, got:\n%s", stderr.String())
+	}
+
+	stderr.Reset()
+	inventoryListCmd.Run(inventoryListCmd, []string{"servers"})
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no warning listing a regular key, got:\n%s", stderr.String())
+	}
+}
+
+func TestTopLevelKey(t *testing.T) {
+	tests := map[string]string{
+		"query.something": "query",
+		".db.prod.host":   "db",
+		"list[0]":         "list",
+		`"set".a`:         "set",
+		"node":            "node",
+	}
+	for query, expected := range tests {
+		if got := topLevelKey(query); got != expected {
+			t.Errorf("topLevelKey(%q) = %q, want %q", query, got, expected)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setWatchFile string
+	setWatchDir  string
+)
+
+// watchDebounce groups the bursts of events editors emit for a single save
+const watchDebounce = 200 * time.Millisecond
+
+// watchPaths calls onChange after any of paths is written, created, renamed
+// or removed, until ctx is done. Files are watched through their directory
+// so editors that save by replacing the file keep triggering changes.
+func watchPaths(ctx context.Context, paths []string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	dirs := map[string]bool{}      // directories added to the watcher
+	wholeDirs := map[string]bool{} // directories whose every file is watched
+	files := map[string]bool{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			dirs[abs] = true
+			wholeDirs[abs] = true
+			continue
+		}
+		files[abs] = true
+		dirs[filepath.Dir(abs)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	// relevant reports whether an event concerns a watched file or a file in
+	// a directory watched as a whole
+	relevant := func(name string) bool {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return false
+		}
+		return files[abs] || wholeDirs[filepath.Dir(abs)]
+	}
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 && relevant(event.Name) {
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
+
+// isWatchImportFile reports whether a file in a --watch-dir directory is imported
+func isWatchImportFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".json5", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// importWatchDir imports every JSON and YAML file in dir, overwriting
+// existing paths, and returns the number of entries set
+func importWatchDir(hi *inventory.HierarchicalInventory, dir string) (int, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for _, entry := range dirEntries {
+		if !entry.IsDir() && isWatchImportFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	entries := make(map[string]interface{})
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		parsed, err := parseImportFile(path, detectImportFormat(path))
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", name, err)
+		}
+		for key, value := range parsed {
+			entries[key] = value
+		}
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	return len(entries), hi.SetBulk(entries)
+}
+
+// importWatchDirVerbose imports the --watch-dir directory and reports the
+// result, returning false if the import failed
+func importWatchDirVerbose(cmd *cobra.Command, hi *inventory.HierarchicalInventory) bool {
+	count, err := importWatchDir(hi, setWatchDir)
+	if err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Failed to import", setWatchDir, ":", err)
+		return false
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Imported %d entries from %s\n", count, setWatchDir)
+	return true
+}
+
+// runSetWatch re-runs apply whenever the --watch file or a file in the
+// --watch-dir directory changes, until interrupted with Ctrl-C
+func runSetWatch(cmd *cobra.Command, apply func()) {
+	var paths []string
+	if setWatchFile != "" {
+		paths = append(paths, setWatchFile)
+	}
+	if setWatchDir != "" {
+		paths = append(paths, setWatchDir)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Watching %s for changes (Ctrl-C to stop)\n", strings.Join(paths, ", "))
+	if err := watchPaths(ctx, paths, apply); err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Watch failed:", err)
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchPaths(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tsukuyo-test-watch-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	watched := filepath.Join(tmpDir, "inv.json")
+	other := filepath.Join(tmpDir, "other.json")
+	assert.NoError(t, os.WriteFile(watched, []byte("{}"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchPaths(ctx, []string{watched}, func() { changes <- struct{}{} })
+	}()
+	// Give the watcher time to start before touching files
+	time.Sleep(100 * time.Millisecond)
+
+	// Files next to the watched one are ignored
+	assert.NoError(t, os.WriteFile(other, []byte("{}"), 0644))
+	select {
+	case <-changes:
+		t.Fatal("unexpected change for an unwatched file")
+	case <-time.After(2 * watchDebounce):
+	}
+
+	// A burst of writes is reported once
+	assert.NoError(t, os.WriteFile(watched, []byte(`{"a":1}`), 0644))
+	assert.NoError(t, os.WriteFile(watched, []byte(`{"a":2}`), 0644))
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a change for the watched file")
+	}
+	select {
+	case <-changes:
+		t.Fatal("expected writes to be debounced into one change")
+	case <-time.After(2 * watchDebounce):
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestImportWatchDir(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	dir := filepath.Join(tmpDir, "inventory.d")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"servers": {"web1": {"host": "10.0.0.1"}}}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("servers:\n  web2:\n    host: 10.0.0.2\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644))
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+
+	count, err := importWatchDir(hi, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	host, err := hi.Query("servers.web2.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", host)
+
+	// Re-importing picks up edits and overwrites existing paths
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"servers": {"web1": {"host": "10.0.0.9"}}}`), 0644))
+	_, err = importWatchDir(hi, dir)
+	assert.NoError(t, err)
+	host, err = hi.Query("servers.web1.host")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.9", host)
+
+	// A broken file is reported by name
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte("{broken"), 0644))
+	_, err = importWatchDir(hi, dir)
+	assert.ErrorContains(t, err, "c.json")
+}
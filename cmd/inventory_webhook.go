@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webhookInsecure bool
+	webhookEvents   []string
+	webhookPaths    []string
+)
+
+var inventoryWebhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Notify a URL whenever the inventory changes",
+	Long: `Manage the webhook stored under _webhooks. After every saved set or delete
+it receives a POST with a JSON body {event, path, old_value, new_value, ts}.
+A failing webhook prints a warning but never undoes the change. Like hooks,
+webhooks are skipped with --no-hooks.
+
+Examples:
+  tsukuyo inventory webhook set https://ci.example.com/hooks/inventory
+  tsukuyo inventory webhook set https://ci.example.com/hooks/inventory --events set --path 'db.*'
+  tsukuyo inventory webhook test`,
+}
+
+var inventoryWebhookSetCmd = &cobra.Command{
+	Use:   "set <url>",
+	Short: "Set the webhook URL and the events it receives",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := url.Parse(args[0])
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return fmt.Errorf("invalid webhook URL '%s': expected an http:// or https:// URL", args[0])
+		}
+		events := make([]interface{}, 0, len(webhookEvents))
+		for _, event := range webhookEvents {
+			if event != inventory.WebhookEventSet && event != inventory.WebhookEventDelete {
+				return fmt.Errorf("invalid webhook event '%s': use set or delete", event)
+			}
+			events = append(events, event)
+		}
+
+		config := map[string]interface{}{
+			"url":    args[0],
+			"events": events,
+		}
+		if len(webhookPaths) > 0 {
+			paths := make([]interface{}, 0, len(webhookPaths))
+			for _, path := range webhookPaths {
+				paths = append(paths, path)
+			}
+			config["paths"] = paths
+		}
+		if webhookInsecure {
+			config["insecure"] = true
+		}
+
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+		if err := hi.Set(inventory.WebhooksKey, config); err != nil {
+			return fmt.Errorf("failed to set webhook: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Webhook %s will receive %s events\n", args[0], strings.Join(webhookEvents, ", "))
+		return nil
+	},
+}
+
+var inventoryWebhookTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Post a test event to the configured webhook",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hi, err := getHierarchicalInventory()
+		if err != nil {
+			return fmt.Errorf("failed to initialize hierarchical inventory: %w", err)
+		}
+		if err := hi.SendTestWebhook(); err != nil {
+			return fmt.Errorf("webhook test failed: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "Test event delivered.")
+		return nil
+	},
+}
+
+func init() {
+	inventoryWebhookSetCmd.Flags().BoolVar(&webhookInsecure, "insecure", false, "Skip TLS certificate verification when posting")
+	inventoryWebhookSetCmd.Flags().StringSliceVar(&webhookEvents, "events", []string{inventory.WebhookEventSet, inventory.WebhookEventDelete}, "Events to send: set, delete")
+	inventoryWebhookSetCmd.Flags().StringSliceVar(&webhookPaths, "path", nil, "Only notify changes to paths matching these glob patterns (e.g. 'db.*')")
+
+	inventoryCmd.AddCommand(inventoryWebhookCmd)
+	inventoryWebhookCmd.AddCommand(inventoryWebhookSetCmd)
+	inventoryWebhookCmd.AddCommand(inventoryWebhookTestCmd)
+}
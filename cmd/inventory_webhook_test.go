@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryWebhook(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		webhookInsecure = false
+		webhookEvents = []string{inventory.WebhookEventSet, inventory.WebhookEventDelete}
+		webhookPaths = nil
+	}()
+
+	var received []inventory.WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload inventory.WebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	inventoryWebhookSetCmd.SetOut(&buf)
+	defer inventoryWebhookSetCmd.SetOut(nil)
+	inventoryWebhookTestCmd.SetOut(&buf)
+	defer inventoryWebhookTestCmd.SetOut(nil)
+
+	assert.Error(t, inventoryWebhookTestCmd.RunE(inventoryWebhookTestCmd, nil))
+	assert.ErrorContains(t, inventoryWebhookSetCmd.RunE(inventoryWebhookSetCmd, []string{"ftp://example.com"}), "invalid webhook URL")
+
+	webhookEvents = []string{"set"}
+	webhookPaths = []string{"db.*"}
+	assert.NoError(t, inventoryWebhookSetCmd.RunE(inventoryWebhookSetCmd, []string{server.URL}))
+	assert.Equal(t, "Webhook "+server.URL+" will receive set events\n", buf.String())
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	stored, err := hi.Query(inventory.WebhooksKey)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"url":    server.URL,
+		"events": []interface{}{"set"},
+		"paths":  []interface{}{"db.*"},
+	}, stored)
+
+	buf.Reset()
+	assert.NoError(t, inventoryWebhookTestCmd.RunE(inventoryWebhookTestCmd, nil))
+	assert.Equal(t, "Test event delivered.\n", buf.String())
+
+	assert.NoError(t, hi.Set("db.pg.host", "10.0.0.1"))
+	assert.NoError(t, hi.Set("servers.web1.host", "10.0.0.2"))
+	assert.NoError(t, hi.Delete("db.pg"))
+
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "test", received[0].Event)
+		assert.Equal(t, "set", received[1].Event)
+		assert.Equal(t, "db.pg.host", received[1].Path)
+		assert.Equal(t, "10.0.0.1", received[1].NewValue)
+	}
+
+	webhookEvents = []string{"update"}
+	assert.ErrorContains(t, inventoryWebhookSetCmd.RunE(inventoryWebhookSetCmd, []string{server.URL}), "invalid webhook event 'update'")
+}
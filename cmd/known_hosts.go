@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+)
+
+// knownHostsEntry formats a host the way OpenSSH writes it in known_hosts
+func knownHostsEntry(host string, port int) string {
+	if port == 22 {
+		return host
+	}
+	return "[" + host + "]:" + strconv.Itoa(port)
+}
+
+// knownHostsLines builds known_hosts lines from the stored ssh_fingerprint of
+// every node. Nodes without a fingerprint are skipped.
+func knownHostsLines(hi *inventory.HierarchicalInventory) []string {
+	names, err := hi.List("node")
+	if err != nil {
+		return nil
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		result, err := hi.Query("node." + name)
+		if err != nil {
+			continue
+		}
+		nodeData, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fingerprint, _ := nodeData["ssh_fingerprint"].(string)
+		host, port := nodeHostPort(nodeData)
+		if fingerprint == "" || host == "" {
+			continue
+		}
+		for _, key := range fingerprintKeys(fingerprint) {
+			lines = append(lines, knownHostsEntry(host, port)+" "+key)
+		}
+	}
+	return lines
+}
+
+// mergeKnownHosts merges new lines into existing known_hosts content. Existing
+// lines for any host present in the new lines are replaced; hashed entries
+// cannot be matched and are kept as they are.
+func mergeKnownHosts(existing string, lines []string) string {
+	hosts := make(map[string]bool)
+	for _, line := range lines {
+		hosts[strings.Fields(line)[0]] = true
+	}
+
+	var merged []string
+	for _, line := range strings.Split(existing, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && !strings.HasPrefix(fields[0], "#") && knownHostsLineMatches(fields[0], hosts) {
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			merged = append(merged, line)
+		}
+	}
+	merged = append(merged, lines...)
+	return strings.Join(merged, "\n") + "\n"
+}
+
+func knownHostsLineMatches(hostField string, hosts map[string]bool) bool {
+	for _, host := range strings.Split(hostField, ",") {
+		if hosts[host] {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultKnownHostsPath returns ~/.ssh/known_hosts
+func defaultKnownHostsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+// appendKnownHosts merges lines into the known_hosts file at path
+func appendKnownHosts(path string, lines []string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(mergeKnownHosts(string(existing), lines)), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
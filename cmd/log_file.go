@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// logFilePath is the --log-file flag value
+var logFilePath string
+
+// logNow returns the time stamped on log lines
+var logNow = time.Now
+
+// openLogFile is the log file of the running command, closed by closeLogFile
+var openLogFile *os.File
+
+// terminalOut and terminalErr are the command's streams from before the
+// --log-file tee, for interactive children that need the terminal itself
+var terminalOut, terminalErr io.Writer
+
+// timestampWriter prefixes every line written through it with an RFC3339
+// timestamp and the command name
+type timestampWriter struct {
+	mu          sync.Mutex
+	w           io.Writer
+	command     string
+	atLineStart bool
+}
+
+func newTimestampWriter(w io.Writer, command string) *timestampWriter {
+	return &timestampWriter{w: w, command: command, atLineStart: true}
+}
+
+func (tw *timestampWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if tw.atLineStart {
+			fmt.Fprintf(&buf, "%s [%s] ", logNow().Format(time.RFC3339), tw.command)
+		}
+		buf.Write(line)
+		tw.atLineStart = line[len(line)-1] == '\n'
+	}
+	if _, err := tw.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// setupLogFile tees the command's output and error streams into --log-file.
+// The file is opened in append mode so earlier sessions are never lost.
+func setupLogFile(cmd *cobra.Command) error {
+	if logFilePath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	openLogFile = f
+
+	command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	logWriter := newTimestampWriter(f, command)
+	terminalOut, terminalErr = cmd.OutOrStdout(), cmd.ErrOrStderr()
+	cmd.SetOut(io.MultiWriter(cmd.OutOrStdout(), logWriter))
+	cmd.SetErr(io.MultiWriter(cmd.ErrOrStderr(), logWriter))
+	return nil
+}
+
+// closeLogFile closes the log file and restores the command's own streams
+func closeLogFile(cmd *cobra.Command, args []string) error {
+	if openLogFile == nil {
+		return nil
+	}
+	cmd.SetOut(nil)
+	cmd.SetErr(nil)
+	terminalOut, terminalErr = nil, nil
+	err := openLogFile.Close()
+	openLogFile = nil
+	return err
+}
+
+// attachTerminal connects an interactive child such as ssh to the command's
+// streams, bypassing the --log-file tee: a pipe in between would hide the
+// terminal from the child, so its session is not logged
+func attachTerminal(c *exec.Cmd, cmd *cobra.Command) {
+	c.Stdin = cmd.InOrStdin()
+	c.Stdout = cmd.OutOrStdout()
+	c.Stderr = cmd.ErrOrStderr()
+	if terminalOut != nil {
+		c.Stdout = terminalOut
+		c.Stderr = terminalErr
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampWriter(t *testing.T) {
+	originalNow := logNow
+	defer func() { logNow = originalNow }()
+	logNow = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	var buf bytes.Buffer
+	w := newTimestampWriter(&buf, "inventory query")
+	_, err := w.Write([]byte("first line\nsecond "))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("line\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-02T03:04:05Z [inventory query] first line\n2025-01-02T03:04:05Z [inventory query] second line\n", buf.String())
+}
+
+func TestLogFileFlag(t *testing.T) {
+	scriptsDir, cleanup := setupTestScripts(t, []tempScript{
+		{Meta: ScriptMeta{Name: "logged", Description: "Logged script"}, Content: "echo 1"},
+	})
+	defer cleanup()
+	defer func() {
+		logFilePath = ""
+		// Later tests may re-execute rootCmd with the last args; the log dir is gone by then
+		rootCmd.SetArgs([]string{})
+	}()
+
+	logPath := filepath.Join(scriptsDir, "tsukuyo.log")
+	assert.NoError(t, os.WriteFile(logPath, []byte("earlier session\n"), 0644))
+
+	output, err := executeCommand(rootCmd, "script", "list", "--log-file", logPath)
+	assert.NoError(t, err)
+	assert.Contains(t, output, "logged")
+
+	// The log is appended to, never truncated, and every line is prefixed
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	log := string(data)
+	assert.Contains(t, log, "earlier session\n")
+	assert.Regexp(t, `\n\d{4}-\d{2}-\d{2}T\S+ \[script list\] NAME\s+DESCRIPTION`, log)
+	assert.Regexp(t, `\[script list\] logged\s+Logged script`, log)
+	assert.Nil(t, openLogFile)
+}
+
+func TestLogFileLeavesInteractiveChildrenOnTheTerminal(t *testing.T) {
+	defer func() { logFilePath = "" }()
+	logFilePath = filepath.Join(t.TempDir(), "tsukuyo.log")
+
+	cmd := &cobra.Command{Use: "ssh"}
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	assert.NoError(t, setupLogFile(cmd))
+	defer closeLogFile(cmd, nil)
+
+	// tsukuyo's own output is teed, an ssh child gets the streams themselves
+	assert.NotSame(t, &out, cmd.OutOrStdout())
+	child := exec.Command("ssh")
+	attachTerminal(child, cmd)
+	assert.Same(t, &out, child.Stdout)
+	assert.Same(t, &errOut, child.Stderr)
+}
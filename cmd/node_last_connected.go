@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// nodeStaleAfter is how long since the last connection before node list
+// --extended flags a node as stale
+const nodeStaleAfter = 30 * 24 * time.Hour
+
+// nodeListExtended is the --extended flag of 'inventory node list'
+var nodeListExtended bool
+
+// connectedNow returns the time recorded as last_connected
+var connectedNow = time.Now
+
+// runSSH runs ssh with the given arguments attached to the command's terminal
+var runSSH = func(cmd *cobra.Command, args []string) error {
+	sshExec := exec.Command("ssh", args...)
+	attachTerminal(sshExec, cmd)
+	return sshExec.Run()
+}
+
+// nodePath returns the inventory path of a node, quoting names such as
+// hostnames that contain dots
+func nodePath(name string) string {
+	if strings.Contains(name, ".") {
+		return `node["` + name + `"]`
+	}
+	return "node." + name
+}
+
+// recordNodeConnected stores the current time as the node's last_connected.
+// Connecting is not an edit, so it leaves _meta alone and fires no hooks or
+// webhooks. Failing to record it only warns, and a read-only inventory is
+// left alone.
+func recordNodeConnected(cmd *cobra.Command, hi *inventory.HierarchicalInventory, name string) {
+	stamp := connectedNow().UTC().Format(time.RFC3339)
+	err := hi.SetUntracked(nodePath(name)+".last_connected", stamp)
+	if err != nil && !errors.Is(err, inventory.ErrReadOnly) {
+		fmt.Fprintln(cmd.ErrOrStderr(), "Warning: failed to record last connection:", err)
+	}
+}
+
+// recordTshConnected records a Teleport connection on the node entry named
+// after the hostname, if the inventory has one
+func recordTshConnected(cmd *cobra.Command, hi *inventory.HierarchicalInventory, hostname string) {
+	if result, err := hi.Query(nodePath(hostname)); err == nil {
+		if _, ok := result.(map[string]interface{}); ok {
+			recordNodeConnected(cmd, hi, hostname)
+		}
+	}
+}
+
+// printNodeListExtended prints the nodes as a table including when each was
+// last connected to. Nodes not connected to within nodeStaleAfter are marked
+// stale.
+func printNodeListExtended(cmd *cobra.Command, hi *inventory.HierarchicalInventory, keys []string) error {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tUSER\tPORT\tLAST_CONNECTED")
+	for _, key := range sorted {
+		result, err := hi.Query(nodePath(key))
+		if err != nil {
+			continue
+		}
+		entry, err := inventory.ParseNodeEntry(result)
+		if err != nil {
+			continue
+		}
+		port := "22"
+		if entry.Port != 0 {
+			port = strconv.Itoa(entry.Port)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key, entry.Host, entry.User, port, formatLastConnected(entry.LastConnected))
+	}
+	return w.Flush()
+}
+
+// formatLastConnected renders a last_connected timestamp, "never" when the
+// node has no recorded connection
+func formatLastConnected(stamp string) string {
+	if stamp == "" {
+		return "never"
+	}
+	connected, err := time.Parse(time.RFC3339, stamp)
+	if err != nil {
+		return stamp
+	}
+	if connectedNow().Sub(connected) > nodeStaleAfter {
+		return stamp + " (stale)"
+	}
+	return stamp
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// nodeOSFilter is the --os-filter flag value for node list
+var nodeOSFilter string
+
+// nodeOSTypes are the values accepted by --os-filter and stored in node.<name>.os
+var nodeOSTypes = []string{"linux", "darwin", "windows"}
+
+// sshUname runs "uname -sr" on a node over ssh and returns its output
+var sshUname = func(target string, port int) (string, error) {
+	args := []string{"-o", "BatchMode=yes", "-p", strconv.Itoa(port), target, "uname -sr"}
+	out, err := exec.Command("ssh", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("ssh uname failed: %v", err)
+	}
+	return string(out), nil
+}
+
+// parseUname maps "uname -sr" output such as "Linux 6.1.0-18-amd64" to an
+// OS type and version. MSYS, MinGW and Cygwin shells report Windows.
+func parseUname(output string) (string, string, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", "", fmt.Errorf("empty uname output")
+	}
+
+	kernel := strings.ToLower(fields[0])
+	version := ""
+	if len(fields) > 1 {
+		version = fields[1]
+	}
+	switch {
+	case kernel == "linux" || kernel == "darwin":
+		return kernel, version, nil
+	case strings.HasPrefix(kernel, "mingw"), strings.HasPrefix(kernel, "msys"), strings.HasPrefix(kernel, "cygwin"):
+		return "windows", version, nil
+	default:
+		return "", "", fmt.Errorf("unrecognized OS '%s'", fields[0])
+	}
+}
+
+// filterNodeKeysByOS returns the node names whose os is osType (case-insensitive)
+func filterNodeKeysByOS(hi *inventory.HierarchicalInventory, keys []string, osType string) []string {
+	var filtered []string
+	for _, key := range keys {
+		result, err := hi.Query("node." + key)
+		if err != nil {
+			continue
+		}
+		entry, err := inventory.ParseNodeEntry(result)
+		if err == nil && strings.EqualFold(entry.OS, osType) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
+
+// validateNodeOSFilter rejects --os-filter values other than nodeOSTypes
+func validateNodeOSFilter(osType string) error {
+	for _, known := range nodeOSTypes {
+		if strings.EqualFold(osType, known) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --os-filter value '%s' (use %s)", osType, strings.Join(nodeOSTypes, ", "))
+}
+
+// handleNodeDetectOS runs uname on a node and stores the result at
+// node.<name>.os and node.<name>.os_version
+func handleNodeDetectOS(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: tsukuyo inventory node detect-os <name>")
+	}
+	name := args[0]
+
+	result, err := hi.Query("node." + name)
+	if err != nil {
+		return fmt.Errorf("node '%s' not found", name)
+	}
+	entry, err := inventory.ParseNodeEntry(result)
+	if err != nil {
+		return fmt.Errorf("invalid node data format for '%s'", name)
+	}
+	if entry.Host == "" {
+		return fmt.Errorf("node '%s' has no host", name)
+	}
+
+	port := entry.Port
+	if port == 0 {
+		port = 22
+	}
+	target := entry.Host
+	if entry.User != "" {
+		target = entry.User + "@" + entry.Host
+	}
+
+	output, err := sshUname(target, port)
+	if err != nil {
+		return err
+	}
+	osType, version, err := parseUname(output)
+	if err != nil {
+		return err
+	}
+
+	if err := hi.SetBulk(map[string]interface{}{
+		"node." + name + ".os":         osType,
+		"node." + name + ".os_version": version,
+	}); err != nil {
+		return fmt.Errorf("failed to store OS: %v", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Node '%s' runs %s %s\n", name, osType, version)
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUname(t *testing.T) {
+	osType, version, err := parseUname("Linux 6.1.0-18-amd64\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "linux", osType)
+	assert.Equal(t, "6.1.0-18-amd64", version)
+
+	osType, version, err = parseUname("Darwin 23.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "darwin", osType)
+	assert.Equal(t, "23.1.0", version)
+
+	osType, _, err = parseUname("MINGW64_NT-10.0-19045 3.4.9")
+	assert.NoError(t, err)
+	assert.Equal(t, "windows", osType)
+
+	_, _, err = parseUname("")
+	assert.Error(t, err)
+	_, _, err = parseUname("Plan9 4")
+	assert.Error(t, err)
+}
+
+func TestNodeOSFilterAndDetect(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() { nodeOSFilter = "" }()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1", "user": "deploy", "os": "linux"}))
+	assert.NoError(t, hi.Set("node.mac1", map[string]interface{}{"host": "10.0.0.2", "os": "darwin"}))
+	assert.NoError(t, hi.Set("node.new1", map[string]interface{}{"host": "10.0.0.3", "port": 2222}))
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	nodeOSFilter = "Linux"
+	assert.NoError(t, handleTypeList(cmd, hi, "node"))
+	assert.Contains(t, buf.String(), "- web1")
+	assert.NotContains(t, buf.String(), "mac1")
+	assert.NotContains(t, buf.String(), "new1")
+
+	nodeOSFilter = "solaris"
+	assert.Error(t, handleTypeList(cmd, hi, "node"))
+
+	original := sshUname
+	defer func() { sshUname = original }()
+	var gotTarget string
+	var gotPort int
+	sshUname = func(target string, port int) (string, error) {
+		gotTarget, gotPort = target, port
+		return "Linux 5.15.0-91-generic\n", nil
+	}
+
+	buf.Reset()
+	assert.NoError(t, handleDynamicTypeCommand(cmd, hi, []string{"node", "detect-os", "new1"}))
+	assert.Equal(t, "10.0.0.3", gotTarget)
+	assert.Equal(t, 2222, gotPort)
+	assert.Contains(t, buf.String(), "Node 'new1' runs linux 5.15.0-91-generic")
+
+	version, err := hi.Query("node.new1.os_version")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.15.0-91-generic", version)
+
+	nodeOSFilter = "linux"
+	buf.Reset()
+	assert.NoError(t, handleTypeList(cmd, hi, "node"))
+	assert.Contains(t, buf.String(), "- new1")
+
+	sshUname = func(target string, port int) (string, error) {
+		return "", fmt.Errorf("ssh uname failed: exit status 255")
+	}
+	assert.Error(t, handleNodeDetectOS(cmd, hi, []string{"web1"}))
+	assert.Error(t, handleNodeDetectOS(cmd, hi, []string{"missing"}))
+}
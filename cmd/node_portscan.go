@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// commonServicePorts are scanned on top of the well-known range by default
+var commonServicePorts = []int{3306, 5432, 6379, 27017}
+
+const portScanWorkers = 100
+
+var (
+	portScanPorts   string
+	portScanTimeout time.Duration
+)
+
+// parsePortSpec turns a spec such as "22,80,8000-8100" into a sorted list of
+// unique ports. An empty spec means 1-1024 plus the common service ports.
+func parsePortSpec(spec string) ([]int, error) {
+	seen := map[int]bool{}
+	add := func(p int) error {
+		if p < 1 || p > 65535 {
+			return fmt.Errorf("port %d out of range (1-65535)", p)
+		}
+		seen[p] = true
+		return nil
+	}
+
+	if strings.TrimSpace(spec) == "" {
+		for p := 1; p <= 1024; p++ {
+			seen[p] = true
+		}
+		for _, p := range commonServicePorts {
+			seen[p] = true
+		}
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if lo, hi, isRange := strings.Cut(part, "-"); isRange {
+			start, err1 := strconv.Atoi(strings.TrimSpace(lo))
+			end, err2 := strconv.Atoi(strings.TrimSpace(hi))
+			if err1 != nil || err2 != nil || start > end {
+				return nil, fmt.Errorf("invalid port range '%s'", part)
+			}
+			for p := start; p <= end; p++ {
+				if err := add(p); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		p, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port '%s'", part)
+		}
+		if err := add(p); err != nil {
+			return nil, err
+		}
+	}
+
+	ports := make([]int, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// scanPorts dials every port on host and returns the sorted open ones
+func scanPorts(host string, ports []int, timeout time.Duration) []int {
+	jobs := make(chan int)
+	var (
+		mu   sync.Mutex
+		open []int
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < portScanWorkers && i < len(ports); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range jobs {
+				conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), timeout)
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				mu.Lock()
+				open = append(open, port)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range ports {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+	sort.Ints(open)
+	return open
+}
+
+// handleNodePortScan probes the host of node.<name> and prints its open ports
+func handleNodePortScan(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: tsukuyo inventory node port-scan <name> [--ports 1-1024,3306] [--scan-timeout 300ms]")
+	}
+	name := args[0]
+
+	result, err := hi.Query("node." + name)
+	if err != nil {
+		return fmt.Errorf("node '%s' not found", name)
+	}
+	nodeData, ok := result.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid node data format for '%s'", name)
+	}
+	host, _ := nodeHostPort(nodeData)
+	if host == "" {
+		return fmt.Errorf("node '%s' has no host", name)
+	}
+
+	ports, err := parsePortSpec(portScanPorts)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Scanning %d port(s) on %s (%s)...\n", len(ports), name, host)
+	open := scanPorts(host, ports, portScanTimeout)
+	if len(open) == 0 {
+		fmt.Fprintln(out, "No open ports found.")
+		return nil
+	}
+	for _, p := range open {
+		fmt.Fprintf(out, "  %d/tcp open\n", p)
+	}
+	return nil
+}
+
+func init() {
+	// 'node port-scan' is dispatched by inventoryCmd itself, so these stay local to it
+	inventoryCmd.Flags().StringVar(&portScanPorts, "ports", "", "Ports to probe with node port-scan, e.g. 22,80,8000-8100 (default 1-1024 plus common service ports)")
+	inventoryCmd.Flags().DurationVar(&portScanTimeout, "scan-timeout", 300*time.Millisecond, "Per-port dial timeout for node port-scan")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePortSpec(t *testing.T) {
+	ports, err := parsePortSpec("")
+	assert.NoError(t, err)
+	assert.Len(t, ports, 1024+len(commonServicePorts))
+	assert.Equal(t, 1, ports[0])
+	assert.Equal(t, 27017, ports[len(ports)-1])
+
+	ports, err = parsePortSpec("443, 20-22,22")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{20, 21, 22, 443}, ports)
+
+	for _, bad := range []string{"abc", "10-5", "0", "70000", "1-x"} {
+		_, err := parsePortSpec(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestHandleNodePortScan(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	openPort := listener.Addr().(*net.TCPAddr).Port
+
+	// Reserve a second port and release it so it is very likely closed
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.local", map[string]interface{}{"host": "127.0.0.1"}))
+
+	portScanPorts = strconv.Itoa(openPort) + "," + strconv.Itoa(closedPort)
+	portScanTimeout = time.Second
+	defer func() {
+		portScanPorts = ""
+		portScanTimeout = 300 * time.Millisecond
+	}()
+
+	var buf bytes.Buffer
+	inventoryCmd.SetOut(&buf)
+	defer inventoryCmd.SetOut(nil)
+
+	assert.NoError(t, handleNodePortScan(inventoryCmd, hi, []string{"local"}))
+	assert.Contains(t, buf.String(), strconv.Itoa(openPort)+"/tcp open")
+	assert.NotContains(t, buf.String(), strconv.Itoa(closedPort)+"/tcp open")
+
+	assert.Error(t, handleNodePortScan(inventoryCmd, hi, []string{"missing"}))
+	assert.Error(t, handleNodePortScan(inventoryCmd, hi, nil))
+}
+
+func TestPortScanFlagsAreNotInherited(t *testing.T) {
+	assert.NotNil(t, inventoryCmd.Flags().Lookup("ports"))
+	assert.NotNil(t, inventoryCmd.Flags().Lookup("scan-timeout"))
+	for _, name := range []string{"ports", "scan-timeout"} {
+		assert.Nil(t, inventorySetCmd.InheritedFlags().Lookup(name), "inventory set should not accept --%s", name)
+	}
+}
@@ -21,6 +21,8 @@ The goal is to reduce manual steps in common workflows and improve productivity.
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE:  initConfig,
+	PersistentPostRunE: closeLogFile,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -37,7 +39,12 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.tsukuyo.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.tsukuyo/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&inventoryNamespace, "namespace", "", "Use an isolated inventory stored in hierarchical-inventory-<namespace>.json")
+	rootCmd.PersistentFlags().StringVar(&logFilePath, "log-file", "", "Also append all output to this file, each line prefixed with a timestamp and the command name")
+	rootCmd.PersistentFlags().BoolVar(&inventoryReadOnly, "read-only", false, "Refuse any write to the inventory")
+	rootCmd.PersistentFlags().BoolVar(&inventoryNoHooks, "no-hooks", false, "Do not run the _hooks commands or _webhooks notifications configured for inventory writes")
+	rootCmd.PersistentFlags().DurationVar(&inventoryIOTimeout, "timeout", inventoryIOTimeout, "Fail inventory reads and writes that take longer than this (e.g. on a hung network mount)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
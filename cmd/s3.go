@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Scheme prefixes S3 locations accepted wherever a file path is
+const s3Scheme = "s3://"
+
+// isS3URI reports whether path names an S3 object rather than a local file
+func isS3URI(path string) bool {
+	return strings.HasPrefix(path, s3Scheme)
+}
+
+// parseS3URI splits s3://bucket/key into its bucket and key
+func parseS3URI(uri string) (string, string, error) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(uri, s3Scheme), "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", fmt.Errorf("invalid S3 location '%s': expected s3://bucket/key", uri)
+	}
+	return bucket, key, nil
+}
+
+// s3PutObject uploads data to bucket/key using the standard AWS credential chain
+var s3PutObject = func(ctx context.Context, bucket, key string, data []byte) error {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	_, err = s3.NewFromConfig(cfg).PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// uploadToS3 writes data to an s3://bucket/key location
+func uploadToS3(ctx context.Context, uri string, data []byte) error {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return err
+	}
+	return s3PutObject(ctx, bucket, key, data)
+}
+
+// s3GetObject downloads bucket/key using the standard AWS credential chain
+var s3GetObject = func(ctx context.Context, bucket, key string) ([]byte, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	out, err := s3.NewFromConfig(cfg).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+// downloadFromS3 copies an s3://bucket/key object into a temporary file named
+// after the key, so format detection by extension still works. The returned
+// cleanup removes the file.
+func downloadFromS3(ctx context.Context, uri string) (string, func(), error) {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := s3GetObject(ctx, bucket, key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "tsukuyo-s3-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	localPath := filepath.Join(dir, path.Base(key))
+	if err := os.WriteFile(localPath, data, 0600); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return localPath, cleanup, nil
+}
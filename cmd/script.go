@@ -2,15 +2,19 @@ package cmd
 
 import (
 	"bufio"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -20,9 +24,33 @@ const (
 )
 
 type ScriptMeta struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Tags        []string `json:"tags"`
+	Name            string   `json:"name" yaml:"name"`
+	Description     string   `json:"description" yaml:"description"`
+	Tags            []string `json:"tags" yaml:"tags"`
+	Language        string   `json:"language,omitempty" yaml:"language,omitempty"`
+	ConfirmRequired bool     `json:"confirm_required,omitempty" yaml:"confirm_required,omitempty"` // run always asks, as with --confirm
+}
+
+// scriptInterpreters maps supported script languages to their interpreter
+var scriptInterpreters = map[string]string{
+	"bash":    "/bin/bash",
+	"sh":      "sh",
+	"python3": "python3",
+	"node":    "node",
+	"ruby":    "ruby",
+}
+
+// scriptInterpreter returns the interpreter for a script language.
+// Scripts without a language run with bash.
+func scriptInterpreter(language string) (string, error) {
+	if language == "" {
+		language = "bash"
+	}
+	interpreter, ok := scriptInterpreters[strings.ToLower(language)]
+	if !ok {
+		return "", fmt.Errorf("unsupported script language: %s (supported: bash, sh, python3, node, ruby)", language)
+	}
+	return interpreter, nil
 }
 
 var getTsukuyoDir = func() string {
@@ -76,6 +104,16 @@ var scriptAddCmd = &cobra.Command{
 		for i := range tags {
 			tags[i] = strings.TrimSpace(tags[i])
 		}
+		fmt.Fprint(cmd.OutOrStdout(), "Language (default: bash): ")
+		language, _ := reader.ReadString('\n')
+		language = strings.ToLower(strings.TrimSpace(language))
+		if language == "" {
+			language = "bash"
+		}
+		if _, err := scriptInterpreter(language); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), err)
+			return
+		}
 		fmt.Fprintln(cmd.OutOrStdout(), "Enter script content (end with EOF/Ctrl+D):")
 		var content strings.Builder
 		for {
@@ -89,13 +127,51 @@ var scriptAddCmd = &cobra.Command{
 			fmt.Fprintln(cmd.OutOrStdout(), "Failed to write script:", err)
 			return
 		}
-		meta := ScriptMeta{Name: name, Description: desc, Tags: tags}
+		meta := ScriptMeta{Name: name, Description: desc, Tags: tags, Language: language}
 		metaBytes, _ := json.MarshalIndent(meta, "", "  ")
 		_ = os.WriteFile(scriptMetaPath(name), metaBytes, 0644)
 		fmt.Fprintln(cmd.OutOrStdout(), "Script added:", name)
 	},
 }
 
+var (
+	listTags         []string
+	listDescContains string
+	listOutput       string
+)
+
+// filterScripts keeps the scripts that carry every tag in tags (case-insensitive)
+// and whose description contains descSubstr
+func filterScripts(scripts []ScriptMeta, tags []string, descSubstr string) []ScriptMeta {
+	filtered := []ScriptMeta{}
+	for _, s := range scripts {
+		if descSubstr != "" && !strings.Contains(strings.ToLower(s.Description), strings.ToLower(descSubstr)) {
+			continue
+		}
+		matchesAll := true
+		for _, tag := range tags {
+			if !hasTagFold(s.Tags, tag) {
+				matchesAll = false
+				break
+			}
+		}
+		if matchesAll {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// hasTagFold reports whether tags contains tag, ignoring case
+func hasTagFold(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 var scriptListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all scripts",
@@ -114,20 +190,89 @@ var scriptListCmd = &cobra.Command{
 				scripts = append(scripts, meta)
 			}
 		}
+		scripts = filterScripts(scripts, listTags, listDescContains)
 		sort.Slice(scripts, func(i, j int) bool { return scripts[i].Name < scripts[j].Name })
-		fmt.Fprintf(cmd.OutOrStdout(), "%-20s %-40s %-20s\n", "NAME", "DESCRIPTION", "TAGS")
-		for _, s := range scripts {
-			fmt.Fprintf(cmd.OutOrStdout(), "%-20s %-40s %-20s\n", s.Name, s.Description, strings.Join(s.Tags, ", "))
+		if err := renderScriptList(cmd.OutOrStdout(), scripts, listOutput); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to list scripts:", err)
 		}
 	},
 }
 
+// renderScriptList writes scripts as a table, a JSON array or a YAML list
+func renderScriptList(out io.Writer, scripts []ScriptMeta, format string) error {
+	switch format {
+	case "table":
+		fmt.Fprintf(out, "%-20s %-40s %-20s\n", "NAME", "DESCRIPTION", "TAGS")
+		for _, s := range scripts {
+			fmt.Fprintf(out, "%-20s %-40s %-20s\n", s.Name, s.Description, strings.Join(s.Tags, ", "))
+		}
+		return nil
+	case "json":
+		data, err := json.MarshalIndent(scripts, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	case "yaml":
+		data, err := yaml.Marshal(scripts)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format: %s (use table, json or yaml)", format)
+	}
+}
+
 var (
 	runWithEnvFile string
 	runEdit        bool
 	runDryRun      bool
+	runCleanEnv    bool
+	runWithDb      string
+	runViaNode     string
+	runConfirm     bool
 )
 
+// confirmPreviewLines is the number of script lines shown before asking to run it
+const confirmPreviewLines = 10
+
+// scriptRunConfirmer asks whether a previewed script should run
+var scriptRunConfirmer = func() (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     "Run this script?",
+		IsConfirm: true,
+	}
+	if _, err := prompt.Run(); err != nil {
+		if err == promptui.ErrAbort {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// printScriptPreview shows what is about to run: the script's name,
+// description, env file and first lines
+func printScriptPreview(out io.Writer, name string, meta ScriptMeta, envFile string, content []byte) {
+	if envFile == "" {
+		envFile = "(none)"
+	}
+	fmt.Fprintf(out, "Script: %s\nDescription: %s\nEnv file: %s\n", name, meta.Description, envFile)
+	fmt.Fprintln(out, "---")
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	for i, line := range lines {
+		if i == confirmPreviewLines {
+			fmt.Fprintf(out, "... (%d more lines)\n", len(lines)-confirmPreviewLines)
+			break
+		}
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintln(out, "---")
+}
+
 var scriptRunCmd = &cobra.Command{
 	Use:   "run [script name]",
 	Short: "Run a script",
@@ -140,7 +285,8 @@ var scriptRunCmd = &cobra.Command{
 		name := args[0]
 		scriptPath := scriptFilePath(name)
 		metaPath := scriptMetaPath(name)
-		if _, err := os.Stat(scriptPath); err != nil {
+		fi, err := os.Stat(scriptPath)
+		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Script not found:", name)
 			return
 		}
@@ -157,17 +303,52 @@ var scriptRunCmd = &cobra.Command{
 			return
 		}
 		content, _ := os.ReadFile(scriptPath)
+		var meta ScriptMeta
+		if metaBytes, err := os.ReadFile(metaPath); err == nil {
+			_ = json.Unmarshal(metaBytes, &meta)
+		}
+		interpreter, err := scriptInterpreter(meta.Language)
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), err)
+			return
+		}
 		var envs map[string]string
 		if runWithEnvFile != "" {
 			envs = loadEnvFile(runWithEnvFile)
 		}
+		if (runWithDb == "") != (runViaNode == "") {
+			fmt.Fprintln(cmd.OutOrStdout(), "--with-db and --via-node must be used together")
+			return
+		}
+		var tunnel *scriptDBTunnel
+		if runWithDb != "" {
+			hi, err := getHierarchicalInventory()
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to initialize inventory:", err)
+				return
+			}
+			t, err := resolveScriptDBTunnel(hi, runWithDb, runViaNode)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), err)
+				return
+			}
+			tunnel = &t
+			if envs == nil {
+				envs = make(map[string]string)
+			}
+			for k, v := range t.Env() {
+				envs[k] = v
+			}
+		}
 		if runDryRun {
 			fmt.Fprintln(cmd.OutOrStdout(), "--- DRY RUN ---")
-			if metaBytes, err := os.ReadFile(metaPath); err == nil {
-				var meta ScriptMeta
-				_ = json.Unmarshal(metaBytes, &meta)
+			if _, err := os.Stat(metaPath); err == nil {
 				fmt.Fprintf(cmd.OutOrStdout(), "Name: %s\nDescription: %s\nTags: %s\n", meta.Name, meta.Description, strings.Join(meta.Tags, ", "))
 			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Interpreter:", interpreter)
+			if tunnel != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Tunnel: -L %s via %s@%s\n", tunnel.Forward, tunnel.User, tunnel.Host)
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), "Env Vars:")
 			for k, v := range envs {
 				fmt.Fprintf(cmd.OutOrStdout(), "%s=%s\n", k, v)
@@ -176,20 +357,101 @@ var scriptRunCmd = &cobra.Command{
 			fmt.Fprintln(cmd.OutOrStdout(), string(content))
 			return
 		}
-		cmdExec := exec.Command("/bin/bash", scriptPath)
+		if isWorldWritable(fi) {
+			fmt.Fprintln(cmd.OutOrStdout(), "Script file is world-writable, refusing to execute for security")
+			return
+		}
+		if runConfirm || meta.ConfirmRequired {
+			printScriptPreview(cmd.OutOrStdout(), name, meta, runWithEnvFile, content)
+			ok, err := scriptRunConfirmer()
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Prompt failed:", err)
+				return
+			}
+			if !ok {
+				fmt.Fprintln(cmd.OutOrStdout(), "Aborted.")
+				return
+			}
+		}
+		cmdExec := exec.Command(interpreter, scriptPath)
 		cmdExec.Stdin = os.Stdin
 		cmdExec.Stdout = os.Stdout
 		cmdExec.Stderr = os.Stderr
-		for k, v := range envs {
-			cmdExec.Env = append(cmdExec.Env, fmt.Sprintf("%s=%s", k, v))
+		var baseEnv []string
+		if !runCleanEnv {
+			baseEnv = os.Environ()
 		}
-		err := cmdExec.Run()
+		cmdExec.Env = mergeEnv(baseEnv, envs)
+		runID, err := newRunID()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to generate run ID:", err)
+			return
+		}
+		cmdExec.Env = append(cmdExec.Env, scriptRunEnv(name, scriptPath, runID)...)
+		if tunnel != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "Opening tunnel -L %s via node '%s'\n", tunnel.Forward, runViaNode)
+			stop, err := sshTunnelStarter(tunnel.User, tunnel.Host, tunnel.Port, tunnel.Forward, tunnel.LocalPort)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), err)
+				return
+			}
+			defer stop()
+		}
+		err = cmdExec.Run()
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Script exited with error:", err)
 		}
 	},
 }
 
+// isWorldWritable reports whether anyone may modify the file, in which case
+// its contents cannot be trusted
+func isWorldWritable(fi os.FileInfo) bool {
+	return fi.Mode().Perm()&0o002 != 0
+}
+
+// mergeEnv returns base with the given variables set on top of it.
+// Variables in overrides replace any existing entry with the same key.
+func mergeEnv(base []string, overrides map[string]string) []string {
+	merged := make([]string, 0, len(base)+len(overrides))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := overrides[key]; !ok {
+			merged = append(merged, kv)
+		}
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		merged = append(merged, fmt.Sprintf("%s=%s", k, overrides[k]))
+	}
+	return merged
+}
+
+// scriptRunEnv returns the TSUKUYO_* variables injected into every script run
+func scriptRunEnv(name, scriptPath, runID string) []string {
+	return []string{
+		"TSUKUYO_SCRIPT_NAME=" + name,
+		"TSUKUYO_SCRIPT_PATH=" + scriptPath,
+		"TSUKUYO_RUN_ID=" + runID,
+	}
+}
+
+// newRunID generates a random (version 4) UUID identifying a single script run
+func newRunID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
 func loadEnvFile(path string) map[string]string {
 	f, err := os.Open(path)
 	if err != nil {
@@ -312,13 +574,28 @@ func containsTag(tags []string, query string) bool {
 var scriptCmd = &cobra.Command{
 	Use:   "script",
 	Short: "Manage and execute script inventory",
-	Long:  `Conveniently execute, view, and edit predefined scripts (bash for now, later node/deno/python).`,
+	Long: `Conveniently execute, view, and edit predefined scripts (bash for now, later node/deno/python).
+
+Run without a subcommand (or with --interactive) to pick an action from a menu.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runScriptMenu(cmd)
+	},
 }
 
 func init() {
+	scriptListCmd.Flags().StringArrayVar(&listTags, "tag", nil, "Only list scripts with this tag (repeatable, all must match)")
+	scriptListCmd.Flags().StringVar(&listDescContains, "description-contains", "", "Only list scripts whose description contains this text")
+	scriptListCmd.Flags().StringVar(&listOutput, "output", "table", "Output format: table, json or yaml")
+
 	scriptRunCmd.Flags().StringVar(&runWithEnvFile, "with-env-file", "", "Path to env file")
 	scriptRunCmd.Flags().BoolVar(&runEdit, "edit", false, "Edit script before running")
 	scriptRunCmd.Flags().BoolVar(&runDryRun, "dry-run", false, "Show env and script content without executing")
+	scriptRunCmd.Flags().StringVar(&runWithDb, "with-db", "", "Tunnel to this DB entry while the script runs (requires --via-node)")
+	scriptRunCmd.Flags().StringVar(&runViaNode, "via-node", "", "Node to open the --with-db tunnel through")
+	scriptRunCmd.Flags().BoolVar(&runConfirm, "confirm", false, "Preview the script and ask for confirmation before running it (default for scripts with confirm_required)")
+	scriptRunCmd.Flags().BoolVar(&runCleanEnv, "clean-env", false, "Do not inherit the parent environment; use only --with-env-file variables")
+
+	scriptCmd.Flags().BoolVarP(&scriptInteractive, "interactive", "i", false, "Pick an action from a menu (the default when no subcommand is given)")
 
 	scriptCmd.AddCommand(scriptAddCmd)
 	scriptCmd.AddCommand(scriptListCmd)
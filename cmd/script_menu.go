@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+// scriptMenuActions are the entries of the menu shown by a bare `tsukuyo script`
+var scriptMenuActions = []string{"List", "Add", "Run", "Edit", "Delete", "Search"}
+
+var scriptInteractive bool
+
+// scriptMenuSelector and scriptMenuInput drive the interactive menu
+var scriptMenuSelector = func(label string, items []string) (string, error) {
+	prompt := promptui.Select{
+		Label: label,
+		Items: items,
+		Searcher: func(input string, index int) bool {
+			return strings.Contains(strings.ToLower(items[index]), strings.ToLower(input))
+		},
+	}
+	_, choice, err := prompt.Run()
+	return choice, err
+}
+
+var scriptMenuInput = func(label string) (string, error) {
+	prompt := promptui.Prompt{Label: label}
+	return prompt.Run()
+}
+
+// scriptNames returns the sorted names of all scripts that have metadata
+func scriptNames() []string {
+	entries, _ := os.ReadDir(getScriptsDir())
+	var names []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), scriptMetaSuffix) {
+			names = append(names, strings.TrimSuffix(e.Name(), scriptMetaSuffix))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runScriptMenu asks for an action and runs the matching subcommand,
+// prompting for a script name or search query where one is needed
+func runScriptMenu(cmd *cobra.Command) {
+	out := cmd.OutOrStdout()
+	if err := ensureScriptDirs(); err != nil {
+		fmt.Fprintln(out, "Failed to access scripts dir:", err)
+		return
+	}
+
+	action, err := scriptMenuSelector("What do you want to do", scriptMenuActions)
+	if err != nil {
+		fmt.Fprintln(out, "Prompt failed:", err)
+		return
+	}
+
+	var sub *cobra.Command
+	var args []string
+	switch action {
+	case "List":
+		sub = scriptListCmd
+	case "Add":
+		sub = scriptAddCmd
+	case "Search":
+		query, err := scriptMenuInput("Search scripts")
+		if err != nil {
+			fmt.Fprintln(out, "Prompt failed:", err)
+			return
+		}
+		sub, args = scriptSearchCmd, []string{query}
+	case "Run", "Edit", "Delete":
+		names := scriptNames()
+		if len(names) == 0 {
+			fmt.Fprintln(out, "No scripts found. Add one with 'tsukuyo script add'.")
+			return
+		}
+		name, err := scriptMenuSelector(fmt.Sprintf("Select script to %s", strings.ToLower(action)), names)
+		if err != nil {
+			fmt.Fprintln(out, "Prompt failed:", err)
+			return
+		}
+		args = []string{name}
+		switch action {
+		case "Run":
+			sub = scriptRunCmd
+		case "Edit":
+			sub = scriptEditCmd
+		default:
+			sub = scriptDeleteCmd
+		}
+	default:
+		fmt.Fprintln(out, "Unknown action:", action)
+		return
+	}
+	sub.Run(sub, args)
+}
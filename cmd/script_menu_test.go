@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptMenu(t *testing.T) {
+	scriptsToCreate := []tempScript{
+		{
+			Meta:    ScriptMeta{Name: "alpha", Description: "First script", Tags: []string{"menu"}},
+			Content: "echo alpha",
+		},
+		{
+			Meta:    ScriptMeta{Name: "beta", Description: "Second script", Tags: []string{"other"}},
+			Content: "echo beta",
+		},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+
+	originalSelector, originalInput := scriptMenuSelector, scriptMenuInput
+	defer func() {
+		scriptMenuSelector, scriptMenuInput = originalSelector, originalInput
+	}()
+
+	// answers are consumed in order by the selector
+	var answers []string
+	var offered [][]string
+	scriptMenuSelector = func(label string, items []string) (string, error) {
+		offered = append(offered, items)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+	scriptMenuInput = func(label string) (string, error) { return "second", nil }
+
+	answers = []string{"List"}
+	output, err := executeCommand(rootCmd, "script")
+	assert.NoError(t, err)
+	assert.Equal(t, scriptMenuActions, offered[0])
+	assert.Contains(t, output, "alpha")
+	assert.Contains(t, output, "beta")
+
+	answers = []string{"Search"}
+	output, err = executeCommand(rootCmd, "script", "--interactive")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "beta")
+	assert.NotContains(t, output, "alpha")
+
+	offered = nil
+	answers = []string{"Delete", "alpha"}
+	output, err = executeCommand(rootCmd, "script")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "beta"}, offered[1])
+	assert.Contains(t, output, "Deleted script: alpha")
+	assert.Equal(t, []string{"beta"}, scriptNames())
+}
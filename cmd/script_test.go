@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 // tempScript represents a script to be created in the temporary test environment.
@@ -89,6 +93,80 @@ echo "Another one"`,
 	assert.Contains(t, output, "test, demo")
 }
 
+func TestScriptListFilters(t *testing.T) {
+	scriptsToCreate := []tempScript{
+		{
+			Meta:    ScriptMeta{Name: "deploy-api", Description: "Deploy API server", Tags: []string{"Deploy", "backend"}},
+			Content: "echo api",
+		},
+		{
+			Meta:    ScriptMeta{Name: "deploy-web", Description: "Deploy frontend", Tags: []string{"deploy", "frontend"}},
+			Content: "echo web",
+		},
+		{
+			Meta:    ScriptMeta{Name: "backup-db", Description: "Nightly backup", Tags: []string{"backup", "backend"}},
+			Content: "echo backup",
+		},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+	defer func() {
+		listTags = nil
+		listDescContains = ""
+	}()
+
+	// Single tag, case-insensitive
+	output, err := executeCommand(rootCmd, "script", "list", "--tag", "DEPLOY")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "deploy-api")
+	assert.Contains(t, output, "deploy-web")
+	assert.NotContains(t, output, "backup-db")
+
+	// Multiple tags are ANDed
+	listTags = nil
+	output, err = executeCommand(rootCmd, "script", "list", "--tag", "deploy", "--tag", "backend")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "deploy-api")
+	assert.NotContains(t, output, "deploy-web")
+	assert.NotContains(t, output, "backup-db")
+
+	// Description substring
+	listTags = nil
+	output, err = executeCommand(rootCmd, "script", "list", "--description-contains", "nightly")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "backup-db")
+	assert.NotContains(t, output, "deploy-api")
+	assert.NotContains(t, output, "deploy-web")
+}
+
+func TestScriptListOutputFormats(t *testing.T) {
+	scriptsToCreate := []tempScript{
+		{
+			Meta:    ScriptMeta{Name: "hello-world", Description: "Prints hello world", Tags: []string{"test"}, Language: "bash"},
+			Content: "echo hello",
+		},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+	defer func() { listOutput = "table" }()
+
+	output, err := executeCommand(rootCmd, "script", "list", "--output", "json")
+	assert.NoError(t, err)
+	var fromJSON []ScriptMeta
+	assert.NoError(t, json.Unmarshal([]byte(output), &fromJSON))
+	assert.Equal(t, scriptsToCreate[0].Meta, fromJSON[0])
+
+	output, err = executeCommand(rootCmd, "script", "list", "--output", "yaml")
+	assert.NoError(t, err)
+	var fromYAML []ScriptMeta
+	assert.NoError(t, yaml.Unmarshal([]byte(output), &fromYAML))
+	assert.Equal(t, scriptsToCreate[0].Meta, fromYAML[0])
+
+	output, err = executeCommand(rootCmd, "script", "list", "--output", "xml")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "unsupported output format: xml")
+}
+
 func TestScriptListEmpty(t *testing.T) {
 	_, cleanup := setupTestScripts(t, []tempScript{}) // No scripts
 	defer cleanup()
@@ -105,7 +183,7 @@ func TestScriptAddCmd(t *testing.T) {
 	defer cleanup()
 
 	// Mock user input
-	input := "new-script\nA cool new script\ntest,new\n#!/bin/bash\necho 'new'\n"
+	input := "new-script\nA cool new script\ntest,new\n\n#!/bin/bash\necho 'new'\n"
 	r, w, _ := os.Pipe()
 	w.Write([]byte(input))
 	w.Close()
@@ -135,6 +213,20 @@ func TestScriptAddCmd(t *testing.T) {
 	assert.Equal(t, "new-script", meta.Name)
 	assert.Equal(t, "A cool new script", meta.Description)
 	assert.Equal(t, []string{"test", "new"}, meta.Tags)
+	assert.Equal(t, "bash", meta.Language)
+}
+
+func TestScriptInterpreter(t *testing.T) {
+	interpreter, err := scriptInterpreter("")
+	assert.NoError(t, err)
+	assert.Equal(t, "/bin/bash", interpreter)
+
+	interpreter, err = scriptInterpreter("python3")
+	assert.NoError(t, err)
+	assert.Equal(t, "python3", interpreter)
+
+	_, err = scriptInterpreter("cobol")
+	assert.Error(t, err)
 }
 
 func TestScriptDeleteCmd(t *testing.T) {
@@ -238,6 +330,257 @@ echo "SCRIPT_VAR=${SCRIPT_VAR}"
 	// of the logic leading up to the execution.
 }
 
+func TestScriptRunInjectsTsukuyoEnv(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "tsukuyo-test-run-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(outDir)
+	outFile := filepath.Join(outDir, "env.txt")
+
+	scriptsToCreate := []tempScript{
+		{
+			Meta: ScriptMeta{Name: "env-test", Description: "Dumps tsukuyo env", Tags: []string{"run"}},
+			Content: `#!/bin/bash
+echo "$TSUKUYO_SCRIPT_NAME" > ` + outFile + `
+echo "$TSUKUYO_SCRIPT_PATH" >> ` + outFile + `
+echo "$TSUKUYO_RUN_ID" >> ` + outFile + `
+`,
+		},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+
+	// Flag values persist on rootCmd between tests
+	runDryRun = false
+	runWithEnvFile = ""
+
+	_, err = executeCommand(rootCmd, "script", "run", "env-test")
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(outFile)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "env-test", lines[0])
+	assert.Equal(t, scriptFilePath("env-test"), lines[1])
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, lines[2])
+}
+
+func TestScriptRunUsesLanguage(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "tsukuyo-test-run-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(outDir)
+	outFile := filepath.Join(outDir, "lang.txt")
+
+	// No shebang: only the Language field selects the interpreter
+	scriptsToCreate := []tempScript{
+		{
+			Meta:    ScriptMeta{Name: "sh-test", Description: "Runs with sh", Language: "sh"},
+			Content: "echo ran > " + outFile + "\n",
+		},
+		{
+			Meta:    ScriptMeta{Name: "bad-lang", Description: "Unknown language", Language: "cobol"},
+			Content: "DISPLAY 'HI'.\n",
+		},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+
+	runDryRun = false
+	runWithEnvFile = ""
+
+	_, err = executeCommand(rootCmd, "script", "run", "sh-test")
+	assert.NoError(t, err)
+	content, err := ioutil.ReadFile(outFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "ran\n", string(content))
+
+	output, err := executeCommand(rootCmd, "script", "run", "bad-lang")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "unsupported script language: cobol")
+}
+
+func TestScriptRunEnvInheritance(t *testing.T) {
+	outDir := t.TempDir()
+	outFile := filepath.Join(outDir, "env.txt")
+	envFile := filepath.Join(outDir, "test.env")
+	assert.NoError(t, os.WriteFile(envFile, []byte("TSUKUYO_TEST_FOO=from-file\n"), 0644))
+
+	t.Setenv("TSUKUYO_TEST_FOO", "from-parent")
+	t.Setenv("TSUKUYO_TEST_BAR", "from-parent")
+
+	scriptsToCreate := []tempScript{
+		{
+			Meta:    ScriptMeta{Name: "env-inherit", Description: "Dumps env"},
+			Content: "#!/bin/bash\necho \"$TSUKUYO_TEST_FOO|$TSUKUYO_TEST_BAR\" > " + outFile + "\n",
+		},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+	defer func() {
+		runWithEnvFile = ""
+		runCleanEnv = false
+	}()
+	runDryRun = false
+
+	// File variables win over the inherited environment
+	_, err := executeCommand(rootCmd, "script", "run", "env-inherit", "--with-env-file", envFile)
+	assert.NoError(t, err)
+	content, err := ioutil.ReadFile(outFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "from-file|from-parent\n", string(content))
+
+	// --clean-env drops the parent environment
+	_, err = executeCommand(rootCmd, "script", "run", "env-inherit", "--with-env-file", envFile, "--clean-env")
+	assert.NoError(t, err)
+	content, err = ioutil.ReadFile(outFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "from-file|\n", string(content))
+}
+
+func TestScriptRunWithDbTunnel(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "env.txt")
+	scriptsToCreate := []tempScript{
+		{
+			Meta:    ScriptMeta{Name: "db-test", Description: "Dumps db env"},
+			Content: "#!/bin/bash\necho \"$DB_HOST:$DB_PORT\" > " + outFile + "\n",
+		},
+	}
+	_, cleanupScripts := setupTestScripts(t, scriptsToCreate)
+	defer cleanupScripts()
+	_, cleanupInventory := setupIsolatedInventory(t)
+	defer cleanupInventory()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("db.pg", DbInventoryEntry{Host: "pg.internal", Type: "postgres", RemotePort: 5432, LocalPort: 15432}))
+	assert.NoError(t, hi.Set("node.bastion", map[string]interface{}{"host": "10.0.0.1", "user": "ops"}))
+
+	var started []string
+	stopped := false
+	originalStarter := sshTunnelStarter
+	sshTunnelStarter = func(user, host string, port int, forward string, localPort int) (func(), error) {
+		started = append(started, fmt.Sprintf("%s@%s:%d -L %s (%d)", user, host, port, forward, localPort))
+		return func() { stopped = true }, nil
+	}
+	defer func() {
+		sshTunnelStarter = originalStarter
+		runWithDb = ""
+		runViaNode = ""
+	}()
+	runDryRun = false
+	runWithEnvFile = ""
+
+	_, err = executeCommand(rootCmd, "script", "run", "db-test", "--with-db", "pg", "--via-node", "bastion")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ops@10.0.0.1:22 -L 15432:pg.internal:5432 (15432)"}, started)
+	assert.True(t, stopped, "tunnel should be torn down after the script exits")
+	content, err := ioutil.ReadFile(outFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:15432\n", string(content))
+
+	// Both flags are required together
+	runViaNode = ""
+	output, err := executeCommand(rootCmd, "script", "run", "db-test", "--with-db", "pg")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "--with-db and --via-node must be used together")
+}
+
+func TestScriptRunRefusesWorldWritable(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "ran.txt")
+	scriptsToCreate := []tempScript{
+		{
+			Meta:    ScriptMeta{Name: "shared", Description: "World-writable script"},
+			Content: "#!/bin/bash\necho ran > " + outFile + "\n",
+		},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+	runDryRun = false
+	runWithEnvFile = ""
+
+	// Chmod explicitly since the umask may strip the bit on creation
+	assert.NoError(t, os.Chmod(scriptFilePath("shared"), 0777))
+
+	output, err := executeCommand(rootCmd, "script", "run", "shared")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Script file is world-writable, refusing to execute for security")
+	assert.NoFileExists(t, outFile)
+
+	assert.NoError(t, os.Chmod(scriptFilePath("shared"), 0755))
+	_, err = executeCommand(rootCmd, "script", "run", "shared")
+	assert.NoError(t, err)
+	assert.FileExists(t, outFile)
+}
+
+func TestScriptRunConfirm(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "ran.txt")
+	var content strings.Builder
+	content.WriteString("#!/bin/bash\n")
+	for i := 1; i <= 11; i++ {
+		fmt.Fprintf(&content, "# line %d\n", i)
+	}
+	content.WriteString("echo ran > " + outFile + "\n")
+	scriptsToCreate := []tempScript{
+		{Meta: ScriptMeta{Name: "wipe", Description: "Drops the staging database"}, Content: content.String()},
+		{Meta: ScriptMeta{Name: "guarded", Description: "Always asks", ConfirmRequired: true}, Content: "#!/bin/bash\necho ran > " + outFile + "\n"},
+	}
+	_, cleanup := setupTestScripts(t, scriptsToCreate)
+	defer cleanup()
+	runDryRun = false
+	runWithEnvFile = ""
+	defer func() { runConfirm = false }()
+
+	originalConfirmer := scriptRunConfirmer
+	defer func() { scriptRunConfirmer = originalConfirmer }()
+	asked := 0
+	answer := false
+	scriptRunConfirmer = func() (bool, error) {
+		asked++
+		return answer, nil
+	}
+
+	output, err := executeCommand(rootCmd, "script", "run", "--confirm", "wipe")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, asked)
+	assert.Contains(t, output, "Script: wipe\nDescription: Drops the staging database\nEnv file: (none)\n")
+	assert.Contains(t, output, "# line 9\n... (3 more lines)\n")
+	assert.NotContains(t, output, "# line 10")
+	assert.Contains(t, output, "Aborted.")
+	assert.NoFileExists(t, outFile)
+
+	answer = true
+	_, err = executeCommand(rootCmd, "script", "run", "--confirm", "wipe")
+	assert.NoError(t, err)
+	assert.FileExists(t, outFile)
+	assert.NoError(t, os.Remove(outFile))
+
+	// confirm_required asks without the flag
+	runConfirm = false
+	answer = false
+	output, err = executeCommand(rootCmd, "script", "run", "guarded")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, asked)
+	assert.Contains(t, output, "Aborted.")
+	assert.NoFileExists(t, outFile)
+}
+
+func TestMergeEnv(t *testing.T) {
+	merged := mergeEnv([]string{"PATH=/bin", "FOO=old", "EMPTY="}, map[string]string{"FOO": "new", "BAZ": "1"})
+	assert.Equal(t, []string{"PATH=/bin", "EMPTY=", "BAZ=1", "FOO=new"}, merged)
+}
+
+func TestNewRunID(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first, err := newRunID()
+	assert.NoError(t, err)
+	second, err := newRunID()
+	assert.NoError(t, err)
+
+	assert.Regexp(t, uuidPattern, first)
+	assert.Regexp(t, uuidPattern, second)
+	assert.NotEqual(t, first, second)
+}
+
 func TestLoadEnvFile(t *testing.T) {
 	tmpDir, err := ioutil.TempDir("", "tsukuyo-test-env-")
 	assert.NoError(t, err)
@@ -271,6 +614,19 @@ func TestSanitizeScriptName(t *testing.T) {
 	assert.Equal(t, "my_complex_script_name", sanitizeScriptName("my complex/script name"))
 }
 
+func TestFilterScripts(t *testing.T) {
+	scripts := []ScriptMeta{
+		{Name: "a", Description: "First script", Tags: []string{"Go", "test"}},
+		{Name: "b", Description: "Second script", Tags: []string{"go"}},
+	}
+
+	assert.Len(t, filterScripts(scripts, nil, ""), 2)
+	assert.Len(t, filterScripts(scripts, []string{"go"}, ""), 2)
+	assert.Len(t, filterScripts(scripts, []string{"go", "test"}, ""), 1)
+	assert.Len(t, filterScripts(scripts, []string{"te"}, ""), 0, "tags must match exactly")
+	assert.Len(t, filterScripts(scripts, nil, "SECOND"), 1)
+}
+
 func TestContainsTag(t *testing.T) {
 	tags := []string{"Go", "Test", "Example"}
 	assert.True(t, containsTag(tags, "test"))
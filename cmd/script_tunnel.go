@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+)
+
+// tunnelReadyTimeout bounds how long to wait for a tunnel's local port
+const tunnelReadyTimeout = 15 * time.Second
+
+// sshTunnelStarter starts a background ssh port-forward and returns its teardown
+var sshTunnelStarter = func(user, host string, port int, forward string, localPort int) (func(), error) {
+	args := []string{"-N", "-o", "ExitOnForwardFailure=yes", "-L", forward}
+	if port != 22 {
+		args = append(args, "-p", strconv.Itoa(port))
+	}
+	args = append(args, fmt.Sprintf("%s@%s", user, host))
+
+	tunnel := exec.Command("ssh", args...)
+	if err := tunnel.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ssh tunnel: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- tunnel.Wait() }()
+	stop := func() {
+		_ = tunnel.Process.Kill()
+		<-exited
+	}
+
+	// Wait until the forwarded port accepts connections or ssh gives up
+	deadline := time.Now().Add(tunnelReadyTimeout)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-exited:
+			return nil, fmt.Errorf("ssh tunnel exited: %v", err)
+		default:
+		}
+		if conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+			conn.Close()
+			return stop, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	stop()
+	return nil, fmt.Errorf("timed out waiting for tunnel on %s", addr)
+}
+
+// scriptDBTunnel describes the tunnel opened for script run --with-db --via-node
+type scriptDBTunnel struct {
+	User      string
+	Host      string
+	Port      int
+	LocalPort int
+	Forward   string
+}
+
+// resolveScriptDBTunnel looks up the db and node entries used for a tunnel
+func resolveScriptDBTunnel(hi *inventory.HierarchicalInventory, dbName, nodeName string) (scriptDBTunnel, error) {
+	dbData, err := hi.Query("db." + dbName)
+	if err != nil {
+		return scriptDBTunnel{}, fmt.Errorf("db entry '%s' not found", dbName)
+	}
+	dbEntry, err := inventory.ParseDbEntry(dbData)
+	if err != nil {
+		return scriptDBTunnel{}, err
+	}
+
+	result, err := hi.Query("node." + nodeName)
+	if err != nil {
+		return scriptDBTunnel{}, fmt.Errorf("node '%s' not found", nodeName)
+	}
+	nodeData, ok := result.(map[string]interface{})
+	if !ok {
+		return scriptDBTunnel{}, fmt.Errorf("invalid node data format for '%s'", nodeName)
+	}
+	user, host, port := nodeConnInfo(nodeData)
+	if host == "" {
+		return scriptDBTunnel{}, fmt.Errorf("node '%s' has no host", nodeName)
+	}
+
+	localPort := dbEntry.LocalPort
+	if localPort == 0 {
+		localPort = dbEntry.RemotePort // Default to same as remote
+	}
+	return scriptDBTunnel{
+		User:      user,
+		Host:      host,
+		Port:      port,
+		LocalPort: localPort,
+		Forward:   fmt.Sprintf("%d:%s:%d", localPort, dbEntry.Host, dbEntry.RemotePort),
+	}, nil
+}
+
+// Env returns the variables that point a script at the tunnel
+func (t scriptDBTunnel) Env() map[string]string {
+	return map[string]string{
+		"DB_HOST": "127.0.0.1",
+		"DB_PORT": strconv.Itoa(t.LocalPort),
+	}
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/arung-agamani/tsukuyo/internal/inventory"
 	"github.com/manifoldco/promptui"
@@ -15,21 +18,43 @@ var sshCmd = &cobra.Command{
 	Use:   "ssh",
 	Short: "Connect to a node using standard SSH client or manage SSH node inventory",
 	Long: `Connect to a node using OpenSSH, or manage SSH node inventory.\n\n\
-Direct connect: tsukuyo ssh <node-name>\n\
+Direct connect: tsukuyo ssh <node-name> (pick from a list if omitted)\n\
 Manage inventory: tsukuyo ssh set|get|list [args]\n\
-Supports SSH tunneling with --tunnel flag.`,
+Supports SSH tunneling with --tunnel flag.\n\
+Batch connectivity check: tsukuyo ssh --nodes-file nodes.txt\n\
+Show the ssh command without connecting: tsukuyo ssh <node-name> --print-command`,
 	Args: cobra.MaximumNArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "Usage: tsukuyo ssh <node-name>|set|get|list [args]")
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sshNodesFile != "" {
+			hi, err := getHierarchicalInventory()
+			if err != nil {
+				return fmt.Errorf("failed to initialize inventory: %v", err)
+			}
+			cmd.SilenceUsage = true
+			return runNodesFileCheck(cmd, hi, sshNodesFile)
 		}
 
 		// Get hierarchical inventory
 		hi, err := getHierarchicalInventory()
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Failed to initialize inventory:", err)
-			return
+			return nil
+		}
+
+		if len(args) == 0 {
+			nodeKeys, err := hi.List("node")
+			if err != nil || len(nodeKeys) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No SSH node inventory found.")
+				fmt.Fprintln(cmd.OutOrStdout(), "Usage: tsukuyo ssh <node-name>|set|get|list [args]")
+				return nil
+			}
+			sort.Strings(nodeKeys)
+			name, err := sshNodeSelector(nodeKeys)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Prompt failed:", err)
+				return nil
+			}
+			args = []string{name}
 		}
 
 		cmds := map[string]bool{"set": true, "get": true, "list": true}
@@ -45,7 +70,7 @@ Supports SSH tunneling with --tunnel flag.`,
 				}
 				if name == "set" || name == "get" || name == "list" {
 					fmt.Fprintln(cmd.OutOrStdout(), "Invalid node name: cannot be 'set', 'get', or 'list'.")
-					return
+					return nil
 				}
 				if len(args) > 2 {
 					host = args[2]
@@ -55,7 +80,7 @@ Supports SSH tunneling with --tunnel flag.`,
 				}
 				if name == "" || host == "" {
 					fmt.Fprintln(cmd.OutOrStdout(), "Name and host must not be empty.")
-					return
+					return nil
 				}
 				// Prompt for user, default to current shell user
 				if u := os.Getenv("USER"); u != "" {
@@ -65,7 +90,7 @@ Supports SSH tunneling with --tunnel flag.`,
 				user, _ = prompt.Run()
 				if user == "" {
 					fmt.Fprintln(cmd.OutOrStdout(), "User must not be empty.")
-					return
+					return nil
 				}
 
 				// Prompt for tags
@@ -92,7 +117,7 @@ Supports SSH tunneling with --tunnel flag.`,
 				err = hi.Set(path, nodeData)
 				if err != nil {
 					fmt.Fprintln(cmd.OutOrStdout(), "Failed to set node:", err)
-					return
+					return nil
 				}
 
 				fmt.Fprintf(cmd.OutOrStdout(), "Node '%s' set to host '%s' with user '%s'\n", name, host, user)
@@ -101,7 +126,7 @@ Supports SSH tunneling with --tunnel flag.`,
 				nodeKeys, err := hi.List("node")
 				if err != nil || len(nodeKeys) == 0 {
 					fmt.Fprintln(cmd.OutOrStdout(), "No SSH node inventory found.")
-					return
+					return nil
 				}
 
 				var name string
@@ -115,23 +140,20 @@ Supports SSH tunneling with --tunnel flag.`,
 				result, err := hi.Query(fmt.Sprintf("node.%s", name))
 				if err != nil {
 					fmt.Fprintln(cmd.OutOrStdout(), "Node not found.")
-					return
+					return nil
 				}
 
 				// Parse the node data
 				nodeData, ok := result.(map[string]interface{})
 				if !ok {
 					fmt.Fprintln(cmd.OutOrStdout(), "Invalid node data format.")
-					return
+					return nil
 				}
 
 				host, _ := nodeData["host"].(string)
 				nodeType, _ := nodeData["type"].(string)
 				user, _ := nodeData["user"].(string)
-				port := 22 // default
-				if p, ok := nodeData["port"].(float64); ok {
-					port = int(p)
-				}
+				_, port := nodeHostPort(nodeData)
 				tags := getNodeTags(nodeData)
 
 				fmt.Fprintf(cmd.OutOrStdout(), "%s: host=%s, type=%s, port=%d, user=%s, tags=%s\n", name, host, nodeType, port, user, strings.Join(tags, ","))
@@ -140,7 +162,7 @@ Supports SSH tunneling with --tunnel flag.`,
 				nodeKeys, err := hi.List("node")
 				if err != nil || len(nodeKeys) == 0 {
 					fmt.Fprintln(cmd.OutOrStdout(), "No SSH node inventory found.")
-					return
+					return nil
 				}
 
 				fmt.Fprintln(cmd.OutOrStdout(), "Available SSH nodes:")
@@ -158,16 +180,13 @@ Supports SSH tunneling with --tunnel flag.`,
 					host, _ := nodeData["host"].(string)
 					nodeType, _ := nodeData["type"].(string)
 					user, _ := nodeData["user"].(string)
-					port := 22 // default
-					if p, ok := nodeData["port"].(float64); ok {
-						port = int(p)
-					}
+					_, port := nodeHostPort(nodeData)
 					tags := getNodeTags(nodeData)
 
 					fmt.Fprintf(cmd.OutOrStdout(), "- %s: host=%s, type=%s, port=%d, user=%s, tags=[%s]\n", nodeName, host, nodeType, port, user, strings.Join(tags, ", "))
 				}
 			}
-			return
+			return nil
 		}
 
 		// Not a command, treat as node name
@@ -175,42 +194,26 @@ Supports SSH tunneling with --tunnel flag.`,
 		result, err := hi.Query(fmt.Sprintf("node.%s", name))
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "Node or command not found.")
-			return
+			return nil
 		}
 
 		// Parse the node data
 		nodeData, ok := result.(map[string]interface{})
 		if !ok {
 			fmt.Fprintln(cmd.OutOrStdout(), "Invalid node data format.")
-			return
-		}
-
-		host, _ := nodeData["host"].(string)
-		user, _ := nodeData["user"].(string)
-		if user == "" {
-			user = "ubuntu"
+			return nil
 		}
 
-		port := 22 // default
-		if p, ok := nodeData["port"].(float64); ok {
-			port = int(p)
-		}
-
-		sshArgs := []string{}
-		if port != 22 {
-			sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", user, host), "-p", fmt.Sprintf("%d", port))
-		} else {
-			sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", user, host))
-		}
+		sshArgs := buildSSHArgs(nodeData, sshForwardAgent)
 
 		if withDbSsh == "__INTERACTIVE__" {
 			withDbSsh = ""
 		}
 		if withDbSsh != "" || cmd.Flags().Changed("with-db") {
-			dbEntry, err := selectDbWithTagging(hi, nodeData)
+			dbEntry, err := inventory.SelectDbEntry(hi, getNodeTags(nodeData))
 			if err != nil {
 				fmt.Fprintln(cmd.OutOrStdout(), err)
-				return
+				return nil
 			}
 
 			localPort := dbEntry.LocalPort
@@ -226,90 +229,116 @@ Supports SSH tunneling with --tunnel flag.`,
 			sshArgs = append([]string{"-L", tunnelTarget}, sshArgs...)
 		}
 
-		sshExec := exec.Command("ssh", sshArgs...)
-		sshExec.Stdin = cmd.InOrStdin()
-		sshExec.Stdout = cmd.OutOrStdout()
-		sshExec.Stderr = cmd.ErrOrStderr()
-		err = sshExec.Run()
-		if err != nil {
+		if sshPrintCommand {
+			fmt.Fprintln(cmd.OutOrStdout(), sshCommandLine(sshArgs))
+			return nil
+		}
+
+		if !sshSkipConnectivityCheck {
+			if err := checkNodeReachable(nodeData); err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), err)
+				return nil
+			}
+		}
+
+		if err := verifyNodeFingerprint(cmd, name, nodeData, sshStrict); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), err)
+			return nil
+		}
+
+		if err := runSSH(cmd, sshArgs); err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "SSH exited with error:", err)
+			return nil
 		}
+		recordNodeConnected(cmd, hi, name)
+		return nil
 	},
 }
 
-var tunnelTarget string
-var withDbSsh string
-
-func init() {
-	sshCmd.Flags().StringVar(&tunnelTarget, "tunnel", "", "Tunnel in format localPort:remoteHost:remotePort (optional)")
-	sshCmd.Flags().StringVar(&withDbSsh, "with-db", "", "Tunnel to DB key from inventory (interactive if empty)")
-	sshCmd.Flags().Lookup("with-db").NoOptDefVal = "__INTERACTIVE__"
-	rootCmd.AddCommand(sshCmd)
-}
+// buildSSHArgs returns the ssh arguments that connect to a node: the
+// user@host target, a non-default port, the configured connect timeout and
+// -A when agent forwarding is requested by --forward-agent or the node's
+// forward_agent field
+func buildSSHArgs(nodeData map[string]interface{}, forwardAgent bool) []string {
+	host, port := nodeHostPort(nodeData)
+	user, _ := nodeData["user"].(string)
+	if user == "" {
+		user = appConfig.GetString("default_ssh_user")
+	}
 
-func selectDbWithTagging(hi *inventory.HierarchicalInventory, nodeData map[string]interface{}) (*DbInventoryEntry, error) {
-	dbEntries, err := hi.List("db")
-	if err != nil || len(dbEntries) == 0 {
-		return nil, fmt.Errorf("no DB inventory found")
+	sshArgs := []string{}
+	if port != 22 {
+		sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", user, host), "-p", fmt.Sprintf("%d", port))
+	} else {
+		sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", user, host))
 	}
 
-	nodeTags := getNodeTags(nodeData)
-	var filteredEntries []string
-	entryMap := make(map[string]DbInventoryEntry)
+	if timeout := appConfig.GetInt("ssh_timeout"); timeout > 0 {
+		sshArgs = append([]string{"-o", fmt.Sprintf("ConnectTimeout=%d", timeout)}, sshArgs...)
+	}
 
-	for _, key := range dbEntries {
-		entryData, err := hi.Query(fmt.Sprintf("db.%s", key))
-		if err != nil {
-			continue
-		}
-		var entry DbInventoryEntry
-		// manual parsing from map[string]interface{} to struct
-		if raw, ok := entryData.(map[string]interface{}); ok {
-			if h, ok := raw["host"].(string); ok {
-				entry.Host = h
-			}
-			if t, ok := raw["type"].(string); ok {
-				entry.Type = t
-			}
-			if rp, ok := raw["remote_port"].(float64); ok {
-				entry.RemotePort = int(rp)
-			}
-			if lp, ok := raw["local_port"].(float64); ok {
-				entry.LocalPort = int(lp)
-			}
-			if tags, ok := raw["tags"].([]interface{}); ok {
-				for _, tag := range tags {
-					if t, ok := tag.(string); ok {
-						entry.Tags = append(entry.Tags, t)
-					}
-				}
-			}
-		}
+	if nodeForward, _ := nodeData["forward_agent"].(bool); forwardAgent || nodeForward {
+		sshArgs = append([]string{"-A"}, sshArgs...)
+	}
+	return sshArgs
+}
 
-		if len(entry.Tags) == 0 || hasCommonTags(nodeTags, entry.Tags) {
-			filteredEntries = append(filteredEntries, key)
-			entryMap[key] = entry
-		}
+// sshCommandLine renders the ssh invocation for --print-command, quoting
+// arguments the shell would otherwise split or expand
+func sshCommandLine(sshArgs []string) string {
+	parts := []string{"ssh"}
+	for _, arg := range sshArgs {
+		parts = append(parts, quoteEnvValue(arg))
 	}
+	return strings.Join(parts, " ")
+}
+
+// sshPreflightTimeout bounds the reachability check done before running ssh
+const sshPreflightTimeout = 3 * time.Second
 
-	if len(filteredEntries) == 0 {
-		return nil, fmt.Errorf("no DB entries with matching tags found")
+// checkNodeReachable dials the node's SSH port so an unreachable node gets a
+// readable error instead of ssh's "Connection refused"
+func checkNodeReachable(nodeData map[string]interface{}) error {
+	host, port := nodeHostPort(nodeData)
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	if err := tcpDialer(addr, sshPreflightTimeout); err != nil {
+		return fmt.Errorf("Cannot reach %s: %v. Check network connectivity and node status.", addr, err)
 	}
+	return nil
+}
 
+// sshNodeSelector picks a node to connect to when no node name is given
+var sshNodeSelector = func(nodeKeys []string) (string, error) {
 	prompt := promptui.Select{
-		Label: "Select DB key for tunnel",
-		Items: filteredEntries,
+		Label: "Select node",
+		Items: nodeKeys,
 		Searcher: func(input string, index int) bool {
-			return strings.Contains(strings.ToLower(filteredEntries[index]), strings.ToLower(input))
+			return strings.Contains(strings.ToLower(nodeKeys[index]), strings.ToLower(input))
 		},
 	}
-	_, selectedKey, err := prompt.Run()
-	if err != nil {
-		return nil, fmt.Errorf("prompt failed: %v", err)
-	}
+	_, name, err := prompt.Run()
+	return name, err
+}
+
+var tunnelTarget string
+var withDbSsh string
+var sshStrict bool
+var sshNodesFile string
+var sshForwardAgent bool
+var sshSkipConnectivityCheck bool
+var sshPrintCommand bool
 
-	selectedEntry := entryMap[selectedKey]
-	return &selectedEntry, nil
+func init() {
+	sshCmd.Flags().StringVar(&tunnelTarget, "tunnel", "", "Tunnel in format localPort:remoteHost:remotePort (optional)")
+	sshCmd.Flags().StringVar(&withDbSsh, "with-db", "", "Tunnel to DB key from inventory (interactive if empty)")
+	sshCmd.Flags().Lookup("with-db").NoOptDefVal = "__INTERACTIVE__"
+	sshCmd.Flags().BoolVar(&sshStrict, "strict", false, "Refuse to connect if the host key differs from the stored ssh_fingerprint")
+	sshCmd.Flags().StringVar(&sshNodesFile, "nodes-file", "", "Test connectivity to the nodes listed in this file (one name per line) and exit")
+	sshCmd.Flags().BoolVarP(&sshForwardAgent, "forward-agent", "A", false, "Forward the SSH agent to the node (ssh -A); set forward_agent: true on a node to make it the default")
+	sshCmd.Flags().BoolVar(&sshSkipConnectivityCheck, "skip-connectivity-check", false, "Run ssh without first checking that the node's port accepts connections")
+	sshCmd.Flags().BoolVar(&sshPrintCommand, "print-command", false, "Print the ssh command that would be run and exit without connecting")
+	sshCmd.Flags().BoolVar(&sshPrintCommand, "dry-run", false, "Alias for --print-command")
+	rootCmd.AddCommand(sshCmd)
 }
 
 func getNodeTags(nodeData map[string]interface{}) []string {
@@ -324,14 +353,3 @@ func getNodeTags(nodeData map[string]interface{}) []string {
 	}
 	return []string{}
 }
-
-func hasCommonTags(tags1, tags2 []string) bool {
-	for _, t1 := range tags1 {
-		for _, t2 := range tags2 {
-			if t1 == t2 {
-				return true
-			}
-		}
-	}
-	return false
-}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// maxParallelSSHChecks bounds the number of concurrent connectivity checks
+const maxParallelSSHChecks = 10
+
+// sshCheckRunner runs a non-interactive ssh connection test against a node
+var sshCheckRunner = func(user, host string, port int) error {
+	args := []string{"-q", "-o", "BatchMode=yes", "-o", "ConnectTimeout=3"}
+	if port != 22 {
+		args = append(args, "-p", strconv.Itoa(port))
+	}
+	args = append(args, fmt.Sprintf("%s@%s", user, host), "true")
+	return exec.Command("ssh", args...).Run()
+}
+
+// nodeConnInfo returns the user, host and port used to connect to a node
+func nodeConnInfo(nodeData map[string]interface{}) (string, string, int) {
+	user, _ := nodeData["user"].(string)
+	if user == "" {
+		user = appConfig.GetString("default_ssh_user")
+	}
+	host, port := nodeHostPort(nodeData)
+	return user, host, port
+}
+
+// readNodesFile returns the node names listed in a file, one per line.
+// Blank lines and lines starting with # are ignored.
+func readNodesFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
+
+type sshCheckResult struct {
+	Name string
+	Host string
+	Err  error
+}
+
+// checkNodesConnectivity tests every node in parallel and returns the
+// results in the order the nodes were given
+func checkNodesConnectivity(hi *inventory.HierarchicalInventory, names []string) []sshCheckResult {
+	results := make([]sshCheckResult, len(names))
+	sem := make(chan struct{}, maxParallelSSHChecks)
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		results[i].Name = name
+
+		data, err := hi.Query("node." + name)
+		if err != nil {
+			results[i].Err = fmt.Errorf("node not found")
+			continue
+		}
+		nodeData, ok := data.(map[string]interface{})
+		if !ok {
+			results[i].Err = fmt.Errorf("invalid node data format")
+			continue
+		}
+		user, host, port := nodeConnInfo(nodeData)
+		results[i].Host = knownHostsEntry(host, port)
+
+		wg.Add(1)
+		go func(i int, user, host string, port int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i].Err = sshCheckRunner(user, host, port)
+		}(i, user, host, port)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// runNodesFileCheck tests connectivity to the nodes listed in path and prints
+// a pass/fail table. It returns an error if any node fails.
+func runNodesFileCheck(cmd *cobra.Command, hi *inventory.HierarchicalInventory, path string) error {
+	names, err := readNodesFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read nodes file: %v", err)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no nodes listed in %s", path)
+	}
+
+	results := checkNodesConnectivity(hi, names)
+
+	out := cmd.OutOrStdout()
+	failed := 0
+	fmt.Fprintf(out, "%-20s %-30s %s\n", "NODE", "HOST", "STATUS")
+	for _, r := range results {
+		status := "ok"
+		if r.Err != nil {
+			status = "FAIL (" + r.Err.Error() + ")"
+			failed++
+		}
+		fmt.Fprintf(out, "%-20s %-30s %s\n", r.Name, r.Host, status)
+	}
+	fmt.Fprintf(out, "\n%d passed, %d failed\n", len(results)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d nodes failed the connectivity check", failed, len(results))
+	}
+	return nil
+}
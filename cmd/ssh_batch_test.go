@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunNodesFileCheck(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1", "user": "deploy"}))
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "10.0.0.2", "port": 2222}))
+
+	originalRunner := sshCheckRunner
+	defer func() { sshCheckRunner = originalRunner }()
+	var mu sync.Mutex
+	var calls []string
+	sshCheckRunner = func(user, host string, port int) error {
+		mu.Lock()
+		calls = append(calls, fmt.Sprintf("%s@%s:%d", user, host, port))
+		mu.Unlock()
+		if host == "10.0.0.2" {
+			return fmt.Errorf("exit status 255")
+		}
+		return nil
+	}
+
+	nodesFile := filepath.Join(tmpDir, "nodes.txt")
+	assert.NoError(t, os.WriteFile(nodesFile, []byte("# fleet\nweb1\n\nweb2\nmissing\n"), 0644))
+
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+
+	err = runNodesFileCheck(cmd, hi, nodesFile)
+	assert.Error(t, err)
+	assert.ElementsMatch(t, []string{"deploy@10.0.0.1:22", "ubuntu@10.0.0.2:2222"}, calls)
+
+	output := buf.String()
+	assert.Regexp(t, `web1\s+10\.0\.0\.1\s+ok`, output)
+	assert.Regexp(t, `web2\s+\[10\.0\.0\.2\]:2222\s+FAIL \(exit status 255\)`, output)
+	assert.Regexp(t, `missing\s+FAIL \(node not found\)`, output)
+	assert.Contains(t, output, "1 passed, 2 failed")
+
+	// All nodes passing returns no error
+	assert.NoError(t, os.WriteFile(nodesFile, []byte("web1\n"), 0644))
+	assert.NoError(t, runNodesFileCheck(cmd, hi, nodesFile))
+}
+
+func TestSSHNodePicker(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	originalSelector := sshNodeSelector
+	defer func() { sshNodeSelector = originalSelector }()
+	var offered []string
+	sshNodeSelector = func(nodeKeys []string) (string, error) {
+		offered = nodeKeys
+		return "ghost", nil
+	}
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	// Without nodes there is nothing to pick from
+	assert.NoError(t, sshCmd.RunE(cmd, nil))
+	assert.Contains(t, buf.String(), "No SSH node inventory found.")
+	assert.Nil(t, offered)
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "10.0.0.2"}))
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1"}))
+
+	// The picked name is used as if it had been passed as the argument
+	buf.Reset()
+	assert.NoError(t, sshCmd.RunE(cmd, nil))
+	assert.Equal(t, []string{"web1", "web2"}, offered)
+	assert.Contains(t, buf.String(), "Node or command not found.")
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+)
+
+// sshConfigHost is one generated ~/.ssh/config Host block
+type sshConfigHost struct {
+	Alias string
+	Block string
+}
+
+// sshConfigHosts builds a Host block for every <typeName>.* entry with a host.
+// Entries must have every tag in all and, if any is given, one of those tags.
+func sshConfigHosts(hi *inventory.HierarchicalInventory, typeName string, all, any []string) []sshConfigHost {
+	names, err := hi.List(typeName)
+	if err != nil {
+		return nil
+	}
+	sort.Strings(names)
+
+	var hosts []sshConfigHost
+	for _, name := range names {
+		result, err := hi.Query(typeName + "." + name)
+		if err != nil {
+			continue
+		}
+		nodeData, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		host, port := nodeHostPort(nodeData)
+		if host == "" {
+			continue
+		}
+		tags := getNodeTags(nodeData)
+		if !hasAllTags(tags, all) || (len(any) > 0 && !hasAnyTag(tags, any)) {
+			continue
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Host %s\n", name)
+		fmt.Fprintf(&b, "    HostName %s\n", host)
+		if user, _ := nodeData["user"].(string); user != "" {
+			fmt.Fprintf(&b, "    User %s\n", user)
+		}
+		if port != 22 {
+			fmt.Fprintf(&b, "    Port %d\n", port)
+		}
+		hosts = append(hosts, sshConfigHost{Alias: name, Block: b.String()})
+	}
+	return hosts
+}
+
+func hasAnyTag(tags, wanted []string) bool {
+	for _, t := range tags {
+		for _, w := range wanted {
+			if t == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderSSHConfig joins Host blocks with blank lines between them
+func renderSSHConfig(hosts []sshConfigHost) string {
+	blocks := make([]string, len(hosts))
+	for i, h := range hosts {
+		blocks[i] = h.Block
+	}
+	return strings.Join(blocks, "\n")
+}
+
+// sshConfigAliases returns the aliases declared on Host lines of an ssh config
+func sshConfigAliases(content string) map[string]bool {
+	aliases := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && strings.EqualFold(fields[0], "Host") {
+			for _, alias := range fields[1:] {
+				aliases[alias] = true
+			}
+		}
+	}
+	return aliases
+}
+
+// mergeSSHConfig appends the Host blocks whose alias is not declared in
+// existing yet. Existing entries are never modified. It returns the merged
+// content and the number of blocks added.
+func mergeSSHConfig(existing string, hosts []sshConfigHost) (string, int) {
+	declared := sshConfigAliases(existing)
+	var added []sshConfigHost
+	for _, h := range hosts {
+		if !declared[h.Alias] {
+			added = append(added, h)
+		}
+	}
+	if len(added) == 0 {
+		return existing, 0
+	}
+
+	merged := strings.TrimRight(existing, "\n")
+	if merged != "" {
+		merged += "\n\n"
+	}
+	return merged + renderSSHConfig(added), len(added)
+}
+
+// defaultSSHConfigPath returns ~/.ssh/config
+func defaultSSHConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "config"), nil
+}
+
+// appendSSHConfig merges Host blocks into the ssh config file at path
+func appendSSHConfig(path string, hosts []sshConfigHost) (int, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	merged, added := mergeSSHConfig(string(existing), hosts)
+	if added == 0 {
+		return 0, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, []byte(merged), 0600); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return added, nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arung-agamani/tsukuyo/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+// sshKeyscan runs ssh-keyscan against a host and returns its output
+var sshKeyscan = func(host string, port int) (string, error) {
+	out, err := exec.Command("ssh-keyscan", "-H", "-p", strconv.Itoa(port), host).Output()
+	if err != nil {
+		return "", fmt.Errorf("ssh-keyscan failed: %v", err)
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		return "", fmt.Errorf("ssh-keyscan returned no host keys for %s:%d", host, port)
+	}
+	return string(out), nil
+}
+
+// nodeHostPort extracts the host and port (default 22) of a node entry
+func nodeHostPort(nodeData map[string]interface{}) (string, int) {
+	host, _ := nodeData["host"].(string)
+	port := 22
+	if p, ok := intValue(nodeData["port"]); ok {
+		port = p
+	}
+	return host, port
+}
+
+// intValue converts a stored number to an int. Numbers decoded from JSON are
+// float64, while 'inventory set' stores whole numbers as int64.
+func intValue(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+// fingerprintKeys returns the sorted "<key-type> <key>" pairs of ssh-keyscan
+// output. Hashed host names are dropped because -H salts them differently on
+// every run.
+func fingerprintKeys(output string) []string {
+	var keys []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		keys = append(keys, fields[1]+" "+fields[2])
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fingerprintsMatch reports whether two ssh-keyscan outputs contain the same host keys
+func fingerprintsMatch(stored, live string) bool {
+	storedKeys, liveKeys := fingerprintKeys(stored), fingerprintKeys(live)
+	if len(storedKeys) != len(liveKeys) {
+		return false
+	}
+	for i := range storedKeys {
+		if storedKeys[i] != liveKeys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// handleNodeSSHFingerprint captures the host keys of a node and stores them
+// at node.<name>.ssh_fingerprint
+func handleNodeSSHFingerprint(cmd *cobra.Command, hi *inventory.HierarchicalInventory, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: tsukuyo inventory node ssh-fingerprint <name>")
+	}
+	name := args[0]
+
+	result, err := hi.Query("node." + name)
+	if err != nil {
+		return fmt.Errorf("node '%s' not found", name)
+	}
+	nodeData, ok := result.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid node data format for '%s'", name)
+	}
+
+	host, port := nodeHostPort(nodeData)
+	if host == "" {
+		return fmt.Errorf("node '%s' has no host", name)
+	}
+
+	fingerprint, err := sshKeyscan(host, port)
+	if err != nil {
+		return err
+	}
+	if err := hi.Set("node."+name+".ssh_fingerprint", fingerprint); err != nil {
+		return fmt.Errorf("failed to store fingerprint: %v", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Stored %d host key(s) for node '%s' (%s:%d)\n", len(fingerprintKeys(fingerprint)), name, host, port)
+	return nil
+}
+
+// verifyNodeFingerprint compares the stored fingerprint of a node with the
+// live one. Mismatches are reported as warnings, or as errors when strict is
+// set. Nodes without a stored fingerprint are not checked.
+func verifyNodeFingerprint(cmd *cobra.Command, name string, nodeData map[string]interface{}, strict bool) error {
+	stored, _ := nodeData["ssh_fingerprint"].(string)
+	if stored == "" {
+		return nil
+	}
+
+	host, port := nodeHostPort(nodeData)
+	live, err := sshKeyscan(host, port)
+	if err != nil {
+		if strict {
+			return fmt.Errorf("refusing to connect: could not verify host key of '%s': %v", name, err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not verify host key of '%s': %v\n", name, err)
+		return nil
+	}
+
+	if fingerprintsMatch(stored, live) {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("refusing to connect: host key of '%s' does not match the stored fingerprint", name)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Warning: host key of '%s' does not match the stored fingerprint. "+
+		"Run 'tsukuyo inventory node ssh-fingerprint %s' if the key was rotated.\n", name, name)
+	return nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKeyscanOutput = `# web1.example.com:2222 SSH-2.0-OpenSSH_9.6
+|1|salt1=|hash1= ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey1
+|1|salt2=|hash2= ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQKey2
+`
+
+func stubSSHKeyscan(t *testing.T, output string, err error) {
+	t.Helper()
+	original := sshKeyscan
+	sshKeyscan = func(host string, port int) (string, error) {
+		return output, err
+	}
+	t.Cleanup(func() { sshKeyscan = original })
+}
+
+func TestFingerprintsMatch(t *testing.T) {
+	// Re-salted hashed host names still match
+	rehashed := `|1|other=|other= ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQKey2
+|1|more=|more= ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey1
+`
+	assert.True(t, fingerprintsMatch(testKeyscanOutput, rehashed))
+	assert.Equal(t, []string{
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey1",
+		"ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQKey2",
+	}, fingerprintKeys(testKeyscanOutput))
+
+	rotated := `|1|salt1=|hash1= ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIRotated
+|1|salt2=|hash2= ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQKey2
+`
+	assert.False(t, fingerprintsMatch(testKeyscanOutput, rotated))
+}
+
+func TestNodeSSHFingerprint(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "web1.example.com", "port": 2222}))
+
+	stubSSHKeyscan(t, testKeyscanOutput, nil)
+
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	assert.NoError(t, handleDynamicTypeCommand(cmd, hi, []string{"node", "ssh-fingerprint", "web1"}))
+	assert.Contains(t, buf.String(), "Stored 2 host key(s) for node 'web1' (web1.example.com:2222)")
+	assert.Error(t, handleNodeSSHFingerprint(cmd, hi, []string{"missing"}))
+	assert.Error(t, handleDynamicTypeCommand(cmd, hi, []string{"db", "ssh-fingerprint", "web1"}))
+
+	result, err := hi.Query("node.web1")
+	assert.NoError(t, err)
+	nodeData := result.(map[string]interface{})
+	assert.Equal(t, testKeyscanOutput, nodeData["ssh_fingerprint"])
+
+	// Matching keys pass silently
+	buf.Reset()
+	assert.NoError(t, verifyNodeFingerprint(cmd, "web1", nodeData, true))
+	assert.Empty(t, buf.String())
+
+	// Rotated keys warn, or refuse in strict mode
+	stubSSHKeyscan(t, "|1|x=|y= ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIRotated\n", nil)
+	assert.NoError(t, verifyNodeFingerprint(cmd, "web1", nodeData, false))
+	assert.Contains(t, buf.String(), "does not match the stored fingerprint")
+	assert.Error(t, verifyNodeFingerprint(cmd, "web1", nodeData, true))
+
+	// Unreachable hosts cannot be verified
+	stubSSHKeyscan(t, "", fmt.Errorf("connection refused"))
+	assert.NoError(t, verifyNodeFingerprint(cmd, "web1", nodeData, false))
+	assert.Error(t, verifyNodeFingerprint(cmd, "web1", nodeData, true))
+
+	// Nodes without a stored fingerprint are not checked
+	assert.NoError(t, verifyNodeFingerprint(cmd, "web2", map[string]interface{}{"host": "web2"}, true))
+}
+
+func TestNodeHostPortAcceptsSetIntegers(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	// 'inventory set' stores whole numbers as int64 until the file is reloaded
+	port, err := parseSetValue("2222", "")
+	assert.NoError(t, err)
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1.host", "10.0.0.1"))
+	assert.NoError(t, hi.Set("node.web1.port", port))
+
+	result, err := hi.Query("node.web1")
+	assert.NoError(t, err)
+	host, got := nodeHostPort(result.(map[string]interface{}))
+	assert.Equal(t, "10.0.0.1", host)
+	assert.Equal(t, 2222, got)
+
+	_, got = nodeHostPort(map[string]interface{}{"port": float64(2200)})
+	assert.Equal(t, 2200, got)
+	_, got = nodeHostPort(map[string]interface{}{"port": "22x"})
+	assert.Equal(t, 22, got)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSSHArgs(t *testing.T) {
+	node := map[string]interface{}{"host": "10.0.0.5", "user": "deploy", "port": float64(2222)}
+
+	args := buildSSHArgs(node, false)
+	assert.NotContains(t, args, "-A")
+	assert.Contains(t, args, "deploy@10.0.0.5")
+	assert.Contains(t, args, "2222")
+
+	// --forward-agent
+	args = buildSSHArgs(node, true)
+	assert.Equal(t, "-A", args[0])
+	assert.Contains(t, args, "deploy@10.0.0.5")
+
+	// forward_agent stored on the node
+	node["forward_agent"] = true
+	args = buildSSHArgs(node, false)
+	assert.Equal(t, "-A", args[0])
+	assert.Equal(t, 1, countArg(args, "-A"))
+}
+
+func countArg(args []string, arg string) int {
+	n := 0
+	for _, a := range args {
+		if a == arg {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSSHPreflightCheck(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1", "port": 2222}))
+
+	originalDialer := tcpDialer
+	defer func() { tcpDialer = originalDialer }()
+	var dialed string
+	tcpDialer = func(addr string, timeout time.Duration) error {
+		dialed = addr
+		return errors.New("connection refused")
+	}
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	assert.NoError(t, sshCmd.RunE(cmd, []string{"web1"}))
+	assert.Equal(t, "10.0.0.1:2222", dialed)
+	assert.Equal(t, "Cannot reach 10.0.0.1:2222: connection refused. Check network connectivity and node status.\n", buf.String())
+
+	tcpDialer = func(addr string, timeout time.Duration) error { return nil }
+	assert.NoError(t, checkNodeReachable(map[string]interface{}{"host": "10.0.0.1"}))
+}
+
+func TestSSHRecordsLastConnected(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1", "user": "deploy"}))
+	assert.NoError(t, hi.Set("node.web2", map[string]interface{}{"host": "10.0.0.2", "last_connected": "2024-01-01T00:00:00Z"}))
+
+	originalDialer, originalRun, originalNow := tcpDialer, runSSH, connectedNow
+	defer func() { tcpDialer, runSSH, connectedNow = originalDialer, originalRun, originalNow }()
+	tcpDialer = func(addr string, timeout time.Duration) error { return nil }
+	connectedNow = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	runErr := errors.New("exit status 255")
+	runSSH = func(cmd *cobra.Command, args []string) error { return runErr }
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	// A failed connection is not recorded
+	assert.NoError(t, sshCmd.RunE(cmd, []string{"web1"}))
+	_, err = hi.Query("node.web1.last_connected")
+	assert.Error(t, err)
+
+	runSSH = func(cmd *cobra.Command, args []string) error { return nil }
+	assert.NoError(t, sshCmd.RunE(cmd, []string{"web1"}))
+	result, err := hi.Query("node.web1.last_connected")
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-03-01T12:00:00Z", result)
+
+	// node list --extended shows the timestamp and flags stale nodes
+	buf.Reset()
+	assert.NoError(t, printNodeListExtended(cmd, hi, []string{"web2", "web1"}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "LAST_CONNECTED")
+	assert.Contains(t, lines[1], "web1")
+	assert.True(t, strings.HasSuffix(lines[1], "2024-03-01T12:00:00Z"))
+	assert.True(t, strings.HasSuffix(lines[2], "2024-01-01T00:00:00Z (stale)"))
+
+	assert.Equal(t, "never", formatLastConnected(""))
+}
+
+func TestSSHLastConnectedIsNotAnEdit(t *testing.T) {
+	tmpDir, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hookLog := filepath.Join(tmpDir, "hooks.log")
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1"}))
+	assert.NoError(t, hi.Set("_hooks", map[string]interface{}{
+		"after-set:node.*": `echo "$TSUKUYO_HOOK_PATH" >> ` + hookLog,
+	}))
+	updatedAt, err := hi.Query("_meta.node.web1.updated_at")
+	assert.NoError(t, err)
+
+	originalDialer, originalRun, originalNow := tcpDialer, runSSH, connectedNow
+	defer func() { tcpDialer, runSSH, connectedNow = originalDialer, originalRun, originalNow }()
+	tcpDialer = func(addr string, timeout time.Duration) error { return nil }
+	runSSH = func(cmd *cobra.Command, args []string) error { return nil }
+	connectedNow = func() time.Time { return time.Now().Add(time.Hour) }
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(&bytes.Buffer{})
+	assert.NoError(t, sshCmd.RunE(cmd, []string{"web1"}))
+
+	_, err = hi.Query("node.web1.last_connected")
+	assert.NoError(t, err)
+	after, err := hi.Query("_meta.node.web1.updated_at")
+	assert.NoError(t, err)
+	assert.Equal(t, updatedAt, after)
+	_, err = os.Stat(hookLog)
+	assert.True(t, os.IsNotExist(err), "connecting should not run after-set hooks")
+}
+
+func TestNodeListFlagsAreNotInherited(t *testing.T) {
+	for _, name := range []string{"extended", "os-filter"} {
+		assert.NotNil(t, inventoryCmd.Flags().Lookup(name))
+		assert.Nil(t, inventorySetCmd.InheritedFlags().Lookup(name), "inventory set should not accept --%s", name)
+		assert.Nil(t, inventoryHierarchicalCmd.InheritedFlags().Lookup(name), "inventory query should not accept --%s", name)
+	}
+}
+
+func TestSSHPrintCommand(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+	defer func() {
+		sshPrintCommand = false
+		tunnelTarget = ""
+	}()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+	assert.NoError(t, hi.Set("node.web1", map[string]interface{}{"host": "10.0.0.1", "user": "deploy", "port": 2222}))
+
+	originalDialer, originalRun := tcpDialer, runSSH
+	defer func() { tcpDialer, runSSH = originalDialer, originalRun }()
+	tcpDialer = func(addr string, timeout time.Duration) error {
+		t.Errorf("Expected no connectivity check, dialed %s", addr)
+		return nil
+	}
+	runSSH = func(cmd *cobra.Command, args []string) error {
+		t.Errorf("Expected ssh not to run, got %v", args)
+		return nil
+	}
+
+	cmd := &cobra.Command{}
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	sshPrintCommand = true
+	tunnelTarget = "8080:localhost:80"
+	assert.NoError(t, sshCmd.RunE(cmd, []string{"web1"}))
+	assert.Equal(t, "ssh -L 8080:localhost:80 deploy@10.0.0.1 -p 2222\n", buf.String())
+
+	_, err = hi.Query("node.web1.last_connected")
+	assert.Error(t, err)
+
+	assert.Equal(t, `ssh -o "ProxyCommand=nc %h %p" ubuntu@host`, sshCommandLine([]string{"-o", "ProxyCommand=nc %h %p", "ubuntu@host"}))
+}
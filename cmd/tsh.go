@@ -38,111 +38,61 @@ var tshCmd = &cobra.Command{
 			return
 		}
 
-		// Step 2: Get nodes list in JSON
-		lsCmd := exec.Command("tsh", "ls", "--format=json")
-		var out bytes.Buffer
-		lsCmd.Stdout = &out
-		if err := lsCmd.Run(); err != nil {
-			fmt.Fprintln(cmd.OutOrStdout(), "Failed to list nodes with 'tsh ls'. Is tsh installed and configured?")
+		// Step 2 and 3: List nodes and their labels
+		nodes, err := loadTshNodes(cmd)
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), err)
 			return
 		}
 
-		// Step 3: Parse JSON nodes and labels
-		var nodes []TshNode
-		if err := json.Unmarshal(out.Bytes(), &nodes); err != nil || len(nodes) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "Failed to parse tsh ls output.")
-			return
+		hi, hiErr := getHierarchicalInventory()
+		groupLabel1, groupLabel2 := tshGroupLabel1, tshGroupLabel2
+		if hiErr == nil {
+			groupLabel1, groupLabel2 = resolveTshGroupLabels(cmd, hi)
 		}
 
-		// Step 4: Wizard for label pair selection (app_namespace + environment)
-		type labelPair struct {
-			AppNamespace string
-			Environment  string
-		}
-		pairSet := map[labelPair]struct{}{}
-		pairToNodes := map[labelPair][]TshNode{}
-		for _, n := range nodes {
-			appns := n.Metadata.Labels["app_namespace"]
-			env := n.Metadata.Labels["environment"]
-			pair := labelPair{AppNamespace: appns, Environment: env}
-			pairSet[pair] = struct{}{}
-			pairToNodes[pair] = append(pairToNodes[pair], n)
-		}
-		pairs := make([]labelPair, 0, len(pairSet))
-		for p := range pairSet {
-			pairs = append(pairs, p)
-		}
-		sort.Slice(pairs, func(i, j int) bool {
-			if pairs[i].AppNamespace == pairs[j].AppNamespace {
-				return pairs[i].Environment < pairs[j].Environment
+		// Step 4: Pick a node, either non-interactively by hostname or via the wizard
+		var selectedNode TshNode
+		if tshHostname != "" {
+			matches := filterTshNodesByHostname(nodes, tshHostname)
+			switch len(matches) {
+			case 0:
+				fmt.Fprintf(cmd.OutOrStdout(), "No nodes with a hostname matching '%s' found.\n", tshHostname)
+				return
+			case 1:
+				selectedNode = matches[0]
+			default:
+				names := make([]string, len(matches))
+				for i, n := range matches {
+					names[i] = n.Spec.Hostname
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Hostname '%s' matches multiple nodes: %s\n", tshHostname, strings.Join(names, ", "))
+				return
 			}
-			return pairs[i].AppNamespace < pairs[j].AppNamespace
-		})
-		pairLabels := make([]string, len(pairs))
-		for i, p := range pairs {
-			pairLabels[i] = fmt.Sprintf("%s | %s", p.AppNamespace, p.Environment)
-		}
-		prompt := promptui.Select{
-			Label: "Select app_namespace | environment",
-			Items: pairLabels,
-		}
-		_, pairLabel, err := prompt.Run()
-		if err != nil {
-			fmt.Fprintln(cmd.OutOrStdout(), "Prompt failed:", err)
-			return
-		}
-		selectedPair := pairs[0]
-		for i, lbl := range pairLabels {
-			if lbl == pairLabel {
-				selectedPair = pairs[i]
-				break
+		} else {
+			var last *tshLast
+			if !tshNoRemember {
+				last = loadTshLast()
 			}
-		}
-		filtered := pairToNodes[selectedPair]
-		if len(filtered) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "No nodes found with that label pair.")
-			return
-		}
-
-		// Step 5: Select node by spec.hostname ONLY
-		hostToNode := map[string]TshNode{}
-		hostnames := make([]string, 0, len(filtered))
-		for _, n := range filtered {
-			host := n.Spec.Hostname
-			if host == "" {
-				continue // skip nodes without a hostname
+			selectedNode, err = pickTshNode(nodes, groupLabel1, groupLabel2, last)
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), err)
+				return
 			}
-			hostToNode[host] = n
-			hostnames = append(hostnames, host)
-		}
-		if len(hostnames) == 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "No nodes with a valid hostname found.")
-			return
 		}
-		sort.Strings(hostnames)
-		prompt = promptui.Select{
-			Label: "Select node (hostname)",
-			Items: hostnames,
-		}
-		_, hostname, err := prompt.Run()
-		if err != nil {
-			fmt.Fprintln(cmd.OutOrStdout(), "Prompt failed:", err)
-			return
-		}
-		selectedNode := hostToNode[hostname]
+		hostname := selectedNode.Spec.Hostname
 
 		if withDb == "__INTERACTIVE__" {
 			withDb = ""
 		}
 		if withDb != "" || cmd.Flags().Changed("with-db") {
 			// Use hierarchical inventory for DB entries
-			hi, err := getHierarchicalInventory()
-			if err != nil {
-				fmt.Fprintln(cmd.OutOrStdout(), "Failed to initialize inventory:", err)
+			if hiErr != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Failed to initialize inventory:", hiErr)
 				return
 			}
 
-			dbEntry, err := selectDbWithTaggingForTsh(hi, selectedNode)
+			dbEntry, err := inventory.SelectDbEntry(hi, getTshNodeTags(selectedNode))
 			if err != nil {
 				fmt.Fprintln(cmd.OutOrStdout(), err)
 				return
@@ -155,10 +105,8 @@ var tshCmd = &cobra.Command{
 			tunnel := fmt.Sprintf("%d:%s:%d", localPort, dbEntry.Host, dbEntry.RemotePort)
 
 			fmt.Fprintf(cmd.OutOrStdout(), "Forwarding local port %d to %s:%d\n", localPort, dbEntry.Host, dbEntry.RemotePort)
-			sshCmd := exec.Command("tsh", "ssh", "-L", tunnel, fmt.Sprintf("ubuntu@%s", hostname))
-			sshCmd.Stdin = cmd.InOrStdin()
-			sshCmd.Stdout = cmd.OutOrStdout()
-			sshCmd.Stderr = cmd.ErrOrStderr()
+			sshCmd := exec.Command("tsh", "ssh", "-L", tunnel, tshTarget(hostname))
+			attachTerminal(sshCmd, cmd)
 			err = sshCmd.Run()
 			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 130 {
 				// Suppress status 130 (SIGINT/Ctrl+C)
@@ -166,13 +114,14 @@ var tshCmd = &cobra.Command{
 			}
 			if err != nil {
 				fmt.Fprintln(cmd.OutOrStdout(), "SSH tunnel exited with error:", err)
+				return
 			}
+			rememberTshNode(cmd, selectedNode, groupLabel1, groupLabel2)
+			recordTshConnected(cmd, hi, hostname)
 			return
 		}
-		sshCmd := exec.Command("tsh", "ssh", fmt.Sprintf("ubuntu@%s", hostname))
-		sshCmd.Stdin = cmd.InOrStdin()
-		sshCmd.Stdout = cmd.OutOrStdout()
-		sshCmd.Stderr = cmd.ErrOrStderr()
+		sshCmd := exec.Command("tsh", "ssh", tshTarget(hostname))
+		attachTerminal(sshCmd, cmd)
 		err = sshCmd.Run()
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 130 {
 			// Suppress status 130 (SIGINT/Ctrl+C)
@@ -180,81 +129,245 @@ var tshCmd = &cobra.Command{
 		}
 		if err != nil {
 			fmt.Fprintln(cmd.OutOrStdout(), "SSH exited with error:", err)
+			return
+		}
+		rememberTshNode(cmd, selectedNode, groupLabel1, groupLabel2)
+		if hiErr == nil {
+			recordTshConnected(cmd, hi, hostname)
 		}
 	},
 }
 
-var withDb string
-
-func init() {
-	tshCmd.Flags().StringVar(&withDb, "with-db", "", "Tunnel to DB key from inventory (interactive if empty)")
-	tshCmd.Flags().Lookup("with-db").NoOptDefVal = "__INTERACTIVE__"
-	rootCmd.AddCommand(tshCmd)
+// tshList runs "tsh ls" with the given output format
+var tshList = func(format string) ([]byte, error) {
+	lsCmd := exec.Command("tsh", "ls", "--format="+format)
+	var out bytes.Buffer
+	lsCmd.Stdout = &out
+	err := lsCmd.Run()
+	return out.Bytes(), err
 }
 
-func selectDbWithTaggingForTsh(hi *inventory.HierarchicalInventory, node TshNode) (*DbInventoryEntry, error) {
-	dbEntries, err := hi.List("db")
-	if err != nil || len(dbEntries) == 0 {
-		return nil, fmt.Errorf("no DB inventory found")
+// loadTshNodes lists the Teleport nodes from "tsh ls --format=json". If that
+// output no longer has the expected shape (a Teleport upgrade changed the
+// schema), it warns and falls back to parsing "tsh ls --format=text".
+func loadTshNodes(cmd *cobra.Command) ([]TshNode, error) {
+	out, err := tshList("json")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list nodes with 'tsh ls'. Is tsh installed and configured?")
+	}
+
+	var nodes []TshNode
+	if err := json.Unmarshal(out, &nodes); err == nil && len(nodes) > 0 && nodes[0].Metadata.Name != "" {
+		return nodes, nil
 	}
 
-	nodeTags := getTshNodeTags(node)
-	var filteredEntries []string
-	entryMap := make(map[string]DbInventoryEntry)
+	fmt.Fprintln(cmd.ErrOrStderr(), "Warning: tsh ls output format may have changed. Try upgrading tsukuyo or tsh.")
+	text, err := tshList("text")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse tsh ls output.")
+	}
+	nodes = parseTshTextNodes(string(text))
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("Failed to parse tsh ls output.")
+	}
+	return nodes, nil
+}
 
-	for _, key := range dbEntries {
-		entryData, err := hi.Query(fmt.Sprintf("db.%s", key))
-		if err != nil {
+// parseTshTextNodes reads the table printed by "tsh ls --format=text":
+//
+//	Node Name   Address       Labels
+//	----------- ------------- -------------------
+//	web-1       10.0.0.1:3022 env=prod,team=core
+//
+// The node name doubles as the hostname, and the last column is read as
+// labels when it holds key=value pairs.
+func parseTshTextNodes(text string) []TshNode {
+	var nodes []TshNode
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "---") || (fields[0] == "Node" && len(fields) > 1 && fields[1] == "Name") {
 			continue
 		}
-		var entry DbInventoryEntry
-		// manual parsing from map[string]interface{} to struct
-		if raw, ok := entryData.(map[string]interface{}); ok {
-			if h, ok := raw["host"].(string); ok {
-				entry.Host = h
-			}
-			if t, ok := raw["type"].(string); ok {
-				entry.Type = t
-			}
-			if rp, ok := raw["remote_port"].(float64); ok {
-				entry.RemotePort = int(rp)
-			}
-			if lp, ok := raw["local_port"].(float64); ok {
-				entry.LocalPort = int(lp)
-			}
-			if tags, ok := raw["tags"].([]interface{}); ok {
-				for _, tag := range tags {
-					if t, ok := tag.(string); ok {
-						entry.Tags = append(entry.Tags, t)
-					}
+
+		var node TshNode
+		node.Metadata.Name = fields[0]
+		node.Spec.Hostname = fields[0]
+		node.Metadata.Labels = map[string]string{}
+		if last := fields[len(fields)-1]; len(fields) > 1 && strings.Contains(last, "=") {
+			for _, pair := range strings.Split(last, ",") {
+				if key, value, ok := strings.Cut(pair, "="); ok && key != "" {
+					node.Metadata.Labels[key] = value
 				}
 			}
 		}
-
-		if len(entry.Tags) == 0 || hasCommonTags(nodeTags, entry.Tags) {
-			filteredEntries = append(filteredEntries, key)
-			entryMap[key] = entry
-		}
+		nodes = append(nodes, node)
 	}
+	return nodes
+}
 
-	if len(filteredEntries) == 0 {
-		return nil, fmt.Errorf("no DB entries with matching tags found")
+// rememberTshNode stores the quick-connect default unless --no-remember is set
+func rememberTshNode(cmd *cobra.Command, node TshNode, groupLabel1, groupLabel2 string) {
+	if tshNoRemember {
+		return
 	}
+	if err := saveTshLast(node, groupLabel1, groupLabel2); err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Warning: failed to remember last connection:", err)
+	}
+}
+
+var withDb string
+
+var (
+	tshGroupLabel1 string
+	tshGroupLabel2 string
+	tshHostname    string
+	tshNoRemember  bool
+)
+
+// tshTarget returns the user@host login for tsh ssh, using default_ssh_user
+func tshTarget(hostname string) string {
+	return fmt.Sprintf("%s@%s", appConfig.GetString("default_ssh_user"), hostname)
+}
+
+func init() {
+	tshCmd.Flags().StringVar(&withDb, "with-db", "", "Tunnel to DB key from inventory (interactive if empty)")
+	tshCmd.Flags().Lookup("with-db").NoOptDefVal = "__INTERACTIVE__"
+	tshCmd.Flags().StringVar(&tshGroupLabel1, "group-label-1", "app_namespace", "First node label used to group nodes in the picker (remembered)")
+	tshCmd.Flags().StringVar(&tshGroupLabel2, "group-label-2", "environment", "Second node label used to group nodes in the picker (remembered)")
+	tshCmd.Flags().StringVar(&tshHostname, "hostname", "", "Connect to the node whose hostname contains this text, skipping the pickers")
+	tshCmd.Flags().BoolVar(&tshNoRemember, "no-remember", false, "Do not offer or save the last used node as a quick-connect default")
+	rootCmd.AddCommand(tshCmd)
+}
 
+// pickTshNode runs the interactive wizard: pick a label pair, then a hostname.
+// If last is still listed, a "Last used" entry at the top connects to it directly.
+func pickTshNode(nodes []TshNode, groupLabel1, groupLabel2 string, last *tshLast) (TshNode, error) {
+	type labelPair struct {
+		First  string
+		Second string
+	}
+	pairSet := map[labelPair]struct{}{}
+	pairToNodes := map[labelPair][]TshNode{}
+	for _, n := range nodes {
+		pair := labelPair{First: n.Metadata.Labels[groupLabel1], Second: n.Metadata.Labels[groupLabel2]}
+		pairSet[pair] = struct{}{}
+		pairToNodes[pair] = append(pairToNodes[pair], n)
+	}
+	pairs := make([]labelPair, 0, len(pairSet))
+	for p := range pairSet {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].First == pairs[j].First {
+			return pairs[i].Second < pairs[j].Second
+		}
+		return pairs[i].First < pairs[j].First
+	})
+	pairLabels := make([]string, len(pairs))
+	for i, p := range pairs {
+		pairLabels[i] = fmt.Sprintf("%s | %s", p.First, p.Second)
+	}
+	items := pairLabels
+	lastNode, hasLast := lastUsedNode(nodes, last)
+	if hasLast {
+		items = append([]string{lastUsedLabel(last)}, pairLabels...)
+	}
 	prompt := promptui.Select{
-		Label: "Select DB key for tunnel",
-		Items: filteredEntries,
+		Label: fmt.Sprintf("Select %s | %s", groupLabel1, groupLabel2),
+		Items: items,
+	}
+	index, pairLabel, err := prompt.Run()
+	if err != nil {
+		return TshNode{}, fmt.Errorf("prompt failed: %v", err)
+	}
+	if hasLast && index == 0 {
+		return lastNode, nil
+	}
+	selectedPair := pairs[0]
+	for i, lbl := range pairLabels {
+		if lbl == pairLabel {
+			selectedPair = pairs[i]
+			break
+		}
+	}
+	filtered := pairToNodes[selectedPair]
+	if len(filtered) == 0 {
+		return TshNode{}, fmt.Errorf("no nodes found with that label pair")
+	}
+
+	// Select node by spec.hostname ONLY
+	hostToNode := map[string]TshNode{}
+	hostnames := make([]string, 0, len(filtered))
+	for _, n := range filtered {
+		host := n.Spec.Hostname
+		if host == "" {
+			continue // skip nodes without a hostname
+		}
+		hostToNode[host] = n
+		hostnames = append(hostnames, host)
+	}
+	if len(hostnames) == 0 {
+		return TshNode{}, fmt.Errorf("no nodes with a valid hostname found")
+	}
+	sort.Strings(hostnames)
+	prompt = promptui.Select{
+		Label: "Select node (hostname)",
+		Items: hostnames,
 		Searcher: func(input string, index int) bool {
-			return strings.Contains(strings.ToLower(filteredEntries[index]), strings.ToLower(input))
+			return strings.Contains(strings.ToLower(hostnames[index]), strings.ToLower(input))
 		},
 	}
-	_, selectedKey, err := prompt.Run()
+	_, hostname, err := prompt.Run()
 	if err != nil {
-		return nil, fmt.Errorf("prompt failed: %v", err)
+		return TshNode{}, fmt.Errorf("prompt failed: %v", err)
+	}
+	return hostToNode[hostname], nil
+}
+
+// filterTshNodesByHostname returns the nodes whose hostname contains substr
+// (case-insensitive), sorted by hostname. An exact hostname match wins over
+// partial matches.
+func filterTshNodesByHostname(nodes []TshNode, substr string) []TshNode {
+	var matches []TshNode
+	needle := strings.ToLower(substr)
+	for _, n := range nodes {
+		host := n.Spec.Hostname
+		if host == "" {
+			continue
+		}
+		if strings.EqualFold(host, substr) {
+			return []TshNode{n}
+		}
+		if strings.Contains(strings.ToLower(host), needle) {
+			matches = append(matches, n)
+		}
 	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Spec.Hostname < matches[j].Spec.Hostname })
+	return matches
+}
 
-	selectedEntry := entryMap[selectedKey]
-	return &selectedEntry, nil
+// resolveTshGroupLabels returns the two label keys used to group Teleport nodes.
+// Explicit flags win and are stored under tsh.group_label_N in the inventory;
+// otherwise the stored values or the flag defaults are used.
+func resolveTshGroupLabels(cmd *cobra.Command, hi *inventory.HierarchicalInventory) (string, string) {
+	labels := []string{
+		cmd.Flags().Lookup("group-label-1").Value.String(),
+		cmd.Flags().Lookup("group-label-2").Value.String(),
+	}
+	paths := []string{"tsh.group_label_1", "tsh.group_label_2"}
+	stored, _ := hi.QueryMultiple(paths)
+	for i, flagName := range []string{"group-label-1", "group-label-2"} {
+		if cmd.Flags().Changed(flagName) {
+			if err := hi.Set(paths[i], labels[i]); err != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Warning: failed to remember %s: %v\n", flagName, err)
+			}
+			continue
+		}
+		if label, ok := stored[paths[i]].(string); ok && label != "" {
+			labels[i] = label
+		}
+	}
+	return labels[0], labels[1]
 }
 
 func getTshNodeTags(node TshNode) []string {
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// tshLast is the quick-connect default remembered after a successful tsh connection
+type tshLast struct {
+	AppNamespace string `json:"last_app_namespace"`
+	Environment  string `json:"last_environment"`
+	Hostname     string `json:"last_hostname"`
+	Timestamp    int64  `json:"ts"`
+}
+
+func tshLastPath() string {
+	return filepath.Join(getTsukuyoDir(), "tsh-last.json")
+}
+
+// loadTshLast returns the remembered connection, or nil if there is none
+func loadTshLast() *tshLast {
+	data, err := os.ReadFile(tshLastPath())
+	if err != nil {
+		return nil
+	}
+	var last tshLast
+	if err := json.Unmarshal(data, &last); err != nil || last.Hostname == "" {
+		return nil
+	}
+	return &last
+}
+
+// saveTshLast remembers node as the quick-connect default
+func saveTshLast(node TshNode, groupLabel1, groupLabel2 string) error {
+	last := tshLast{
+		AppNamespace: node.Metadata.Labels[groupLabel1],
+		Environment:  node.Metadata.Labels[groupLabel2],
+		Hostname:     node.Spec.Hostname,
+		Timestamp:    time.Now().Unix(),
+	}
+	data, err := json.MarshalIndent(last, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(tshLastPath()), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(tshLastPath(), data, 0644)
+}
+
+// lastUsedNode finds the remembered node among the currently listed nodes
+func lastUsedNode(nodes []TshNode, last *tshLast) (TshNode, bool) {
+	if last == nil {
+		return TshNode{}, false
+	}
+	for _, n := range nodes {
+		if n.Spec.Hostname == last.Hostname {
+			return n, true
+		}
+	}
+	return TshNode{}, false
+}
+
+// lastUsedLabel is the picker entry offered for the remembered connection
+func lastUsedLabel(last *tshLast) string {
+	return fmt.Sprintf("Last used: %s | %s → %s", last.AppNamespace, last.Environment, last.Hostname)
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTshFlagsCmd creates a command carrying the tsh grouping flags
+func newTshFlagsCmd() *cobra.Command {
+	c := &cobra.Command{Use: "tsh"}
+	c.Flags().String("group-label-1", "app_namespace", "")
+	c.Flags().String("group-label-2", "environment", "")
+	return c
+}
+
+func TestResolveTshGroupLabels(t *testing.T) {
+	_, cleanup := setupIsolatedInventory(t)
+	defer cleanup()
+
+	hi, err := getHierarchicalInventory()
+	assert.NoError(t, err)
+
+	// Defaults when nothing is stored
+	first, second := resolveTshGroupLabels(newTshFlagsCmd(), hi)
+	assert.Equal(t, "app_namespace", first)
+	assert.Equal(t, "environment", second)
+
+	// Explicit flags are used and remembered
+	c := newTshFlagsCmd()
+	assert.NoError(t, c.Flags().Set("group-label-1", "team"))
+	first, second = resolveTshGroupLabels(c, hi)
+	assert.Equal(t, "team", first)
+	assert.Equal(t, "environment", second)
+
+	stored, err := hi.Query("tsh.group_label_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "team", stored)
+
+	// Later sessions pick up the stored value
+	first, second = resolveTshGroupLabels(newTshFlagsCmd(), hi)
+	assert.Equal(t, "team", first)
+	assert.Equal(t, "environment", second)
+}
+
+func newTestTshNode(hostname string) TshNode {
+	var n TshNode
+	n.Metadata.Name = hostname + "-id"
+	n.Spec.Hostname = hostname
+	return n
+}
+
+func TestFilterTshNodesByHostname(t *testing.T) {
+	nodes := []TshNode{
+		newTestTshNode("web-prod-2"),
+		newTestTshNode("web-prod-1"),
+		newTestTshNode("db-prod-1"),
+		newTestTshNode("web"),
+		newTestTshNode(""),
+	}
+
+	hostnames := func(nodes []TshNode) []string {
+		var names []string
+		for _, n := range nodes {
+			names = append(names, n.Spec.Hostname)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"web-prod-1", "web-prod-2"}, hostnames(filterTshNodesByHostname(nodes, "WEB-PROD")))
+	assert.Equal(t, []string{"db-prod-1", "web-prod-1"}, hostnames(filterTshNodesByHostname(nodes, "prod-1")))
+	assert.Equal(t, []string{"web"}, hostnames(filterTshNodesByHostname(nodes, "web")), "exact match wins")
+	assert.Empty(t, filterTshNodesByHostname(nodes, "cache"))
+}
+
+func TestTshLastRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalGetTsukuyoDir := getTsukuyoDir
+	getTsukuyoDir = func() string { return tmpDir }
+	defer func() { getTsukuyoDir = originalGetTsukuyoDir }()
+
+	assert.Nil(t, loadTshLast())
+
+	var node TshNode
+	node.Metadata.Labels = map[string]string{"app_namespace": "shop", "environment": "prod"}
+	node.Spec.Hostname = "web-prod-1"
+	assert.NoError(t, saveTshLast(node, "app_namespace", "environment"))
+
+	last := loadTshLast()
+	if assert.NotNil(t, last) {
+		assert.Equal(t, "shop", last.AppNamespace)
+		assert.Equal(t, "prod", last.Environment)
+		assert.Equal(t, "web-prod-1", last.Hostname)
+		assert.NotZero(t, last.Timestamp)
+		assert.Equal(t, "Last used: shop | prod → web-prod-1", lastUsedLabel(last))
+	}
+
+	// The remembered node is only offered while it is still listed
+	found, ok := lastUsedNode([]TshNode{node}, last)
+	assert.True(t, ok)
+	assert.Equal(t, "web-prod-1", found.Spec.Hostname)
+	_, ok = lastUsedNode(nil, last)
+	assert.False(t, ok)
+	_, ok = lastUsedNode([]TshNode{node}, nil)
+	assert.False(t, ok)
+}
+
+func TestParseTshTextNodes(t *testing.T) {
+	text := `Node Name   Address        Labels
+----------- -------------- ------------------
+web-1       10.0.0.1:3022  env=prod,team=core
+tunnel-node ⟵ Tunnel
+`
+	nodes := parseTshTextNodes(text)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, "web-1", nodes[0].Spec.Hostname)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "core"}, nodes[0].Metadata.Labels)
+	assert.Equal(t, "tunnel-node", nodes[1].Metadata.Name)
+	assert.Empty(t, nodes[1].Metadata.Labels)
+}
+
+func TestLoadTshNodesFallsBackToText(t *testing.T) {
+	original := tshList
+	defer func() { tshList = original }()
+
+	outputs := map[string]string{
+		"json": `[{"metadata": {"name": "uuid-1", "labels": {"env": "prod"}}, "spec": {"hostname": "web-1"}}]`,
+		"text": "Node Name Address Labels\nweb-2 10.0.0.2:3022 env=dev\n",
+	}
+	var formats []string
+	tshList = func(format string) ([]byte, error) {
+		formats = append(formats, format)
+		return []byte(outputs[format]), nil
+	}
+
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	nodes, err := loadTshNodes(cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, "web-1", nodes[0].Spec.Hostname)
+	assert.Equal(t, []string{"json"}, formats)
+	assert.Empty(t, stderr.String())
+
+	// A schema change leaves metadata empty
+	formats = nil
+	outputs["json"] = `[{"resource": {"name": "web-1"}}]`
+	nodes, err = loadTshNodes(cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"json", "text"}, formats)
+	assert.Contains(t, stderr.String(), "Warning: tsh ls output format may have changed. Try upgrading tsukuyo or tsh.")
+	assert.Equal(t, "web-2", nodes[0].Spec.Hostname)
+	assert.Equal(t, "dev", nodes[0].Metadata.Labels["env"])
+
+	outputs["text"] = ""
+	_, err = loadTshNodes(cmd)
+	assert.EqualError(t, err, "Failed to parse tsh ls output.")
+}
+
+func TestTshTargetUsesDefaultSSHUser(t *testing.T) {
+	defer func() { appConfig = newAppConfig() }()
+
+	assert.Equal(t, "ubuntu@web1.internal", tshTarget("web1.internal"))
+
+	appConfig.Set("default_ssh_user", "admin")
+	assert.Equal(t, "admin@web1.internal", tshTarget("web1.internal"))
+}
@@ -0,0 +1,139 @@
+package inventory
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupCompanionFiles are the files kept next to the inventory in the data
+// directory that Backup adds to the tarball when they exist
+var backupCompanionFiles = []string{"tsh-last.json"}
+
+// gzipMagic starts every gzip stream, telling a tarball backup apart from a
+// plain JSON one
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// writeBackupTarball writes a gzipped tarball to path holding data under the
+// inventory's store file name and every companion file found in the data
+// directory
+func (hi *HierarchicalInventory) writeBackupTarball(path string, data []byte) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	add := func(name string, content []byte) error {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+
+	if err := add(filepath.Base(hi.storeFile(".json")), data); err != nil {
+		return err
+	}
+	for _, name := range backupCompanionFiles {
+		content, err := os.ReadFile(filepath.Join(hi.dataDir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := add(name, content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// readBackupTarball returns the inventory JSON stored in a tarball backup and
+// the companion files it holds, keyed by name. The tarball must hold exactly
+// one inventory file named after this inventory's store file, so a backup of
+// another namespace is rejected. Nothing is written here; Restore writes the
+// companions once the whole backup has been validated.
+func (hi *HierarchicalInventory) readBackupTarball(data []byte) ([]byte, map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	known := make(map[string]bool, len(backupCompanionFiles))
+	for _, name := range backupCompanionFiles {
+		known[name] = true
+	}
+
+	storeName := filepath.Base(hi.storeFile(".json"))
+	var inventoryData []byte
+	inventoryFiles := 0
+	companions := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid backup tarball: %w", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch name := header.Name; {
+		case name == storeName:
+			inventoryData = content
+			inventoryFiles++
+		case known[name]:
+			companions[name] = content
+		}
+	}
+	switch inventoryFiles {
+	case 0:
+		return nil, nil, fmt.Errorf("backup tarball contains no inventory file named %s", storeName)
+	case 1:
+		return inventoryData, companions, nil
+	default:
+		return nil, nil, fmt.Errorf("backup tarball contains %d inventory files named %s", inventoryFiles, storeName)
+	}
+}
+
+// writeBackupCompanions writes the companion files of a restored tarball back
+// into the data directory
+func (hi *HierarchicalInventory) writeBackupCompanions(companions map[string][]byte) error {
+	for _, name := range backupCompanionFiles {
+		content, ok := companions[name]
+		if !ok {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(hi.dataDir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalBackup returns the inventory data as indented JSON
+func (hi *HierarchicalInventory) marshalBackup() ([]byte, error) {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+	return json.MarshalIndent(hi.data, "", "  ")
+}
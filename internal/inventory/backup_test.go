@@ -0,0 +1,140 @@
+package inventory
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHierarchicalInventory_BackupRestore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set("servers.web1.host", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	companion := filepath.Join(tempDir, "tsh-last.json")
+	if err := os.WriteFile(companion, []byte(`{"hostname":"web-prod-1"}`), 0644); err != nil {
+		t.Fatalf("Failed to write companion file: %v", err)
+	}
+
+	backupFile, err := hi.Backup()
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if !strings.HasSuffix(backupFile, ".tar.gz") {
+		t.Errorf("Expected a tarball backup, got %s", backupFile)
+	}
+
+	// Change the data and the companion file, then restore the tarball
+	if err := hi.Set("servers.web1.host", "10.0.0.9"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if err := os.WriteFile(companion, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("Failed to write companion file: %v", err)
+	}
+	if err := hi.Restore(backupFile); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result, _ := hi.Query("servers.web1.host"); result != "10.0.0.1" {
+		t.Errorf("Expected restored host, got %v", result)
+	}
+	content, err := os.ReadFile(companion)
+	if err != nil || string(content) != `{"hostname":"web-prod-1"}` {
+		t.Errorf("Expected companion file to be restored, got %q (%v)", content, err)
+	}
+
+	// Plain JSON backups from older versions still restore
+	legacy := filepath.Join(tempDir, "backup-1700000000.json")
+	if err := os.WriteFile(legacy, []byte(`{"servers": {"web2": {"host": "10.0.0.2"}}}`), 0644); err != nil {
+		t.Fatalf("Failed to write legacy backup: %v", err)
+	}
+	if err := hi.Restore(legacy); err != nil {
+		t.Fatalf("Restore of a JSON backup failed: %v", err)
+	}
+	result, err := hi.Query("servers")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if !reflect.DeepEqual(result, map[string]interface{}{"web2": map[string]interface{}{"host": "10.0.0.2"}}) {
+		t.Errorf("Unexpected data after JSON restore: %v", result)
+	}
+
+	corrupt := filepath.Join(tempDir, "backup-corrupt.tar.gz")
+	if err := os.WriteFile(corrupt, []byte{0x1f, 0x8b, 0x00}, 0644); err != nil {
+		t.Fatalf("Failed to write corrupt backup: %v", err)
+	}
+	if err := hi.Restore(corrupt); err == nil {
+		t.Error("Expected an error restoring a corrupt tarball")
+	}
+}
+
+func TestHierarchicalInventory_RestoreRejectsTarballWithoutInventory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set("servers.web1.host", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	companion := filepath.Join(tempDir, "tsh-last.json")
+	if err := os.WriteFile(companion, []byte(`{"hostname":"web-prod-1"}`), 0644); err != nil {
+		t.Fatalf("Failed to write companion file: %v", err)
+	}
+
+	// A tarball holding only a companion file must not overwrite it
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte(`{"hostname":"attacker"}`)
+	if err := tw.WriteHeader(&tar.Header{Name: "tsh-last.json", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("Failed to write tar entry: %v", err)
+	}
+	tw.Close()
+	gz.Close()
+	noInventory := filepath.Join(tempDir, "backup-no-inventory.tar.gz")
+	if err := os.WriteFile(noInventory, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write backup: %v", err)
+	}
+
+	if err := hi.Restore(noInventory); err == nil {
+		t.Error("Expected an error restoring a tarball without an inventory file")
+	}
+	if got, _ := os.ReadFile(companion); string(got) != `{"hostname":"web-prod-1"}` {
+		t.Errorf("Companion file was overwritten by a rejected backup: %q", got)
+	}
+
+	// A backup of another namespace is rejected too
+	staging, err := NewNamespacedInventory(tempDir, "staging")
+	if err != nil {
+		t.Fatalf("Failed to create namespaced inventory: %v", err)
+	}
+	backupFile, err := hi.Backup()
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if err := staging.Restore(backupFile); err == nil {
+		t.Error("Expected an error restoring another namespace's backup")
+	}
+}
@@ -0,0 +1,55 @@
+package inventory
+
+// BeginBatch defers writing the inventory file until CommitBatch, so a run of
+// Set and Delete calls is saved once instead of after every change. Their
+// after-hooks and webhooks are held back until the batch has been saved.
+func (hi *HierarchicalInventory) BeginBatch() {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.batching = true
+	hi.batchPending = nil
+}
+
+// CommitBatch ends a batch started with BeginBatch and saves the inventory if
+// anything changed during it. Once the save succeeds, the held back hooks and
+// webhooks run in order; if it fails they are dropped. Without an open batch
+// it does nothing.
+func (hi *HierarchicalInventory) CommitBatch() error {
+	hi.mu.Lock()
+	if !hi.batching {
+		hi.mu.Unlock()
+		return nil
+	}
+	pending := hi.batchPending
+	hi.batching = false
+	hi.batchPending = nil
+	if hi.batchDirty {
+		hi.batchDirty = false
+		if err := hi.saveData(); err != nil {
+			hi.mu.Unlock()
+			return err
+		}
+	}
+	hi.mu.Unlock()
+
+	// Hooks and webhooks take the lock themselves
+	for _, notify := range pending {
+		if err := notify(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterSave runs notify, which reports a change that has just been saved. In a
+// batch the change is not on disk yet, so notify is queued for CommitBatch.
+func (hi *HierarchicalInventory) afterSave(notify func() error) error {
+	hi.mu.Lock()
+	if hi.batching {
+		hi.batchPending = append(hi.batchPending, notify)
+		hi.mu.Unlock()
+		return nil
+	}
+	hi.mu.Unlock()
+	return notify()
+}
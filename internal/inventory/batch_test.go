@@ -0,0 +1,140 @@
+package inventory
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHierarchicalInventory_Batch(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	if err := hi.Set("db.a.host", "a"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := hi.Set("db.b.host", "b"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	originalWrite := writeFile
+	defer func() { writeFile = originalWrite }()
+	writes := 0
+	writeFile = func(path string, data []byte, perm os.FileMode) error {
+		writes++
+		return originalWrite(path, data, perm)
+	}
+
+	hi.BeginBatch()
+	if err := hi.Delete("db.a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := hi.Delete("db.b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if writes != 0 {
+		t.Errorf("Expected no writes during the batch, got %d", writes)
+	}
+	if err := hi.CommitBatch(); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	if writes != 1 {
+		t.Errorf("Expected one write on commit, got %d", writes)
+	}
+
+	// The committed file has both deletes
+	reloaded, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	if keys, err := reloaded.List("db"); err != nil || len(keys) != 0 {
+		t.Errorf("Expected db to be empty after commit, got %v, %v", keys, err)
+	}
+
+	// A batch without changes, or no batch at all, writes nothing
+	hi.BeginBatch()
+	if err := hi.CommitBatch(); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	if err := hi.CommitBatch(); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	if writes != 1 {
+		t.Errorf("Expected no further writes, got %d", writes)
+	}
+}
+
+func TestHierarchicalInventory_BatchDefersWebhooks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalPost, originalWrite := postWebhook, writeFile
+	defer func() { postWebhook, writeFile = originalPost, originalWrite }()
+	var posted []string
+	postWebhook = func(url string, insecure bool, body []byte) error {
+		posted = append(posted, string(body))
+		return nil
+	}
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	if err := hi.Set(WebhooksKey, WebhookConfig{URL: "http://hooks.example", Events: []string{WebhookEventDelete}}); err != nil {
+		t.Fatalf("Failed to set webhook: %v", err)
+	}
+	for _, path := range []string{"db.a.host", "db.b.host", "db.c.host"} {
+		if err := hi.Set(path, "x"); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	hi.SetHooksEnabled(true)
+
+	// A batch whose save fails announces nothing
+	writeFile = func(path string, data []byte, perm os.FileMode) error {
+		return errors.New("disk full")
+	}
+	hi.BeginBatch()
+	if err := hi.Delete("db.a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(posted) != 0 {
+		t.Errorf("Expected no webhook before the batch is committed, got %v", posted)
+	}
+	if err := hi.CommitBatch(); err == nil {
+		t.Fatal("Expected CommitBatch to report the failed save")
+	}
+	if len(posted) != 0 {
+		t.Errorf("Expected no webhook after a failed commit, got %v", posted)
+	}
+
+	// A committed batch sends every delete once the file is written
+	writeFile = originalWrite
+	hi.BeginBatch()
+	if err := hi.Delete("db.b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := hi.Delete("db.c"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(posted) != 0 {
+		t.Errorf("Expected no webhook before the batch is committed, got %v", posted)
+	}
+	if err := hi.CommitBatch(); err != nil {
+		t.Fatalf("CommitBatch failed: %v", err)
+	}
+	if len(posted) != 2 || !strings.Contains(posted[0], `"path":"db.b"`) || !strings.Contains(posted[1], `"path":"db.c"`) {
+		t.Errorf("Expected delete webhooks for db.b and db.c, got %v", posted)
+	}
+}
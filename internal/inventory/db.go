@@ -0,0 +1,112 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// DbInventoryEntry represents a database entry in the inventory.
+type DbInventoryEntry struct {
+	Host        string   `json:"host"`
+	Type        string   `json:"type"` // e.g., "postgres", "redis", "mongodb"
+	RemotePort  int      `json:"remote_port"`
+	LocalPort   int      `json:"local_port,omitempty"` // Optional: if not set, a default will be used
+	Tags        []string `json:"tags,omitempty"`
+	Environment string   `json:"environment,omitempty"` // e.g., "prod", "staging", "dev"
+}
+
+// ParseDbEntry converts a stored db entry into a DbInventoryEntry. Entries
+// may be the struct itself (set in this process) or its generic JSON form
+// (loaded from disk).
+func ParseDbEntry(data interface{}) (DbInventoryEntry, error) {
+	switch v := data.(type) {
+	case DbInventoryEntry:
+		return v, nil
+	case *DbInventoryEntry:
+		return *v, nil
+	}
+
+	var entry DbInventoryEntry
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entry, fmt.Errorf("invalid db entry: %v", err)
+	}
+	return entry, nil
+}
+
+// FilterDbEntries returns the db entries usable from a node with the given
+// tags, keyed by name. Entries without tags match every node.
+func FilterDbEntries(hi *HierarchicalInventory, nodeTags []string) (map[string]DbInventoryEntry, error) {
+	keys, err := hi.List("db")
+	if err != nil || len(keys) == 0 {
+		return nil, fmt.Errorf("no DB inventory found")
+	}
+
+	entries := make(map[string]DbInventoryEntry)
+	for _, key := range keys {
+		data, err := hi.Query("db." + key)
+		if err != nil {
+			continue
+		}
+		entry, err := ParseDbEntry(data)
+		if err != nil {
+			continue
+		}
+		if len(entry.Tags) == 0 || hasCommonTags(nodeTags, entry.Tags) {
+			entries[key] = entry
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no DB entries with matching tags found")
+	}
+	return entries, nil
+}
+
+// SelectDbEntry prompts for one of the db entries matching the node tags.
+// It is shared by the SSH and TSH tunnelling code.
+func SelectDbEntry(hi *HierarchicalInventory, nodeTags []string) (*DbInventoryEntry, error) {
+	entries, err := FilterDbEntries(hi, nodeTags)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	prompt := promptui.Select{
+		Label: "Select DB key for tunnel",
+		Items: names,
+		Searcher: func(input string, index int) bool {
+			return strings.Contains(strings.ToLower(names[index]), strings.ToLower(input))
+		},
+	}
+	_, selectedKey, err := prompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("prompt failed: %v", err)
+	}
+
+	selectedEntry := entries[selectedKey]
+	return &selectedEntry, nil
+}
+
+func hasCommonTags(tags1, tags2 []string) bool {
+	for _, t1 := range tags1 {
+		for _, t2 := range tags2 {
+			if t1 == t2 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package inventory
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseDbEntry(t *testing.T) {
+	expected := DbInventoryEntry{Host: "db1", Type: "postgres", RemotePort: 5432, LocalPort: 15432, Tags: []string{"prod"}}
+
+	inputs := []interface{}{
+		expected,
+		&expected,
+		map[string]interface{}{
+			"host":        "db1",
+			"type":        "postgres",
+			"remote_port": float64(5432),
+			"local_port":  15432,
+			"tags":        []interface{}{"prod"},
+		},
+	}
+	for _, input := range inputs {
+		entry, err := ParseDbEntry(input)
+		if err != nil {
+			t.Errorf("ParseDbEntry(%T) failed: %v", input, err)
+			continue
+		}
+		if !reflect.DeepEqual(entry, expected) {
+			t.Errorf("ParseDbEntry(%T) = %+v, expected %+v", input, entry, expected)
+		}
+	}
+
+	if _, err := ParseDbEntry(map[string]interface{}{"remote_port": "not-a-number"}); err == nil {
+		t.Error("Expected error for invalid remote_port")
+	}
+}
+
+func TestFilterDbEntries(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+
+	if _, err := FilterDbEntries(hi, nil); err == nil {
+		t.Error("Expected error when there is no db inventory")
+	}
+
+	hi.Set("db.prod-pg", DbInventoryEntry{Host: "prod", RemotePort: 5432, Tags: []string{"prod"}})
+	hi.Set("db.dev-pg", map[string]interface{}{"host": "dev", "remote_port": 5432, "tags": []interface{}{"dev"}})
+	hi.Set("db.shared", map[string]interface{}{"host": "shared", "remote_port": 6379})
+
+	entries, err := FilterDbEntries(hi, []string{"prod"})
+	if err != nil {
+		t.Fatalf("FilterDbEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries["prod-pg"].Host != "prod" || entries["shared"].Host != "shared" {
+		t.Errorf("Expected prod-pg and untagged shared entry, got %+v", entries)
+	}
+
+	hi.Delete("db.shared")
+	if _, err := FilterDbEntries(hi, []string{"staging"}); err == nil {
+		t.Error("Expected error when no entries match the node tags")
+	}
+}
@@ -0,0 +1,94 @@
+package inventory
+
+import (
+	"reflect"
+	"sort"
+)
+
+// DiffType describes how a path changed between two inventories
+type DiffType string
+
+const (
+	DiffAdded    DiffType = "added"
+	DiffModified DiffType = "modified"
+	DiffRemoved  DiffType = "removed"
+)
+
+// DiffEntry is a single changed leaf path between two inventories
+type DiffEntry struct {
+	Path string      `json:"path"`
+	Type DiffType    `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Diff compares this inventory (the old state) with other (the new state)
+// and returns the changed leaf paths sorted by path. Arrays are compared as
+// whole values.
+func (hi *HierarchicalInventory) Diff(other *HierarchicalInventory) ([]DiffEntry, error) {
+	if err := hi.ensureDataLoaded(); err != nil {
+		return nil, err
+	}
+	if err := other.ensureDataLoaded(); err != nil {
+		return nil, err
+	}
+
+	hi.mu.RLock()
+	oldData, err := NormalizeValue(hi.data)
+	hi.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	other.mu.RLock()
+	newData, err := NormalizeValue(other.data)
+	other.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffData(oldData, newData), nil
+}
+
+// DiffData compares two generic JSON values and returns the changed leaf paths
+func DiffData(oldData, newData interface{}) []DiffEntry {
+	var entries []DiffEntry
+	diffValues("", oldData, newData, &entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+func diffValues(path string, oldValue, newValue interface{}, entries *[]DiffEntry) {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+
+	if oldIsMap && newIsMap {
+		for key, oldChild := range oldMap {
+			childPath := joinDiffPath(path, key)
+			if newChild, ok := newMap[key]; ok {
+				diffValues(childPath, oldChild, newChild, entries)
+			} else {
+				*entries = append(*entries, DiffEntry{Path: childPath, Type: DiffRemoved, Old: oldChild})
+			}
+		}
+		for key, newChild := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				*entries = append(*entries, DiffEntry{Path: joinDiffPath(path, key), Type: DiffAdded, New: newChild})
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*entries = append(*entries, DiffEntry{Path: path, Type: DiffModified, Old: oldValue, New: newValue})
+	}
+}
+
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
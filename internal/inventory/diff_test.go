@@ -0,0 +1,61 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHierarchicalInventory_Diff(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	oldFile := filepath.Join(tempDir, "old.json")
+	newFile := filepath.Join(tempDir, "new.json")
+	os.WriteFile(oldFile, []byte(`{"db":{"a":{"host":"a.example.com","port":5432},"b":{"host":"b"}},"tags":["x"]}`), 0644)
+	os.WriteFile(newFile, []byte(`{"db":{"a":{"host":"a.example.com","port":5433},"c":{"host":"c"}},"tags":["x","y"]}`), 0644)
+
+	oldInv, _ := NewHierarchicalInventory(tempDir)
+	newInv, _ := NewHierarchicalInventory(tempDir)
+	if err := oldInv.LoadFromFile(oldFile, "json"); err != nil {
+		t.Fatalf("Failed to load old file: %v", err)
+	}
+	if err := newInv.LoadFromFile(newFile, "json"); err != nil {
+		t.Fatalf("Failed to load new file: %v", err)
+	}
+
+	entries, err := oldInv.Diff(newInv)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	expected := []struct {
+		path string
+		typ  DiffType
+	}{
+		{"db.a.port", DiffModified},
+		{"db.b", DiffRemoved},
+		{"db.c", DiffAdded},
+		{"tags", DiffModified},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d diff entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+	for i, e := range expected {
+		if entries[i].Path != e.path || entries[i].Type != e.typ {
+			t.Errorf("Entry %d: expected %s %s, got %s %s", i, e.typ, e.path, entries[i].Type, entries[i].Path)
+		}
+	}
+	if entries[0].Old != 5432.0 || entries[0].New != 5433.0 {
+		t.Errorf("Expected port 5432 -> 5433, got %v -> %v", entries[0].Old, entries[0].New)
+	}
+
+	// Identical inventories have no differences
+	entries, err = oldInv.Diff(oldInv)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected no differences, got %+v (err %v)", entries, err)
+	}
+}
@@ -0,0 +1,501 @@
+package inventory
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// filterPartRegex matches a [?(expr)] filter segment, optionally preceded by
+// a plain key
+var filterPartRegex = regexp.MustCompile(`^(.*?)\[\?\((.*)\)\]$`)
+
+// FilterExpr is a compiled [?(expr)] predicate. Expressions refer to the
+// candidate element as @ and support comparisons (== != < <= > >=), logic
+// (&& || !), arithmetic on numbers (+ - * /) and parentheses, e.g.
+// "@.remote_port + 1 == @.local_port".
+type FilterExpr struct {
+	source string
+	root   filterNode
+}
+
+// String returns the expression source
+func (f *FilterExpr) String() string {
+	return f.source
+}
+
+// CompileFilter parses a filter expression
+func CompileFilter(expr string) (*FilterExpr, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter '%s': %v", expr, err)
+	}
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected '%s'", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter '%s': %v", expr, err)
+	}
+	return &FilterExpr{source: expr, root: root}, nil
+}
+
+// Match reports whether item satisfies the filter. Evaluation errors, such as
+// arithmetic on a missing field or a division by zero, count as no match.
+func (f *FilterExpr) Match(item interface{}) bool {
+	value, err := f.root.eval(item)
+	if err != nil {
+		return false
+	}
+	return truthy(value)
+}
+
+type filterTokenKind int
+
+const (
+	tokenNumber filterTokenKind = iota
+	tokenString
+	tokenIdent
+	tokenPath
+	tokenOp
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	num  float64
+	path []QuerySegment
+}
+
+// filterOps are the operators, longest first so "==" wins over "="
+var filterOps = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "(", ")"}
+
+// tokenizeFilter splits an expression into numbers, quoted strings,
+// identifiers (true, false, null), @-paths and operators
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '@':
+			end := i + 1
+			for end < len(expr) && isFilterPathChar(expr[end]) {
+				if expr[end] == '[' && end+1 < len(expr) && expr[end+1] == '"' {
+					close := strings.Index(expr[end+2:], `"]`)
+					if close < 0 {
+						return nil, fmt.Errorf("unterminated quoted key")
+					}
+					end += close + 4
+					continue
+				}
+				end++
+			}
+			path, err := parseFilterPath(expr[i+1 : end])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: tokenPath, text: expr[i:end], path: path})
+			i = end
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			text := expr[i+1 : i+1+end]
+			tokens = append(tokens, filterToken{kind: tokenString, text: text})
+			i += end + 2
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(expr) && expr[i+1] >= '0' && expr[i+1] <= '9':
+			end := i
+			for end < len(expr) && (expr[end] >= '0' && expr[end] <= '9' || expr[end] == '.') {
+				end++
+			}
+			num, err := strconv.ParseFloat(expr[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%s'", expr[i:end])
+			}
+			tokens = append(tokens, filterToken{kind: tokenNumber, text: expr[i:end], num: num})
+			i = end
+		case c >= 'a' && c <= 'z':
+			end := i
+			for end < len(expr) && expr[end] >= 'a' && expr[end] <= 'z' {
+				end++
+			}
+			word := expr[i:end]
+			if word != "true" && word != "false" && word != "null" {
+				return nil, fmt.Errorf("unknown identifier '%s'", word)
+			}
+			tokens = append(tokens, filterToken{kind: tokenIdent, text: word})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range filterOps {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character '%c'", c)
+			}
+			tokens = append(tokens, filterToken{kind: tokenOp, text: op})
+			i += len(op)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+// isFilterPathChar reports whether c can continue an @-path. '-' is left out
+// so "@.port-1" subtracts; hyphenated keys are written @["remote-port"].
+func isFilterPathChar(c byte) bool {
+	return c == '.' || c == '[' || c == ']' || c == '_' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// parseFilterPath parses the part of an @-path after the @, e.g. ".tags[0]"
+func parseFilterPath(path string) ([]QuerySegment, error) {
+	var segments []QuerySegment
+	for _, part := range splitQueryParts(path) {
+		if part == "" {
+			continue
+		}
+		partSegments, err := parseQueryPart(part)
+		if err != nil {
+			return nil, err
+		}
+		for _, segment := range partSegments {
+			if segment.Type != SegmentTypeKey && segment.Type != SegmentTypeIndex {
+				return nil, fmt.Errorf("only keys and indexes are allowed in '@%s'", path)
+			}
+		}
+		segments = append(segments, partSegments...)
+	}
+	return segments, nil
+}
+
+// filterParser is a recursive-descent parser over filter tokens. Precedence
+// from lowest to highest: ||, &&, comparisons, + -, * /, unary ! -.
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOp {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *filterParser) parseBinary(next func() (filterNode, error), ops ...string) (filterNode, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peekOp(ops...)
+		if op == "" {
+			return left, nil
+		}
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peekOp("==", "!=", "<=", ">=", "<", ">")
+	if op == "" {
+		return left, nil
+	}
+	p.pos++
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return binaryNode{op: op, left: left, right: right}, nil
+}
+
+func (p *filterParser) parseSum() (filterNode, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *filterParser) parseProduct() (filterNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if op := p.peekOp("!", "-"); op != "" {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case tokenNumber:
+		return literalNode{value: token.num}, nil
+	case tokenString:
+		return literalNode{value: token.text}, nil
+	case tokenIdent:
+		switch token.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+		return literalNode{value: nil}, nil
+	case tokenPath:
+		return pathNode{path: token.path}, nil
+	}
+
+	if token.text != "(" {
+		return nil, fmt.Errorf("unexpected '%s'", token.text)
+	}
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peekOp(")") == "" {
+		return nil, fmt.Errorf("missing ')'")
+	}
+	p.pos++
+	return inner, nil
+}
+
+// filterNode is a node of a compiled filter expression
+type filterNode interface {
+	eval(item interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(item interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+// pathNode resolves an @-path against the candidate element. Missing fields
+// evaluate to nil so "@.port == null" can test for them.
+type pathNode struct {
+	path []QuerySegment
+}
+
+func (n pathNode) eval(item interface{}) (interface{}, error) {
+	current := item
+	for _, segment := range n.path {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			if segment.Type != SegmentTypeKey {
+				return nil, nil
+			}
+			current = v[segment.Key]
+		case []interface{}:
+			if segment.Type != SegmentTypeIndex || segment.Index < 0 || segment.Index >= len(v) {
+				return nil, nil
+			}
+			current = v[segment.Index]
+		default:
+			return nil, nil
+		}
+	}
+	return current, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand filterNode
+}
+
+func (n unaryNode) eval(item interface{}) (interface{}, error) {
+	value, err := n.operand.eval(item)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !truthy(value), nil
+	}
+	num, ok := filterNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %v", value)
+	}
+	return -num, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right filterNode
+}
+
+func (n binaryNode) eval(item interface{}) (interface{}, error) {
+	left, err := n.left.eval(item)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit logic before evaluating the right-hand side
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+	}
+
+	right, err := n.right.eval(item)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return truthy(right), nil
+	case "==":
+		return filterEqual(left, right), nil
+	case "!=":
+		return !filterEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		return filterCompare(n.op, left, right)
+	}
+
+	a, okA := filterNumber(left)
+	b, okB := filterNumber(right)
+	if !okA || !okB {
+		return nil, fmt.Errorf("'%s' needs numbers, got %v and %v", n.op, left, right)
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	default:
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	}
+}
+
+// filterNumber converts JSON and Go numeric values to float64
+func filterNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// filterEqual compares numbers numerically and everything else by value
+func filterEqual(a, b interface{}) bool {
+	numA, okA := filterNumber(a)
+	numB, okB := filterNumber(b)
+	if okA && okB {
+		return numA == numB
+	}
+	switch a.(type) {
+	case nil, string, bool:
+		return a == b
+	}
+	return false
+}
+
+// filterCompare orders two numbers or two strings
+func filterCompare(op string, a, b interface{}) (bool, error) {
+	var cmp int
+	numA, okA := filterNumber(a)
+	numB, okB := filterNumber(b)
+	strA, isStrA := a.(string)
+	strB, isStrB := b.(string)
+	switch {
+	case okA && okB:
+		switch {
+		case numA < numB:
+			cmp = -1
+		case numA > numB:
+			cmp = 1
+		}
+	case isStrA && isStrB:
+		cmp = strings.Compare(strA, strB)
+	default:
+		return false, fmt.Errorf("cannot compare %v and %v", a, b)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// truthy reports whether a filter result counts as a match. Missing values,
+// false, zero and empty strings do not.
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	if num, ok := filterNumber(value); ok {
+		return num != 0
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package inventory
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCompileFilter(t *testing.T) {
+	item := map[string]interface{}{
+		"host":        "10.0.0.1",
+		"remote_port": float64(5432),
+		"local_port":  float64(5433),
+		"remote-port": float64(22),
+		"tags":        []interface{}{"prod", "eu"},
+		"enabled":     true,
+	}
+
+	matches := []string{
+		"@.remote_port + 1 == 5433",
+		"@.remote_port + 1 == @.local_port",
+		"@.local_port - @.remote_port == 1",
+		"@.remote_port * 2 / 4 == 2716",
+		"(@.remote_port + 8) / 10 == 544",
+		"-@.remote_port < 0",
+		`@.host == "10.0.0.1"`,
+		"@.host != '10.0.0.2'",
+		`@.tags[0] == "prod"`,
+		`@["remote-port"] == 22`,
+		"@.enabled && @.remote_port >= 5432",
+		"@.missing == null || @.remote_port > 9000",
+		"!@.missing",
+		"2 + 3 * 4 == 14",
+	}
+	for _, expr := range matches {
+		filter, err := CompileFilter(expr)
+		if err != nil {
+			t.Errorf("Failed to compile %q: %v", expr, err)
+			continue
+		}
+		if !filter.Match(item) {
+			t.Errorf("Expected %q to match", expr)
+		}
+	}
+
+	nonMatches := []string{
+		"@.remote_port + 1 == 5432",
+		"@.missing + 1 == 1",
+		"@.remote_port / 0 == 1",
+		`@.host + 1 == 2`,
+		`@.host < 5`,
+	}
+	for _, expr := range nonMatches {
+		filter, err := CompileFilter(expr)
+		if err != nil {
+			t.Errorf("Failed to compile %q: %v", expr, err)
+			continue
+		}
+		if filter.Match(item) {
+			t.Errorf("Expected %q not to match", expr)
+		}
+	}
+
+	for _, expr := range []string{"", "@.port ==", "(@.port == 1", "@.port === 1", "foo == 1", `@.host == "x`, "@.port # 1"} {
+		if _, err := CompileFilter(expr); err == nil {
+			t.Errorf("Expected %q to fail to compile", expr)
+		}
+	}
+}
+
+func TestHierarchicalInventory_QueryFilter(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set("db", map[string]interface{}{
+		"pg":    map[string]interface{}{"host": "10.0.0.1", "remote_port": 5432, "local_port": 5433},
+		"redis": map[string]interface{}{"host": "10.0.0.2", "remote_port": 6379, "local_port": 16379},
+	}); err != nil {
+		t.Fatalf("Failed to set db: %v", err)
+	}
+	if err := hi.Set("servers", []interface{}{
+		map[string]interface{}{"name": "web1", "cpu": 2, "mem": 4},
+		map[string]interface{}{"name": "web2", "cpu": 8, "mem": 32},
+	}); err != nil {
+		t.Fatalf("Failed to set servers: %v", err)
+	}
+
+	result, err := hi.Query("db.[?(@.remote_port + 1 == @.local_port)].host")
+	if err != nil {
+		t.Fatalf("Failed to query db filter: %v", err)
+	}
+	if !reflect.DeepEqual(result, map[string]interface{}{"pg": "10.0.0.1"}) {
+		t.Errorf("Unexpected db filter result: %v", result)
+	}
+
+	result, err = hi.Query("servers[?(@.mem / @.cpu >= 4)].name")
+	if err != nil {
+		t.Fatalf("Failed to query servers filter: %v", err)
+	}
+	if !reflect.DeepEqual(result, []interface{}{"web2"}) {
+		t.Errorf("Unexpected servers filter result: %v", result)
+	}
+
+	if err := ValidateQuery("db.[?(@.remote_port + 1 == 5433)].host"); err != nil {
+		t.Errorf("Expected filter query to be valid, got %v", err)
+	}
+	if err := ValidateQuery("db.[?(@.remote_port +)]"); err == nil {
+		t.Error("Expected invalid filter expression to fail validation")
+	}
+}
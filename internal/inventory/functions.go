@@ -0,0 +1,68 @@
+package inventory
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// queryFunctionRegex matches a query wrapped in a function call such as
+// keys(db) or length(db.[*].host)
+var queryFunctionRegex = regexp.MustCompile(`^(keys|values|length)\((.*)\)$`)
+
+// applyQueryFunction evaluates a query function on the value its argument
+// resolved to. keys returns the sorted keys of an object or the indexes of
+// an array, values the values of an object in key order or the elements of
+// an array, and length the number of keys, elements or characters.
+func applyQueryFunction(name string, value interface{}) (interface{}, error) {
+	switch name {
+	case "keys":
+		switch v := value.(type) {
+		case map[string]interface{}:
+			keys := make([]interface{}, 0, len(v))
+			for _, key := range sortedKeys(v) {
+				keys = append(keys, key)
+			}
+			return keys, nil
+		case []interface{}:
+			keys := make([]interface{}, len(v))
+			for i := range v {
+				keys[i] = i
+			}
+			return keys, nil
+		}
+	case "values":
+		switch v := value.(type) {
+		case map[string]interface{}:
+			values := make([]interface{}, 0, len(v))
+			for _, key := range sortedKeys(v) {
+				values = append(values, v[key])
+			}
+			return values, nil
+		case []interface{}:
+			return append([]interface{}{}, v...), nil
+		}
+	case "length":
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return len(v), nil
+		case []interface{}:
+			return len(v), nil
+		case string:
+			return utf8.RuneCountInString(v), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown query function: %s", name)
+	}
+	return nil, fmt.Errorf("%s() cannot be applied to %s", name, withArticle(valueKind(value)))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
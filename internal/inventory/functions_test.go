@@ -0,0 +1,67 @@
+package inventory
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestHierarchicalInventory_QueryFunctions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	hi.data = map[string]interface{}{
+		"db": map[string]interface{}{
+			"server2": map[string]interface{}{"host": "db2.example.com"},
+			"server1": map[string]interface{}{"host": "db1.example.com", "tags": []interface{}{"prod", "primary"}},
+		},
+	}
+	hi.loaded = true
+
+	tests := []struct {
+		query    string
+		expected interface{}
+		wantErr  bool
+	}{
+		{query: "keys(db)", expected: []interface{}{"server1", "server2"}},
+		{query: "keys(db.server1.tags)", expected: []interface{}{0, 1}},
+		{query: "values(db.[*].host)", expected: []interface{}{"db1.example.com", "db2.example.com"}},
+		{query: "values(db.server2)", expected: []interface{}{"db2.example.com"}},
+		{query: "length(db)", expected: 2},
+		{query: "length(db.server1.tags)", expected: 2},
+		{query: "length(db.server1.host)", expected: 15},
+		{query: "length(keys(db))", expected: 2},
+		{query: "keys()", expected: []interface{}{"db"}},
+		{query: "keys(db.server1.host)", wantErr: true},
+		{query: "keys(db.missing)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if err := ValidateQuery(tt.query); err != nil {
+				t.Fatalf("ValidateQuery(%q) failed: %v", tt.query, err)
+			}
+			result, err := hi.Query(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Query() = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+
+	if err := ValidateQuery("keys(db..x)"); err == nil {
+		t.Error("Expected ValidateQuery to check the function argument")
+	}
+	if err := hi.Set("keys(db)", "x"); err == nil {
+		t.Error("Expected Set to reject a function query")
+	}
+}
@@ -2,12 +2,15 @@ package inventory
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,10 +19,21 @@ import (
 
 // HierarchicalInventory manages a jq-like hierarchical data structure
 type HierarchicalInventory struct {
-	dataDir string
-	data    map[string]interface{}
-	loaded  bool
-	mu      sync.RWMutex
+	dataDir        string
+	data           map[string]interface{}
+	loaded         bool
+	validators     map[string]TypeValidator
+	requiredFields map[string][]string
+	maxDepth       int
+	namespace      string
+	readOnly       bool
+	hooksEnabled   bool
+	ioTimeout      time.Duration
+	maxSize        int64
+	batching       bool
+	batchDirty     bool
+	batchPending   []func() error
+	mu             sync.RWMutex
 }
 
 // NewHierarchicalInventory creates a new hierarchical inventory instance
@@ -33,6 +47,69 @@ func NewHierarchicalInventory(dataDir string) (*HierarchicalInventory, error) {
 	return hi, nil
 }
 
+// namespacePattern restricts namespace names to safe file name characters
+var namespacePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// NewNamespacedInventory creates an inventory isolated in its own files,
+// hierarchical-inventory-<namespace>.json, within dataDir. An empty namespace
+// is the default inventory.
+func NewNamespacedInventory(dataDir, namespace string) (*HierarchicalInventory, error) {
+	if namespace != "" && !namespacePattern.MatchString(namespace) {
+		return nil, fmt.Errorf("invalid namespace '%s': use letters, digits, '-' and '_'", namespace)
+	}
+	hi, err := NewHierarchicalInventory(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	hi.namespace = namespace
+	return hi, nil
+}
+
+// NewMemoryInventory creates a read-only inventory over data that is never
+// loaded from or saved to disk, for querying values that did not come from
+// the inventory files
+func NewMemoryInventory(data map[string]interface{}) *HierarchicalInventory {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return &HierarchicalInventory{
+		data:     data,
+		loaded:   true,
+		readOnly: true,
+	}
+}
+
+// ErrReadOnly is returned by every write to a read-only inventory
+var ErrReadOnly = errors.New("inventory is read-only")
+
+// SetReadOnly makes every write fail with ErrReadOnly. A read-only inventory
+// also does not create its binary cache, so it never touches the filesystem.
+func (hi *HierarchicalInventory) SetReadOnly(readOnly bool) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.readOnly = readOnly
+}
+
+func (hi *HierarchicalInventory) isReadOnly() bool {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+	return hi.readOnly
+}
+
+// storeFile returns the path of the inventory's data file with the given extension
+func (hi *HierarchicalInventory) storeFile(ext string) string {
+	name := "hierarchical-inventory"
+	if hi.namespace != "" {
+		name += "-" + hi.namespace
+	}
+	return filepath.Join(hi.dataDir, name+ext)
+}
+
+// StorePath returns the path of the JSON file the inventory is saved to
+func (hi *HierarchicalInventory) StorePath() string {
+	return hi.storeFile(".json")
+}
+
 // ensureDataLoaded ensures that data is loaded, using lazy loading
 func (hi *HierarchicalInventory) ensureDataLoaded() error {
 	hi.mu.RLock()
@@ -54,7 +131,12 @@ func (hi *HierarchicalInventory) ensureDataLoaded() error {
 		return err
 	}
 
-	if err := hi.loadData(); err != nil {
+	ctx, cancel := hi.ioContext()
+	defer cancel()
+	if err := hi.loadData(ctx); err != nil {
+		return err
+	}
+	if err := hi.migrateLegacyFiles(ctx); err != nil {
 		return err
 	}
 
@@ -67,22 +149,33 @@ func (hi *HierarchicalInventory) ensureDataDir() error {
 	return os.MkdirAll(hi.dataDir, 0755)
 }
 
-// loadData loads all inventory data from files with binary caching for speed
-func (hi *HierarchicalInventory) loadData() error {
+// loadData loads all inventory data from files with binary caching for speed.
+// Reads that outlast ctx fail with ErrIOTimeout rather than being treated as
+// a missing file, so a slow disk never looks like an empty inventory.
+func (hi *HierarchicalInventory) loadData(ctx context.Context) error {
 	// Try to load from fast binary cache first
-	binaryFile := filepath.Join(hi.dataDir, "hierarchical-inventory.gob")
-	jsonFile := filepath.Join(hi.dataDir, "hierarchical-inventory.json")
+	binaryFile := hi.storeFile(".gob")
+	jsonFile := hi.storeFile(".json")
 
 	// Check if binary cache exists and is newer than JSON file
 	if binaryStat, err := os.Stat(binaryFile); err == nil {
 		if jsonStat, err := os.Stat(jsonFile); err != nil || binaryStat.ModTime().After(jsonStat.ModTime()) {
 			// Binary cache is newer or JSON doesn't exist, use binary
-			data, err := os.ReadFile(binaryFile)
+			data, err := readFileContext(ctx, binaryFile)
+			if errors.Is(err, ErrIOTimeout) {
+				return err
+			}
 			if err == nil {
-				buf := bytes.NewBuffer(data)
-				dec := gob.NewDecoder(buf)
-				if err := dec.Decode(&hi.data); err == nil {
-					return nil // Successfully loaded from binary cache
+				if payload, ok := stripGobHeader(data); ok {
+					buf := bytes.NewBuffer(payload)
+					dec := gob.NewDecoder(buf)
+					if err := dec.Decode(&hi.data); err == nil {
+						return nil // Successfully loaded from binary cache
+					}
+					hi.data = make(map[string]interface{})
+				} else if !hi.readOnly {
+					// Written by another version of tsukuyo; rebuild it from JSON
+					_ = os.Remove(binaryFile)
 				}
 			}
 		}
@@ -90,28 +183,32 @@ func (hi *HierarchicalInventory) loadData() error {
 
 	// Fall back to JSON loading
 	if _, err := os.Stat(jsonFile); err == nil {
-		if err := hi.loadFromSingleFile(jsonFile); err == nil {
+		err := hi.loadFromSingleFile(ctx, jsonFile)
+		if err == nil {
 			// Create binary cache for next time
 			hi.createBinaryCache()
 			return nil
 		}
+		var syntaxErr *SyntaxError
+		if errors.Is(err, ErrIOTimeout) || errors.As(err, &syntaxErr) {
+			return err
+		}
 	}
 
-	// Otherwise, load from multiple *-inventory.json files
-	if err := hi.loadFromMultipleFiles(); err == nil {
-		// Create binary cache for next time
-		hi.createBinaryCache()
-		return nil
-	}
-
+	// Legacy *-inventory.json files are merged in by migrateLegacyFiles
 	return nil // No files to load, start with empty data
 }
 
 // createBinaryCache creates a binary cache file for faster loading
 func (hi *HierarchicalInventory) createBinaryCache() {
-	binaryFile := filepath.Join(hi.dataDir, "hierarchical-inventory.gob")
+	if hi.readOnly {
+		return
+	}
+	binaryFile := hi.storeFile(".gob")
 
 	var buf bytes.Buffer
+	buf.WriteString(gobMagic)
+	buf.WriteByte(gobCacheVersion)
 	enc := gob.NewEncoder(&buf)
 	if err := enc.Encode(hi.data); err == nil {
 		// Write binary cache, ignore errors as it's just optimization
@@ -119,29 +216,96 @@ func (hi *HierarchicalInventory) createBinaryCache() {
 	}
 }
 
-// loadFromSingleFile loads data from a single hierarchical-inventory.json file
-func (hi *HierarchicalInventory) loadFromSingleFile(filePath string) error {
-	data, err := os.ReadFile(filePath)
+// The binary cache starts with gobMagic and gobCacheVersion. Bump the version
+// whenever the cached data layout changes so older caches are discarded
+// instead of decoding incorrectly.
+const (
+	gobMagic        = "TS"
+	gobCacheVersion = byte(1)
+)
+
+// stripGobHeader returns the gob payload of a binary cache file, or false if
+// the file lacks the magic number or was written with another cache version
+func stripGobHeader(data []byte) ([]byte, bool) {
+	headerLen := len(gobMagic) + 1
+	if len(data) < headerLen || string(data[:len(gobMagic)]) != gobMagic || data[len(gobMagic)] != gobCacheVersion {
+		return nil, false
+	}
+	return data[headerLen:], true
+}
+
+// loadFromSingleFile loads data from a single hierarchical-inventory.json file.
+// Comments and trailing commas from hand edits are tolerated.
+func (hi *HierarchicalInventory) loadFromSingleFile(ctx context.Context, filePath string) error {
+	data, err := readFileContext(ctx, filePath)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(data, &hi.data)
+	err = UnmarshalJSON5(data, &hi.data)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return newSyntaxError(filePath, data, syntaxErr)
+	}
+	return err
 }
 
-// loadFromMultipleFiles loads data from multiple *-inventory.json files
-func (hi *HierarchicalInventory) loadFromMultipleFiles() error {
+// SyntaxError reports malformed JSON in an inventory file together with the
+// line it was found on
+type SyntaxError struct {
+	File string
+	Line int
+	Err  *json.SyntaxError
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Syntax error in %s at line %d: %v", filepath.Base(e.File), e.Line, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// newSyntaxError locates the line of err's byte offset within data. The
+// offset points just past the offending character.
+func newSyntaxError(file string, data []byte, err *json.SyntaxError) *SyntaxError {
+	offset := int(err.Offset) - 1
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(data) {
+		offset = len(data)
+	}
+	return &SyntaxError{File: file, Line: bytes.Count(data[:offset], []byte("\n")) + 1, Err: err}
+}
+
+// legacyInventoryFiles returns the *-inventory.json files of the data
+// directory, leaving out the default and namespaced hierarchical stores
+func (hi *HierarchicalInventory) legacyInventoryFiles() []string {
 	files, err := filepath.Glob(filepath.Join(hi.dataDir, "*-inventory.json"))
 	if err != nil {
-		return err
+		return nil
 	}
 
+	var legacy []string
 	for _, file := range files {
+		if !strings.HasPrefix(filepath.Base(file), "hierarchical-inventory") {
+			legacy = append(legacy, file)
+		}
+	}
+	return legacy
+}
+
+// loadFromMultipleFiles loads data from multiple *-inventory.json files
+func (hi *HierarchicalInventory) loadFromMultipleFiles(ctx context.Context) error {
+	for _, file := range hi.legacyInventoryFiles() {
 		// Extract the inventory type from filename (e.g., "db-inventory.json" -> "db")
-		baseName := filepath.Base(file)
-		inventoryType := strings.TrimSuffix(baseName, "-inventory.json")
+		inventoryType := strings.TrimSuffix(filepath.Base(file), "-inventory.json")
 
-		data, err := os.ReadFile(file)
+		data, err := readFileContext(ctx, file)
+		if errors.Is(err, ErrIOTimeout) {
+			return err
+		}
 		if err != nil {
 			continue // Skip files that can't be read
 		}
@@ -151,7 +315,10 @@ func (hi *HierarchicalInventory) loadFromMultipleFiles() error {
 			continue // Skip invalid JSON files
 		}
 
-		hi.data[inventoryType] = fileData
+		// Never clobber a type that is already in the hierarchical store
+		if _, exists := hi.data[inventoryType]; !exists {
+			hi.data[inventoryType] = fileData
+		}
 	}
 
 	return nil
@@ -159,17 +326,29 @@ func (hi *HierarchicalInventory) loadFromMultipleFiles() error {
 
 // saveData saves all inventory data to storage with binary cache
 func (hi *HierarchicalInventory) saveData() error {
+	if hi.readOnly {
+		return ErrReadOnly
+	}
+	if hi.batching {
+		// CommitBatch writes the file once the batch ends
+		hi.batchDirty = true
+		return nil
+	}
+
 	// Prefer single file approach for hierarchical data
-	singleFile := filepath.Join(hi.dataDir, "hierarchical-inventory.json")
+	singleFile := hi.storeFile(".json")
 
 	data, err := json.MarshalIndent(hi.data, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(singleFile, data, 0644); err != nil {
+	ctx, cancel := hi.ioContext()
+	defer cancel()
+	if err := writeFileContext(ctx, singleFile, data, 0644); err != nil {
 		return err
 	}
+	hi.warnIfOversized(singleFile)
 
 	// Create binary cache for faster next load
 	hi.createBinaryCache()
@@ -177,6 +356,35 @@ func (hi *HierarchicalInventory) saveData() error {
 	return nil
 }
 
+// writeFileAtomic writes data to path.tmp in the same directory and renames
+// it over path, so a crash mid-write never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpFile := path + ".tmp"
+	f, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
+
 // Query performs a jq-like query on the hierarchical data
 func (hi *HierarchicalInventory) Query(query string) (interface{}, error) {
 	// Ensure data is loaded
@@ -184,6 +392,39 @@ func (hi *HierarchicalInventory) Query(query string) (interface{}, error) {
 		return nil, err
 	}
 
+	return hi.queryValue(query)
+}
+
+// QueryMultiple queries several paths under a single read lock. Results are
+// keyed by the original path; paths that fail are returned in the error map
+// instead.
+func (hi *HierarchicalInventory) QueryMultiple(paths []string) (map[string]interface{}, map[string]error) {
+	results := make(map[string]interface{})
+	errs := make(map[string]error)
+
+	if err := hi.ensureDataLoaded(); err != nil {
+		for _, path := range paths {
+			errs[path] = err
+		}
+		return results, errs
+	}
+
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	for _, path := range paths {
+		value, err := hi.queryValue(path)
+		if err != nil {
+			errs[path] = err
+			continue
+		}
+		results[path] = value
+	}
+	return results, errs
+}
+
+// queryValue resolves a query against the loaded data without locking
+func (hi *HierarchicalInventory) queryValue(query string) (interface{}, error) {
 	if query == "" {
 		return hi.data, nil
 	}
@@ -202,80 +443,221 @@ func (hi *HierarchicalInventory) Query(query string) (interface{}, error) {
 func (hi *HierarchicalInventory) parseQuery(query string) ([]QuerySegment, error) {
 	var segments []QuerySegment
 
-	// Split by dots, but handle array notation
-	parts := strings.Split(query, ".")
+	// A function call wraps a whole query: keys(db), length(db.[*].host)
+	if matches := queryFunctionRegex.FindStringSubmatch(query); matches != nil {
+		args, err := hi.parseQuery(matches[2])
+		if err != nil {
+			return nil, err
+		}
+		return append(segments, QuerySegment{Type: SegmentTypeFunction, Func: matches[1], Args: args}), nil
+	}
+
+	// Split by dots, but handle array and quoted key notation
+	parts := splitQueryParts(query)
 
 	for _, part := range parts {
 		if part == "" {
 			continue
 		}
 
-		// Check for standalone array notation [index] or [*]
-		standaloneArrayRegex := regexp.MustCompile(`^\[(.+)\]$`)
-		if matches := standaloneArrayRegex.FindStringSubmatch(part); matches != nil {
-			// Handle array index or wildcard
-			indexPart := matches[1]
-			if indexPart == "*" {
-				segments = append(segments, QuerySegment{
-					Type: SegmentTypeWildcard,
-				})
-			} else {
-				index, err := strconv.Atoi(indexPart)
-				if err != nil {
-					return nil, fmt.Errorf("invalid array index: %s", indexPart)
-				}
-				segments = append(segments, QuerySegment{
-					Type:  SegmentTypeIndex,
-					Index: index,
-				})
-			}
+		partSegments, err := parseQueryPart(part)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, partSegments...)
+	}
+
+	for i, segment := range segments {
+		if segment.Type == SegmentTypeSelf && segment.KeyMode && i < len(segments)-1 {
+			return nil, fmt.Errorf("@key must be the last segment of a query")
+		}
+	}
+
+	return segments, nil
+}
+
+// quotedKeyRegex matches a ["literal.key"] segment, optionally preceded by a
+// plain key and followed by further bracket segments
+var quotedKeyRegex = regexp.MustCompile(`^(.*?)\["([^"]+)"\](.*)$`)
+
+// splitQueryParts splits a query on dots that are not inside a ["..."] key
+// or a [?(...)] filter
+func splitQueryParts(query string) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if !inQuotes && strings.HasPrefix(query[i:], "[?(") {
+			end := filterEnd(query[i:])
+			current.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		}
+		switch {
+		case c == '"' && i > 0 && query[i-1] == '[' && !inQuotes:
+			inQuotes = true
+		case c == '"' && inQuotes && i+1 < len(query) && query[i+1] == ']':
+			inQuotes = false
+		case c == '.' && !inQuotes:
+			parts = append(parts, current.String())
+			current.Reset()
 			continue
 		}
+		current.WriteByte(c)
+	}
+	return append(parts, current.String())
+}
 
-		// Check for key with array notation key[index] or key[*]
-		keyArrayRegex := regexp.MustCompile(`^(.+?)\[(.+)\]$`)
-		if matches := keyArrayRegex.FindStringSubmatch(part); matches != nil {
-			// Handle the base part first
-			if matches[1] != "" {
-				segments = append(segments, QuerySegment{
-					Type: SegmentTypeKey,
-					Key:  matches[1],
-				})
+// filterEnd returns the length of the [?(...)] filter at the start of s,
+// skipping parentheses inside quoted strings, or len(s) if it is unclosed
+func filterEnd(s string) int {
+	depth := 0
+	var quote byte
+	for i := 2; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
 			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 && i+1 < len(s) && s[i+1] == ']' {
+				return i + 2
+			}
+		}
+	}
+	return len(s)
+}
 
-			// Handle array index or wildcard
-			indexPart := matches[2]
-			if indexPart == "*" {
-				segments = append(segments, QuerySegment{
-					Type: SegmentTypeWildcard,
-				})
-			} else {
-				index, err := strconv.Atoi(indexPart)
-				if err != nil {
-					return nil, fmt.Errorf("invalid array index: %s", indexPart)
-				}
-				segments = append(segments, QuerySegment{
-					Type:  SegmentTypeIndex,
-					Index: index,
-				})
+// parseQueryPart parses a single dot-separated part of a query
+func parseQueryPart(part string) ([]QuerySegment, error) {
+	var segments []QuerySegment
+
+	// Filters select the elements for which an expression holds: key[?(@.port > 5000)]
+	if matches := filterPartRegex.FindStringSubmatch(part); matches != nil {
+		if matches[1] != "" {
+			base, err := parseQueryPart(matches[1])
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, base...)
+		}
+		filter, err := CompileFilter(matches[2])
+		if err != nil {
+			return nil, err
+		}
+		return append(segments, QuerySegment{Type: SegmentTypeFilter, Filter: filter}), nil
+	}
+
+	// Quoted keys may contain dots and brackets: key["literal.key"]
+	if matches := quotedKeyRegex.FindStringSubmatch(part); matches != nil {
+		if matches[1] != "" {
+			base, err := parseQueryPart(matches[1])
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, base...)
+		}
+		segments = append(segments, QuerySegment{
+			Type: SegmentTypeKey,
+			Key:  matches[2],
+		})
+		if matches[3] != "" {
+			rest, err := parseQueryPart(matches[3])
+			if err != nil {
+				return nil, err
 			}
+			segments = append(segments, rest...)
+		}
+		return segments, nil
+	}
+
+	// @ is the current value and @key the key it was reached through
+	switch part {
+	case "@":
+		return append(segments, QuerySegment{Type: SegmentTypeSelf}), nil
+	case "@key":
+		return append(segments, QuerySegment{Type: SegmentTypeSelf, KeyMode: true}), nil
+	}
+
+	// Check for standalone array notation [index] or [*]
+	standaloneArrayRegex := regexp.MustCompile(`^\[(.+)\]$`)
+	if matches := standaloneArrayRegex.FindStringSubmatch(part); matches != nil {
+		// Handle array index or wildcard
+		indexPart := matches[1]
+		if indexPart == "*" {
+			segments = append(segments, QuerySegment{
+				Type: SegmentTypeWildcard,
+			})
 		} else {
-			// Regular key access
+			index, err := strconv.Atoi(indexPart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array index: %s", indexPart)
+			}
+			segments = append(segments, QuerySegment{
+				Type:  SegmentTypeIndex,
+				Index: index,
+			})
+		}
+		return segments, nil
+	}
+
+	// Check for key with array notation key[index] or key[*]
+	keyArrayRegex := regexp.MustCompile(`^(.+?)\[(.+)\]$`)
+	if matches := keyArrayRegex.FindStringSubmatch(part); matches != nil {
+		// Handle the base part first
+		if matches[1] != "" {
 			segments = append(segments, QuerySegment{
 				Type: SegmentTypeKey,
-				Key:  part,
+				Key:  matches[1],
+			})
+		}
+
+		// Handle array index or wildcard
+		indexPart := matches[2]
+		if indexPart == "*" {
+			segments = append(segments, QuerySegment{
+				Type: SegmentTypeWildcard,
+			})
+		} else {
+			index, err := strconv.Atoi(indexPart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array index: %s", indexPart)
+			}
+			segments = append(segments, QuerySegment{
+				Type:  SegmentTypeIndex,
+				Index: index,
 			})
 		}
+	} else {
+		// Regular key access
+		segments = append(segments, QuerySegment{
+			Type: SegmentTypeKey,
+			Key:  part,
+		})
 	}
 
 	return segments, nil
 }
 
-// QuerySegment represents a single segment of a query
+// QuerySegment represents a single segment of a query. KeyMode makes a
+// SegmentTypeSelf segment (@key) yield the key, or array index, of the
+// current value instead of the value itself (@). A SegmentTypeFunction
+// segment applies Func to the value its Args query resolves to.
 type QuerySegment struct {
-	Type  SegmentType
-	Key   string
-	Index int
+	Type    SegmentType
+	Key     string
+	Index   int
+	Filter  *FilterExpr
+	KeyMode bool
+	Func    string
+	Args    []QuerySegment
 }
 
 // SegmentType represents the type of query segment
@@ -285,151 +667,651 @@ const (
 	SegmentTypeKey SegmentType = iota
 	SegmentTypeIndex
 	SegmentTypeWildcard
+	SegmentTypeFilter
+	SegmentTypeSelf
+	SegmentTypeFunction
 )
 
+// DefaultMaxDepth is the nesting depth at which navigation gives up
+const DefaultMaxDepth = 64
+
+// DefaultMaxPathSegments is the most segments a query path may have
+const DefaultMaxPathSegments = 20
+
+// errDepthExceeded is returned when a query or value nests deeper than the limit
+var errDepthExceeded = fmt.Errorf("query depth limit exceeded, possible circular reference")
+
+// errPathTooDeep is returned when a query path has more than DefaultMaxPathSegments segments
+var errPathTooDeep = fmt.Errorf("query depth limit exceeded")
+
+// SetMaxDepth changes the nesting depth limit for query paths and stored values.
+// A limit of 0 or less restores DefaultMaxDepth.
+func (hi *HierarchicalInventory) SetMaxDepth(depth int) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.maxDepth = depth
+}
+
+func (hi *HierarchicalInventory) depthLimit() int {
+	if hi.maxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return hi.maxDepth
+}
+
 // navigate recursively navigates through the data structure
 func (hi *HierarchicalInventory) navigate(data interface{}, segments []QuerySegment) (interface{}, error) {
+	if len(segments) > DefaultMaxPathSegments {
+		return nil, errPathTooDeep
+	}
+	return hi.navigateDepth(data, segments, 0)
+}
+
+// navigateDepth navigates one segment at a time, failing once depth exceeds the limit
+func (hi *HierarchicalInventory) navigateDepth(data interface{}, segments []QuerySegment, depth int) (interface{}, error) {
 	if len(segments) == 0 {
 		return data, nil
 	}
+	if depth >= hi.depthLimit() {
+		return nil, errDepthExceeded
+	}
 
 	segment := segments[0]
 	remaining := segments[1:]
 
 	switch segment.Type {
 	case SegmentTypeKey:
-		return hi.navigateKey(data, segment.Key, remaining)
+		return hi.navigateKey(data, segment.Key, remaining, depth)
 	case SegmentTypeIndex:
-		return hi.navigateIndex(data, segment.Index, remaining)
+		return hi.navigateIndex(data, segment.Index, remaining, depth)
 	case SegmentTypeWildcard:
-		return hi.navigateWildcard(data, remaining)
+		return hi.navigateWildcard(data, remaining, depth)
+	case SegmentTypeFilter:
+		return hi.navigateFilter(data, segment.Filter, remaining, depth)
+	case SegmentTypeSelf:
+		if segment.KeyMode {
+			return nil, fmt.Errorf("@key can only follow a key, index or wildcard")
+		}
+		return hi.navigateDepth(data, remaining, depth+1)
+	case SegmentTypeFunction:
+		value, err := hi.navigateDepth(data, segment.Args, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		result, err := applyQueryFunction(segment.Func, value)
+		if err != nil {
+			return nil, err
+		}
+		return hi.navigateDepth(result, remaining, depth+1)
 	default:
 		return nil, fmt.Errorf("unknown segment type")
 	}
 }
 
 // navigateKey handles key-based navigation
-func (hi *HierarchicalInventory) navigateKey(data interface{}, key string, remaining []QuerySegment) (interface{}, error) {
+func (hi *HierarchicalInventory) navigateKey(data interface{}, key string, remaining []QuerySegment, depth int) (interface{}, error) {
 	switch d := data.(type) {
 	case map[string]interface{}:
 		value, exists := d[key]
 		if !exists {
 			return nil, fmt.Errorf("key not found: %s", key)
 		}
-		return hi.navigate(value, remaining)
+		return hi.navigateChild(key, value, remaining, depth)
 	default:
 		return nil, fmt.Errorf("cannot access key %s on non-object type", key)
 	}
 }
 
 // navigateIndex handles array index navigation
-func (hi *HierarchicalInventory) navigateIndex(data interface{}, index int, remaining []QuerySegment) (interface{}, error) {
+func (hi *HierarchicalInventory) navigateIndex(data interface{}, index int, remaining []QuerySegment, depth int) (interface{}, error) {
 	switch d := data.(type) {
 	case []interface{}:
 		if index < 0 || index >= len(d) {
 			return nil, fmt.Errorf("array index out of bounds: %d", index)
 		}
-		return hi.navigate(d[index], remaining)
+		return hi.navigateChild(index, d[index], remaining, depth)
 	default:
 		return nil, fmt.Errorf("cannot access index %d on non-array type", index)
 	}
 }
 
-// navigateWildcard handles wildcard navigation
-func (hi *HierarchicalInventory) navigateWildcard(data interface{}, remaining []QuerySegment) (interface{}, error) {
+// navigateChild navigates the remaining path on a value reached through key,
+// answering a leading @key with the key itself
+func (hi *HierarchicalInventory) navigateChild(key interface{}, value interface{}, remaining []QuerySegment, depth int) (interface{}, error) {
+	if len(remaining) == 1 && remaining[0].Type == SegmentTypeSelf && remaining[0].KeyMode {
+		return key, nil
+	}
+	return hi.navigateDepth(value, remaining, depth+1)
+}
+
+// navigateWildcard handles wildcard navigation. Arrays are walked in order
+// and objects in key order; either way the results form an array.
+func (hi *HierarchicalInventory) navigateWildcard(data interface{}, remaining []QuerySegment, depth int) (interface{}, error) {
+	var keys []interface{}
+	var items []interface{}
+	switch d := data.(type) {
+	case []interface{}:
+		for i, item := range d {
+			keys = append(keys, i)
+			items = append(items, item)
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(d))
+		for name := range d {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			keys = append(keys, name)
+			items = append(items, d[name])
+		}
+	default:
+		return nil, fmt.Errorf("cannot use wildcard on non-array, non-object type")
+	}
+
+	var results []interface{}
+	for i, item := range items {
+		result, err := hi.navigateChild(keys[i], item, remaining, depth)
+		if err == errDepthExceeded {
+			return nil, err
+		}
+		if err != nil {
+			continue // Skip items that don't match the remaining path
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// navigateFilter keeps the elements of an array, or the entries of an object,
+// that match filter and navigates the remaining path on each of them.
+// Arrays yield an array and objects yield an object keyed like the original.
+func (hi *HierarchicalInventory) navigateFilter(data interface{}, filter *FilterExpr, remaining []QuerySegment, depth int) (interface{}, error) {
 	switch d := data.(type) {
 	case []interface{}:
-		var results []interface{}
-		for _, item := range d {
-			result, err := hi.navigate(item, remaining)
+		results := []interface{}{}
+		for i, item := range d {
+			if !filter.Match(item) {
+				continue
+			}
+			result, err := hi.navigateChild(i, item, remaining, depth)
+			if err == errDepthExceeded {
+				return nil, err
+			}
 			if err != nil {
-				continue // Skip items that don't match the remaining path
+				continue
 			}
 			results = append(results, result)
 		}
 		return results, nil
+	case map[string]interface{}:
+		results := make(map[string]interface{})
+		for key, item := range d {
+			if !filter.Match(item) {
+				continue
+			}
+			result, err := hi.navigateChild(key, item, remaining, depth)
+			if err == errDepthExceeded {
+				return nil, err
+			}
+			if err != nil {
+				continue
+			}
+			results[key] = result
+		}
+		return results, nil
 	default:
-		return nil, fmt.Errorf("cannot use wildcard on non-array type")
+		return nil, fmt.Errorf("cannot filter non-array, non-object type")
 	}
 }
 
+// checkValueDepth rejects values nested deeper than the limit, which is what a
+// map or slice that contains itself looks like
+func (hi *HierarchicalInventory) checkValueDepth(value interface{}, depth int) error {
+	if depth > hi.depthLimit() {
+		return errDepthExceeded
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			if err := hi.checkValueDepth(child, depth+1); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := hi.checkValueDepth(child, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Set sets a value at the specified query path
 func (hi *HierarchicalInventory) Set(query string, value interface{}) error {
+	if hi.isReadOnly() {
+		return ErrReadOnly
+	}
+
+	// Ensure data is loaded
+	if err := hi.ensureDataLoaded(); err != nil {
+		return err
+	}
+
+	if err := hi.runHooks(HookBeforeSet, query, value); err != nil {
+		return err
+	}
+
+	oldValue := hi.currentValue(query)
+	if err := hi.setLocked(query, value); err != nil {
+		return err
+	}
+
+	return hi.afterSave(func() error {
+		hi.notifyWebhook(WebhookEventSet, query, oldValue, value)
+		return hi.runHooks(HookAfterSet, query, value)
+	})
+}
+
+// setLocked sets and saves a single path under the write lock
+func (hi *HierarchicalInventory) setLocked(query string, value interface{}) error {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+
+	if err := hi.setValue(query, value); err != nil {
+		return err
+	}
+
+	return hi.saveData()
+}
+
+// SetUntracked sets and saves a value without touching the entry's _meta or
+// running hooks and webhooks. It is for bookkeeping such as a node's
+// last_connected, which is not an edit of the entry.
+func (hi *HierarchicalInventory) SetUntracked(query string, value interface{}) error {
+	if hi.isReadOnly() {
+		return ErrReadOnly
+	}
+
 	// Ensure data is loaded
 	if err := hi.ensureDataLoaded(); err != nil {
 		return err
 	}
 
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+
+	if _, err := hi.putValue(query, value); err != nil {
+		return err
+	}
+	return hi.saveData()
+}
+
+// SetBulk sets multiple paths under a single write lock and a single save.
+// Paths that fail are reported in a BulkSetError while the successful ones
+// are still committed.
+func (hi *HierarchicalInventory) SetBulk(entries map[string]interface{}) error {
+	if hi.isReadOnly() {
+		return ErrReadOnly
+	}
+
+	// Ensure data is loaded
+	if err := hi.ensureDataLoaded(); err != nil {
+		return err
+	}
+
+	// Apply paths in a stable order so parents are created before children
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := hi.runHooks(HookBeforeSet, path, entries[path]); err != nil {
+			return err
+		}
+	}
+
+	oldValues := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		oldValues[path] = hi.currentValue(path)
+	}
+
+	applied, bulkErr, err := hi.setBulkLocked(paths, entries)
+	if err != nil {
+		return err
+	}
+
+	err = hi.afterSave(func() error {
+		for _, path := range applied {
+			hi.notifyWebhook(WebhookEventSet, path, oldValues[path], entries[path])
+		}
+		for _, path := range applied {
+			if err := hi.runHooks(HookAfterSet, path, entries[path]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(bulkErr) > 0 {
+		return bulkErr
+	}
+	return nil
+}
+
+// setBulkLocked applies and saves paths under the write lock, returning the
+// paths that were set and the per-path failures
+func (hi *HierarchicalInventory) setBulkLocked(paths []string, entries map[string]interface{}) ([]string, BulkSetError, error) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+
+	var applied []string
+	var bulkErr BulkSetError
+	for _, path := range paths {
+		if err := hi.setValue(path, entries[path]); err != nil {
+			bulkErr = append(bulkErr, &PathError{Path: path, Err: err})
+			continue
+		}
+		applied = append(applied, path)
+	}
+
+	if len(applied) > 0 {
+		if err := hi.saveData(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return applied, bulkErr, nil
+}
+
+// setValue sets a value at the specified query path without saving
+func (hi *HierarchicalInventory) setValue(query string, value interface{}) error {
+	segments, err := hi.putValue(query, value)
+	if err != nil {
+		return err
+	}
+	hi.touchMeta(segments)
+	return nil
+}
+
+// putValue validates and stores a value at the specified query path without
+// saving or recording metadata, returning the parsed path
+func (hi *HierarchicalInventory) putValue(query string, value interface{}) ([]QuerySegment, error) {
 	if query == "" {
-		return fmt.Errorf("cannot set root level")
+		return nil, fmt.Errorf("cannot set root level")
 	}
 
 	segments, err := hi.parseQuery(query)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if len(segments) > DefaultMaxPathSegments {
+		return nil, errPathTooDeep
+	}
+
+	if err := hi.validateEntry(segments, value); err != nil {
+		return nil, err
+	}
+	if err := hi.validateSchema(segments, value); err != nil {
+		return nil, err
 	}
 
-	// Navigate to the parent and set the final key
+	// Navigate to the parent of the final key
+	parentMap := hi.data
+	finalSegment := segments[len(segments)-1]
 	if len(segments) == 1 {
 		// Setting at root level
-		segment := segments[0]
-		if segment.Type != SegmentTypeKey {
-			return fmt.Errorf("can only set keys at root level")
+		if finalSegment.Type != SegmentTypeKey {
+			return nil, fmt.Errorf("can only set keys at root level")
 		}
-		hi.data[segment.Key] = value
 	} else {
 		// Navigate to parent
 		parent, err := hi.navigate(hi.data, segments[:len(segments)-1])
-		if err != nil {
-			// Try to create the path if it doesn't exist
+		if err != nil || parent == nil {
+			// Try to create the path if it doesn't exist (or is a nil array slot)
 			parent, err = hi.createPath(segments[:len(segments)-1])
+			if conflict, ok := err.(*PathConflictError); ok {
+				conflict.Path = query
+			}
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 
-		// Set the final value
-		finalSegment := segments[len(segments)-1]
-		switch finalSegment.Type {
-		case SegmentTypeKey:
-			parentMap, ok := parent.(map[string]interface{})
-			if !ok {
-				return fmt.Errorf("cannot set key on non-object type")
+		if finalSegment.Type != SegmentTypeKey {
+			return nil, fmt.Errorf("can only set keys, not array indices or wildcards")
+		}
+		var ok bool
+		parentMap, ok = parent.(map[string]interface{})
+		if !ok {
+			return nil, &PathConflictError{
+				Path:     query,
+				Conflict: FormatSegments(segments[:len(segments)-1]),
+				Kind:     valueKind(parent),
+				Want:     "map",
 			}
-			parentMap[finalSegment.Key] = value
-		default:
-			return fmt.Errorf("can only set keys, not array indices or wildcards")
 		}
 	}
 
-	return hi.saveData()
+	// Set the final value, undoing it if the value now (indirectly) contains itself
+	previous, existed := parentMap[finalSegment.Key]
+	parentMap[finalSegment.Key] = value
+	if err := hi.checkValueDepth(value, len(segments)); err != nil {
+		if existed {
+			parentMap[finalSegment.Key] = previous
+		} else {
+			delete(parentMap, finalSegment.Key)
+		}
+		return nil, err
+	}
+
+	return segments, nil
 }
 
-// createPath creates a path in the data structure if it doesn't exist
-func (hi *HierarchicalInventory) createPath(segments []QuerySegment) (interface{}, error) {
-	current := hi.data
+// PathError records the failure of a single path in a bulk operation
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// BulkSetError collects the per-path failures of SetBulk
+type BulkSetError []*PathError
+
+func (e BulkSetError) Error() string {
+	messages := make([]string, len(e))
+	for i, pathErr := range e {
+		messages[i] = pathErr.Error()
+	}
+	return fmt.Sprintf("failed to set %d path(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// PathConflictError is returned when a path cannot be created because one of
+// its intermediate nodes already holds a value of another kind, e.g. setting
+// db.server1.host while db.server1 is a string
+type PathConflictError struct {
+	Path     string // the path being set
+	Conflict string // the existing intermediate path
+	Kind     string // the kind of value stored at Conflict
+	Want     string // "map" or "array"
+}
+
+func (e *PathConflictError) Error() string {
+	return fmt.Sprintf("cannot create path '%s': '%s' exists as %s value, not %s", e.Path, e.Conflict, withArticle(e.Kind), withArticle(e.Want))
+}
+
+// withArticle prefixes a value kind with "a" or "an"
+func withArticle(kind string) string {
+	if strings.IndexAny(kind, "aeiou") == 0 {
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// valueKind names the kind of a stored value for error messages
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	if _, ok := filterNumber(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
 
+// FormatSegments renders parsed segments back into query syntax. Keys
+// containing dots or brackets are quoted as ["key"].
+func FormatSegments(segments []QuerySegment) string {
+	var b strings.Builder
 	for _, segment := range segments {
-		if segment.Type != SegmentTypeKey {
-			return nil, fmt.Errorf("can only create paths with keys")
+		switch segment.Type {
+		case SegmentTypeKey:
+			if strings.ContainsAny(segment.Key, ".[]") {
+				fmt.Fprintf(&b, `["%s"]`, segment.Key)
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(segment.Key)
+		case SegmentTypeIndex:
+			fmt.Fprintf(&b, "[%d]", segment.Index)
+		case SegmentTypeWildcard:
+			b.WriteString("[*]")
+		case SegmentTypeFilter:
+			fmt.Fprintf(&b, "[?(%s)]", segment.Filter)
+		case SegmentTypeSelf:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			if segment.KeyMode {
+				b.WriteString("@key")
+			} else {
+				b.WriteString("@")
+			}
+		case SegmentTypeFunction:
+			fmt.Fprintf(&b, "%s(%s)", segment.Func, FormatSegments(segment.Args))
+		}
+	}
+	return b.String()
+}
+
+// createPath creates a path in the data structure if it doesn't exist
+func (hi *HierarchicalInventory) createPath(segments []QuerySegment) (interface{}, error) {
+	if len(segments) > DefaultMaxPathSegments {
+		return nil, errPathTooDeep
+	}
+	var current interface{} = hi.data
+
+	for i, segment := range segments {
+		// The next segment decides whether a missing child is an object or an array
+		wantArray := i+1 < len(segments) && segments[i+1].Type == SegmentTypeIndex
+		newChild := func() interface{} {
+			if wantArray {
+				return []interface{}{}
+			}
+			return make(map[string]interface{})
 		}
 
-		if _, exists := current[segment.Key]; !exists {
-			current[segment.Key] = make(map[string]interface{})
+		switch segment.Type {
+		case SegmentTypeKey:
+			parentMap, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, &PathConflictError{Conflict: FormatSegments(segments[:i]), Kind: valueKind(current), Want: "map"}
+			}
+			if child, exists := parentMap[segment.Key]; !exists || child == nil {
+				parentMap[segment.Key] = newChild()
+			}
+			current = parentMap[segment.Key]
+		case SegmentTypeIndex:
+			parentArr, ok := current.([]interface{})
+			if !ok {
+				return nil, &PathConflictError{Conflict: FormatSegments(segments[:i]), Kind: valueKind(current), Want: "array"}
+			}
+			if segment.Index < 0 {
+				return nil, fmt.Errorf("array index out of bounds: %d", segment.Index)
+			}
+			if segment.Index >= len(parentArr) {
+				// Pad with nil up to the index and store the grown array in its parent
+				grown := make([]interface{}, segment.Index+1)
+				copy(grown, parentArr)
+				if err := hi.replaceAt(segments[:i], grown); err != nil {
+					return nil, err
+				}
+				parentArr = grown
+			}
+			if parentArr[segment.Index] == nil {
+				parentArr[segment.Index] = newChild()
+			}
+			current = parentArr[segment.Index]
+		default:
+			return nil, fmt.Errorf("can only create paths with keys and array indices")
 		}
 
-		next, ok := current[segment.Key].(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("path conflict: %s is not an object", segment.Key)
+		if wantArray {
+			if _, ok := current.([]interface{}); !ok {
+				return nil, &PathConflictError{Conflict: FormatSegments(segments[:i+1]), Kind: valueKind(current), Want: "array"}
+			}
+		} else if _, ok := current.(map[string]interface{}); !ok {
+			return nil, &PathConflictError{Conflict: FormatSegments(segments[:i+1]), Kind: valueKind(current), Want: "map"}
 		}
-		current = next
 	}
 
 	return current, nil
 }
 
+// replaceAt stores value at an existing path whose parent is an object or array
+func (hi *HierarchicalInventory) replaceAt(segments []QuerySegment, value interface{}) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("cannot replace root level")
+	}
+	parent, err := hi.navigate(hi.data, segments[:len(segments)-1])
+	if err != nil {
+		return err
+	}
+	last := segments[len(segments)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if last.Type == SegmentTypeKey {
+			p[last.Key] = value
+			return nil
+		}
+	case []interface{}:
+		if last.Type == SegmentTypeIndex && last.Index >= 0 && last.Index < len(p) {
+			p[last.Index] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot replace value at this path")
+}
+
 // Delete removes a value at the specified query path
 func (hi *HierarchicalInventory) Delete(query string) error {
+	if hi.isReadOnly() {
+		return ErrReadOnly
+	}
+
 	// Ensure data is loaded
 	if err := hi.ensureDataLoaded(); err != nil {
 		return err
@@ -444,6 +1326,28 @@ func (hi *HierarchicalInventory) Delete(query string) error {
 		return err
 	}
 
+	// Hooks see the value being deleted; a missing path is left to the
+	// checks in deleteLocked to report
+	deleted := hi.currentValue(query)
+	if err := hi.runHooks(HookBeforeDelete, query, deleted); err != nil {
+		return err
+	}
+
+	if err := hi.deleteLocked(segments); err != nil {
+		return err
+	}
+
+	return hi.afterSave(func() error {
+		hi.notifyWebhook(WebhookEventDelete, query, deleted, nil)
+		return hi.runHooks(HookAfterDelete, query, deleted)
+	})
+}
+
+// deleteLocked removes the value at segments and saves the inventory
+func (hi *HierarchicalInventory) deleteLocked(segments []QuerySegment) error {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+
 	if len(segments) == 1 {
 		// Deleting at root level
 		segment := segments[0]
@@ -472,6 +1376,7 @@ func (hi *HierarchicalInventory) Delete(query string) error {
 		delete(parentMap, finalSegment.Key)
 	}
 
+	hi.dropMeta(segments)
 	return hi.saveData()
 }
 
@@ -494,6 +1399,62 @@ func (hi *HierarchicalInventory) List(query string) ([]string, error) {
 	}
 }
 
+// ListRecursive returns the full paths of every leaf value below prefix,
+// sorted by key. Maps and arrays are descended into rather than returned;
+// array elements use the [N] index syntax and keys containing dots are
+// quoted as ["key"]. Reserved "_" keys are skipped at the root.
+func (hi *HierarchicalInventory) ListRecursive(prefix string) ([]string, error) {
+	data, err := hi.Query(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if root, ok := data.(map[string]interface{}); ok && prefix == "" {
+		visible := make(map[string]interface{}, len(root))
+		for key, value := range root {
+			if !strings.HasPrefix(key, "_") {
+				visible[key] = value
+			}
+		}
+		data = visible
+	}
+
+	var paths []string
+	collectLeafPaths(prefix, data, &paths)
+	return paths, nil
+}
+
+// collectLeafPaths appends the path of every non-map, non-array value below
+// value to paths, depth first.
+func collectLeafPaths(path string, value interface{}, paths *[]string) {
+	join := func(segment string) string {
+		if strings.Contains(segment, ".") {
+			return path + `["` + segment + `"]`
+		}
+		if path == "" {
+			return segment
+		}
+		return path + "." + segment
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectLeafPaths(join(key), v[key], paths)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectLeafPaths(join(fmt.Sprintf("[%d]", i)), item, paths)
+		}
+	default:
+		*paths = append(*paths, path)
+	}
+}
+
 // GetData returns the raw data for debugging/inspection
 func (hi *HierarchicalInventory) GetData() map[string]interface{} {
 	return hi.data
@@ -549,25 +1510,185 @@ func (hi *HierarchicalInventory) LoadFromFile(filePath string, format string) er
 
 	switch format {
 	case "json":
-		return json.Unmarshal(data, &hi.data)
+		err = UnmarshalJSON5(data, &hi.data)
 	case "gob":
-		return hi.GobDecode(data)
+		err = hi.GobDecode(data)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
+	if err != nil {
+		return err
+	}
+
+	// Loaded data replaces the lazily-loaded store contents
+	hi.mu.Lock()
+	hi.loaded = true
+	hi.mu.Unlock()
+	return nil
 }
 
-// Backup creates a backup of the inventory data
+// Clone returns a deep copy of the inventory that saves to dataDir instead,
+// with the same validators and depth limit. Changes to the clone do not
+// affect the original.
+func (hi *HierarchicalInventory) Clone(dataDir string) (*HierarchicalInventory, error) {
+	if err := hi.ensureDataLoaded(); err != nil {
+		return nil, err
+	}
+
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	copied, err := NormalizeValue(hi.data)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := copied.(map[string]interface{})
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	clone := &HierarchicalInventory{
+		dataDir:        dataDir,
+		data:           data,
+		loaded:         true,
+		validators:     make(map[string]TypeValidator, len(hi.validators)),
+		requiredFields: make(map[string][]string, len(hi.requiredFields)),
+		maxDepth:       hi.maxDepth,
+		namespace:      hi.namespace,
+	}
+	for typeName, validator := range hi.validators {
+		clone.validators[typeName] = validator
+	}
+	for typeName, fields := range hi.requiredFields {
+		clone.requiredFields[typeName] = fields
+	}
+	return clone, nil
+}
+
+// Backup creates a backup-<ts>.tar.gz in the data directory holding the
+// inventory JSON and the companion files kept next to it
 func (hi *HierarchicalInventory) Backup() (string, error) {
-	backupFile := filepath.Join(hi.dataDir, fmt.Sprintf("backup-%d.json", time.Now().Unix()))
-	err := hi.SaveToFile(backupFile, "json")
+	if hi.isReadOnly() {
+		return "", ErrReadOnly
+	}
+	if err := hi.ensureDataLoaded(); err != nil {
+		return "", err
+	}
+	data, err := hi.marshalBackup()
 	if err != nil {
 		return "", err
 	}
+	backupFile := filepath.Join(hi.dataDir, fmt.Sprintf("backup-%d.tar.gz", time.Now().Unix()))
+	if err := hi.writeBackupTarball(backupFile, data); err != nil {
+		return "", err
+	}
 	return backupFile, nil
 }
 
-// Restore restores the inventory data from a backup file
+// Restore restores the inventory data from a backup file: either a tarball
+// made by Backup, whose companion files are written back to the data
+// directory, or a plain JSON backup from older versions. The format is
+// detected from the file's first bytes.
 func (hi *HierarchicalInventory) Restore(backupFile string) error {
-	return hi.LoadFromFile(backupFile, "json")
+	data, err := os.ReadFile(backupFile)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return hi.LoadFromFile(backupFile, "json")
+	}
+
+	inventoryData, companions, err := hi.readBackupTarball(data)
+	if err != nil {
+		return err
+	}
+	var restored map[string]interface{}
+	if err := UnmarshalJSON5(inventoryData, &restored); err != nil {
+		return err
+	}
+	if restored == nil {
+		restored = make(map[string]interface{})
+	}
+	if err := hi.writeBackupCompanions(companions); err != nil {
+		return err
+	}
+
+	hi.mu.Lock()
+	hi.data = restored
+	hi.loaded = true
+	hi.mu.Unlock()
+	return nil
+}
+
+// ValidateQuery checks the syntax of a query without running it. Besides the
+// errors parseQuery reports, it rejects empty segments, unbalanced brackets
+// and unterminated quoted keys, which parseQuery would silently accept.
+func ValidateQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("empty query")
+	}
+	if matches := queryFunctionRegex.FindStringSubmatch(query); matches != nil {
+		if matches[2] == "" {
+			return nil
+		}
+		return ValidateQuery(matches[2])
+	}
+
+	for i, part := range splitQueryParts(query) {
+		if part == "" {
+			return fmt.Errorf("empty segment at position %d", i+1)
+		}
+		if filterPartRegex.MatchString(part) {
+			// Filter expressions are checked by compiling them
+			if _, err := parseQueryPart(part); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkBrackets(part); err != nil {
+			return err
+		}
+		if _, err := parseQueryPart(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBrackets reports unbalanced [ ] pairs and unterminated ["..."] keys in
+// a single query part
+func checkBrackets(part string) error {
+	depth := 0
+	for i := 0; i < len(part); i++ {
+		switch part[i] {
+		case '[':
+			if i+1 < len(part) && part[i+1] == '"' {
+				end := strings.Index(part[i+2:], `"]`)
+				if end < 0 {
+					return fmt.Errorf("unterminated quoted key in '%s'", part)
+				}
+				if end == 0 {
+					return fmt.Errorf("empty quoted key in '%s'", part)
+				}
+				i += end + 3
+				continue
+			}
+			if depth > 0 {
+				return fmt.Errorf("nested brackets in '%s'", part)
+			}
+			depth++
+		case ']':
+			if depth == 0 {
+				return fmt.Errorf("unexpected ']' in '%s'", part)
+			}
+			if part[i-1] == '[' {
+				return fmt.Errorf("empty brackets in '%s'", part)
+			}
+			depth--
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unclosed '[' in '%s'", part)
+	}
+	return nil
 }
@@ -1,11 +1,17 @@
 package inventory
 
 import (
+	"bytes"
+	"encoding/gob"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHierarchicalInventory_BasicQueries(t *testing.T) {
@@ -193,8 +199,38 @@ func TestHierarchicalInventory_ArrayQueries(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "query wildcard on non-array",
-			query:   "db.[*]",
+			name:    "query wildcard on scalar",
+			query:   "db.izuna-db.[0].env.[*]",
+			wantErr: true,
+		},
+		{
+			name:     "query wildcard over object keys",
+			query:    "db.[*].@key",
+			expected: []interface{}{"izuna-db"},
+		},
+		{
+			name:     "query wildcard array indexes",
+			query:    "db.izuna-db.[*].@key",
+			expected: []interface{}{0, 1},
+		},
+		{
+			name:     "query key of entry",
+			query:    "db.izuna-db.@key",
+			expected: "izuna-db",
+		},
+		{
+			name:     "query current value",
+			query:    "db.izuna-db.[1].@.env",
+			expected: "prd",
+		},
+		{
+			name:    "query @key not last",
+			query:   "db.[*].@key.env",
+			wantErr: true,
+		},
+		{
+			name:    "query @key at root",
+			query:   "@key",
 			wantErr: true,
 		},
 	}
@@ -561,3 +597,775 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+func TestHierarchicalInventory_SetBulk(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+
+	if err := hi.Set("config.version", "1.0.0"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	err = hi.SetBulk(map[string]interface{}{
+		"servers.web.host":       "nginx.example.com",
+		"servers.web.port":       80,
+		"servers.db.host":        "postgres.example.com",
+		"config.version.invalid": "cannot nest under a string",
+		"servers.[0]":            "cannot set an index",
+	})
+
+	bulkErr, ok := err.(BulkSetError)
+	if !ok {
+		t.Fatalf("Expected BulkSetError, got %T (%v)", err, err)
+	}
+	if len(bulkErr) != 2 {
+		t.Fatalf("Expected 2 failed paths, got %d: %v", len(bulkErr), bulkErr)
+	}
+	failed := []string{bulkErr[0].Path, bulkErr[1].Path}
+	if !contains(failed, "config.version.invalid") || !contains(failed, "servers.[0]") {
+		t.Errorf("Unexpected failed paths: %v", failed)
+	}
+
+	// Successful paths are committed and persisted
+	hi2, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	for path, expected := range map[string]interface{}{
+		"servers.web.host": "nginx.example.com",
+		"servers.web.port": float64(80),
+		"servers.db.host":  "postgres.example.com",
+		"config.version":   "1.0.0",
+	} {
+		result, err := hi2.Query(path)
+		if err != nil {
+			t.Errorf("Failed to query %s: %v", path, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("Query(%s) = %v, want %v", path, result, expected)
+		}
+	}
+
+	// A fully successful bulk set returns nil
+	if err := hi.SetBulk(map[string]interface{}{"servers.cache.host": "redis.example.com"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestHierarchicalInventory_QueryMultiple(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+
+	if err := hi.SetBulk(map[string]interface{}{
+		"servers.web.host": "nginx.example.com",
+		"servers.web.port": 80,
+	}); err != nil {
+		t.Fatalf("Failed to set values: %v", err)
+	}
+
+	results, errs := hi.QueryMultiple([]string{"servers.web.host", "servers.web.port", "servers.missing", "servers.[x]"})
+	if len(results) != 2 || results["servers.web.host"] != "nginx.example.com" || results["servers.web.port"] != 80 {
+		t.Errorf("Unexpected results: %v", results)
+	}
+	if len(errs) != 2 || errs["servers.missing"] == nil || errs["servers.[x]"] == nil {
+		t.Errorf("Expected errors for the missing and invalid paths, got %v", errs)
+	}
+}
+
+func TestEntryMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalUser, originalNow := metaUser, metaNow
+	defer func() { metaUser, metaNow = originalUser, originalNow }()
+	metaUser = func() string { return "alice" }
+	metaNow = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+
+	if err := hi.Set("servers.web1", map[string]interface{}{"host": "10.0.0.1"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// A later write to a field updates updated_at only
+	metaNow = func() time.Time { return time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC) }
+	if err := hi.Set("servers.web1.host", "10.0.0.2"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	result, err := hi.Query("_meta.servers.web1")
+	if err != nil {
+		t.Fatalf("Query metadata failed: %v", err)
+	}
+	meta := result.(map[string]interface{})
+	if meta["created_at"] != "2024-01-01T00:00:00Z" || meta["created_by"] != "alice" || meta["updated_at"] != "2024-02-01T00:00:00Z" {
+		t.Errorf("Unexpected metadata: %v", meta)
+	}
+
+	// Reserved keys and type-level writes are not tracked
+	if err := hi.Set("_aliases.web", "servers.web1"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := hi.Query("_meta._aliases"); err == nil {
+		t.Error("Expected no metadata for reserved keys")
+	}
+
+	// Deleting the entry drops its metadata
+	if err := hi.Delete("servers.web1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := hi.Query("_meta.servers.web1"); err == nil {
+		t.Error("Expected metadata to be removed with the entry")
+	}
+}
+
+func TestModifiedSince(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalNow := metaNow
+	defer func() { metaNow = originalNow }()
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+
+	writes := []struct {
+		path string
+		at   time.Time
+	}{
+		{"servers.old.host", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"servers.web1.host", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"apps.api.port", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, write := range writes {
+		metaNow = func() time.Time { return write.at }
+		if err := hi.Set(write.path, "x"); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	since := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	paths, err := hi.ModifiedSince("", since)
+	if err != nil {
+		t.Fatalf("ModifiedSince failed: %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"apps.api", "servers.web1"}) {
+		t.Errorf("Unexpected entries: %v", paths)
+	}
+
+	paths, err = hi.ModifiedSince("servers", since)
+	if err != nil {
+		t.Fatalf("ModifiedSince failed: %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"servers.web1"}) {
+		t.Errorf("Unexpected entries: %v", paths)
+	}
+}
+
+func TestHierarchicalInventory_DepthLimit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+
+	// A value that references its own ancestor is rejected and not stored
+	a := map[string]interface{}{"name": "a"}
+	if err := hi.Set("a", a); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	cyclic := map[string]interface{}{"parent": a}
+	err = hi.Set("a.b", cyclic)
+	if err == nil || err.Error() != "query depth limit exceeded, possible circular reference" {
+		t.Fatalf("Expected depth limit error, got %v", err)
+	}
+	if _, err := hi.Query("a.b"); err == nil {
+		t.Error("Circular value should not have been stored")
+	}
+
+	// Queries deeper than the configured limit fail
+	hi.SetMaxDepth(3)
+	if err := hi.Set("x.y", map[string]interface{}{"z": "ok"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if result, err := hi.Query("x.y.z"); err != nil || result != "ok" {
+		t.Errorf("Expected query within the limit to succeed, got %v, %v", result, err)
+	}
+	if err := hi.Set("x.y.z", map[string]interface{}{"w": "deep"}); err == nil {
+		t.Error("Expected value nested beyond the limit to be rejected")
+	}
+	if _, err := hi.Query("x.y.z.w"); err == nil {
+		t.Error("Expected query beyond the limit to fail")
+	}
+}
+
+func TestHierarchicalInventory_PathDepthLimit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+
+	keys := make([]string, DefaultMaxPathSegments+1)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("k%d", i)
+	}
+
+	// A path at the limit can be created and read back
+	atLimit := strings.Join(keys[:DefaultMaxPathSegments], ".")
+	if err := hi.Set(atLimit, "ok"); err != nil {
+		t.Fatalf("Set at the depth limit failed: %v", err)
+	}
+	if result, err := hi.Query(atLimit); err != nil || result != "ok" {
+		t.Errorf("Expected query at the depth limit to succeed, got %v, %v", result, err)
+	}
+
+	// One more segment is rejected without creating anything
+	tooDeep := strings.Join(keys, ".")
+	if err := hi.Set("other."+tooDeep[len("k0."):], "deep"); err == nil || err.Error() != "query depth limit exceeded" {
+		t.Errorf("Expected path depth error from Set, got %v", err)
+	}
+	if _, err := hi.Query("other"); err == nil {
+		t.Error("Rejected path should not have been created")
+	}
+	if _, err := hi.Query(tooDeep); err == nil || err.Error() != "query depth limit exceeded" {
+		t.Errorf("Expected path depth error from Query, got %v", err)
+	}
+}
+
+func TestHierarchicalInventory_DeepValueWithinNestingLimit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+
+	// Values may nest deeper than a query path may reach, up to DefaultMaxDepth
+	for _, levels := range []int{DefaultMaxPathSegments + 1, DefaultMaxDepth - 2} {
+		var value interface{} = "leaf"
+		for i := 0; i < levels; i++ {
+			value = map[string]interface{}{"n": value}
+		}
+		path := fmt.Sprintf("deep.v%d", levels)
+		if err := hi.Set(path, value); err != nil {
+			t.Fatalf("Set of a value nested %d levels failed: %v", levels, err)
+		}
+
+		reloaded, err := NewHierarchicalInventory(tempDir)
+		if err != nil {
+			t.Fatalf("Failed to create inventory: %v", err)
+		}
+		if _, err := reloaded.Query(path); err != nil {
+			t.Errorf("Value nested %d levels was not saved: %v", levels, err)
+		}
+	}
+}
+
+func TestNamespacedInventory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	work, err := NewNamespacedInventory(tempDir, "work")
+	if err != nil {
+		t.Fatalf("Failed to create namespaced inventory: %v", err)
+	}
+	if err := work.Set("servers.web1.host", "10.0.0.1"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "hierarchical-inventory-work.json")); err != nil {
+		t.Errorf("Expected namespaced data file: %v", err)
+	}
+
+	// The default namespace neither sees nor loads the namespaced file
+	def, err := NewNamespacedInventory(tempDir, "")
+	if err != nil {
+		t.Fatalf("Failed to create default inventory: %v", err)
+	}
+	keys, err := def.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected an empty default inventory, got keys %v", keys)
+	}
+
+	// Reopening the namespace finds its data again
+	reopened, _ := NewNamespacedInventory(tempDir, "work")
+	if result, err := reopened.Query("servers.web1.host"); err != nil || result != "10.0.0.1" {
+		t.Errorf("Expected namespaced data after reopening, got %v, %v", result, err)
+	}
+
+	if _, err := NewNamespacedInventory(tempDir, "../escape"); err == nil {
+		t.Error("Expected invalid namespace to be rejected")
+	}
+}
+
+func TestHierarchicalInventory_QuotedKeys(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+
+	if err := hi.Set(`db["some.host.name"].port`, 5432); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := hi.Set(`db["some.host.name"].tags`, []interface{}{"a", "b"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected interface{}
+	}{
+		{`db["some.host.name"].port`, 5432},
+		{`db.["some.host.name"].port`, 5432},
+		{`["db"]["some.host.name"]["port"]`, 5432},
+		{`db["some.host.name"].tags[1]`, "b"},
+		{`db["some.host.name"]["tags"][0]`, "a"},
+	}
+	for _, tt := range tests {
+		result, err := hi.Query(tt.query)
+		if err != nil {
+			t.Errorf("Query %s failed: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("Query %s: expected %v, got %v", tt.query, tt.expected, result)
+		}
+	}
+
+	// The literal key is stored as a single key, not split on dots
+	keys, err := hi.List("db")
+	if err != nil || !reflect.DeepEqual(keys, []string{"some.host.name"}) {
+		t.Errorf("Expected a single literal key, got %v (%v)", keys, err)
+	}
+}
+
+func TestHierarchicalInventory_CreateArrayPath(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+
+	if err := hi.Set("servers.[2].host", "x"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	result, err := hi.Query("servers")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	expected := []interface{}{nil, nil, map[string]interface{}{"host": "x"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	// Existing elements are kept when the array grows, and nil slots are filled in
+	if err := hi.Set("servers[4].host", "y"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := hi.Set("servers.[0].host", "z"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	for query, want := range map[string]interface{}{
+		"servers.[0].host": "z",
+		"servers.[2].host": "x",
+		"servers.[4].host": "y",
+	} {
+		if got, err := hi.Query(query); err != nil || got != want {
+			t.Errorf("Query %s: expected %v, got %v (%v)", query, want, got, err)
+		}
+	}
+
+	// Nested arrays
+	if err := hi.Set("matrix.[1].[1].v", 1); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, err := hi.Query("matrix.[1].[1].v"); err != nil || got != 1 {
+		t.Errorf("Expected nested array value, got %v (%v)", got, err)
+	}
+
+	// Indexing into an object is a conflict
+	if err := hi.Set("servers.[2].[0].host", "bad"); err == nil {
+		t.Error("Expected path conflict when indexing into an object")
+	}
+}
+
+func TestHierarchicalInventory_ReadOnly(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	hi, err := NewHierarchicalInventory(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	hi.SetReadOnly(true)
+
+	if err := hi.Set("servers.web1.host", "10.0.0.1"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Set, got %v", err)
+	}
+	if err := hi.SetBulk(map[string]interface{}{"servers.web2.host": "10.0.0.2"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from SetBulk, got %v", err)
+	}
+	if err := hi.Delete("servers"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Delete, got %v", err)
+	}
+	if _, err := hi.Backup(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Backup, got %v", err)
+	}
+
+	// Nothing was written to the data directory
+	files, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no files in a read-only data dir, got %d", len(files))
+	}
+
+	hi.SetReadOnly(false)
+	if err := hi.Set("servers.web1.host", "10.0.0.1"); err != nil {
+		t.Errorf("Set failed after leaving read-only mode: %v", err)
+	}
+}
+
+func TestHierarchicalInventory_StaleBinaryCache(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	jsonFile := filepath.Join(tmpDir, "hierarchical-inventory.json")
+	gobFile := filepath.Join(tmpDir, "hierarchical-inventory.gob")
+	if err := os.WriteFile(jsonFile, []byte(`{"web1":"from-json"}`), 0644); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+
+	// Encode a cache holding different data, as an older tsukuyo would have
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(map[string]interface{}{"web1": "from-gob"}); err != nil {
+		t.Fatalf("Failed to encode gob: %v", err)
+	}
+
+	for name, cache := range map[string][]byte{
+		"no header":     payload.Bytes(),
+		"wrong version": append([]byte{'T', 'S', gobCacheVersion + 1}, payload.Bytes()...),
+	} {
+		if err := os.WriteFile(gobFile, cache, 0644); err != nil {
+			t.Fatalf("Failed to write gob: %v", err)
+		}
+		future := time.Now().Add(time.Hour)
+		if err := os.Chtimes(gobFile, future, future); err != nil {
+			t.Fatalf("Failed to touch gob: %v", err)
+		}
+
+		hi, err := NewHierarchicalInventory(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to create inventory: %v", err)
+		}
+		if got, err := hi.Query("web1"); err != nil || got != "from-json" {
+			t.Errorf("%s: expected the JSON value, got %v (%v)", name, got, err)
+		}
+
+		// The stale cache was replaced by a current one
+		data, err := os.ReadFile(gobFile)
+		if err != nil {
+			t.Fatalf("%s: expected a rebuilt cache: %v", name, err)
+		}
+		if _, ok := stripGobHeader(data); !ok {
+			t.Errorf("%s: rebuilt cache has no current header", name)
+		}
+	}
+
+	// The rebuilt cache loads on the next start
+	hi, err := NewHierarchicalInventory(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	if got, err := hi.Query("web1"); err != nil || got != "from-json" {
+		t.Errorf("Expected the rebuilt cache value, got %v (%v)", got, err)
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	valid := []string{
+		"db",
+		"db.server1.host",
+		"servers[0].host",
+		"servers.[*].host",
+		"servers.[-1]",
+		`db["some.host.name"].port`,
+		`db["a[b]"]`,
+		"matrix.[1].[2]",
+	}
+	for _, query := range valid {
+		if err := ValidateQuery(query); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", query, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                 "empty query",
+		"db..host":         "empty segment at position 2",
+		".db":              "empty segment at position 1",
+		"db.":              "empty segment at position 2",
+		"servers[0":        "unclosed '['",
+		"servers0]":        "unexpected ']'",
+		"servers[]":        "empty brackets",
+		"servers[[0]]":     "nested brackets",
+		"servers[x]":       "invalid array index: x",
+		`db["unterminated`: "unterminated quoted key",
+		`db[""]`:           "empty quoted key",
+	}
+	for query, want := range invalid {
+		err := ValidateQuery(query)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q to fail with %q, got %v", query, want, err)
+		}
+	}
+}
+
+func TestHierarchicalInventory_PathConflict(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set("servers.web1", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if err := hi.Set("servers.pool", []interface{}{"a"}); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	cases := map[string]string{
+		"servers.web1.host":        "cannot create path 'servers.web1.host': 'servers.web1' exists as a string value, not a map",
+		"servers.web1.net.ip":      "cannot create path 'servers.web1.net.ip': 'servers.web1' exists as a string value, not a map",
+		"servers.pool.size":        "cannot create path 'servers.pool.size': 'servers.pool' exists as an array value, not a map",
+		"servers.[0].host":         "cannot create path 'servers.[0].host': 'servers' exists as a map value, not an array",
+		`servers["web1"].tags.[0]`: "cannot create path 'servers[\"web1\"].tags.[0]': 'servers.web1' exists as a string value, not a map",
+	}
+	for path, want := range cases {
+		err := hi.Set(path, "x")
+		var conflict *PathConflictError
+		if !errors.As(err, &conflict) {
+			t.Errorf("Set %s: expected PathConflictError, got %v", path, err)
+			continue
+		}
+		if err.Error() != want {
+			t.Errorf("Set %s:\n got  %s\n want %s", path, err, want)
+		}
+	}
+
+	if result, _ := hi.Query("servers.web1"); result != "10.0.0.1" {
+		t.Errorf("Expected conflicting value to be kept, got %v", result)
+	}
+}
+
+func TestHierarchicalInventory_ListRecursive(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set("servers", map[string]interface{}{
+		"server2": map[string]interface{}{"host": "10.0.0.2"},
+		"server1": map[string]interface{}{"host": "10.0.0.1", "port": 5432, "tags": []interface{}{"prod", "sql"}},
+	}); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if err := hi.Set(`apps["web.internal"]`, "web"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	paths, err := hi.ListRecursive("servers")
+	if err != nil {
+		t.Fatalf("ListRecursive failed: %v", err)
+	}
+	expected := []string{
+		"servers.server1.host",
+		"servers.server1.port",
+		"servers.server1.tags.[0]",
+		"servers.server1.tags.[1]",
+		"servers.server2.host",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+
+	paths, err = hi.ListRecursive("")
+	if err != nil {
+		t.Fatalf("ListRecursive failed: %v", err)
+	}
+	if paths[0] != `apps["web.internal"]` || len(paths) != 6 {
+		t.Errorf("Expected reserved keys to be skipped at the root, got %v", paths)
+	}
+
+	paths, err = hi.ListRecursive("servers.server2.host")
+	if err != nil || !reflect.DeepEqual(paths, []string{"servers.server2.host"}) {
+		t.Errorf("Expected a leaf to list itself, got %v (%v)", paths, err)
+	}
+
+	if _, err := hi.ListRecursive("missing"); err == nil {
+		t.Error("Expected an error for a missing prefix")
+	}
+}
+
+func TestHierarchicalInventory_AtomicSave(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set("servers.web1", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	jsonFile := filepath.Join(tempDir, "hierarchical-inventory.json")
+	if _, err := os.Stat(jsonFile + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temp file to be renamed away, got %v", err)
+	}
+	saved, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read inventory file: %v", err)
+	}
+
+	// A write that cannot complete leaves the previous file intact
+	if err := os.Mkdir(jsonFile+".tmp", 0755); err != nil {
+		t.Fatalf("Failed to block temp file: %v", err)
+	}
+	if err := hi.Set("servers.web2", "10.0.0.2"); err == nil {
+		t.Error("Expected the save to fail")
+	}
+	current, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read inventory file: %v", err)
+	}
+	if string(current) != string(saved) {
+		t.Errorf("Expected inventory file to be unchanged, got %s", current)
+	}
+}
+
+func TestHierarchicalInventory_MapWildcardKeys(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	hi.data = map[string]interface{}{
+		"servers": map[string]interface{}{
+			"server2": map[string]interface{}{"host": "10.0.0.2", "port": float64(8080)},
+			"server1": map[string]interface{}{"host": "10.0.0.1", "port": float64(22)},
+		},
+	}
+	hi.loaded = true
+
+	keys, err := hi.Query("servers.[*].@key")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []interface{}{"server1", "server2"}) {
+		t.Errorf("Expected sorted keys, got %v", keys)
+	}
+
+	hosts, err := hi.Query("servers.[*].host")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if !reflect.DeepEqual(hosts, []interface{}{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("Expected hosts in key order, got %v", hosts)
+	}
+
+	filtered, err := hi.Query("servers[?(@.port > 1000)].@key")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if !reflect.DeepEqual(filtered, map[string]interface{}{"server2": "server2"}) {
+		t.Errorf("Unexpected filtered keys: %v", filtered)
+	}
+
+	if err := hi.Set("servers.@key", "x"); err == nil {
+		t.Error("Expected Set to reject @key")
+	}
+}
@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HooksKey is the reserved top-level key holding hook commands, keyed by
+// "<event>:<path pattern>", e.g. "before-set:db.*" or "after-delete:node.*".
+const HooksKey = "_hooks"
+
+// Hook events
+const (
+	HookBeforeSet    = "before-set"
+	HookAfterSet     = "after-set"
+	HookBeforeDelete = "before-delete"
+	HookAfterDelete  = "after-delete"
+)
+
+// runHook executes a hook command through /bin/sh with the given extra environment
+var runHook = func(command string, env []string) error {
+	c := exec.Command("/bin/sh", "-c", command)
+	c.Env = append(os.Environ(), env...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+// SetHooksEnabled turns execution of _hooks commands on or off. Hooks are
+// off by default so library users never run shell commands by accident.
+func (hi *HierarchicalInventory) SetHooksEnabled(enabled bool) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.hooksEnabled = enabled
+}
+
+// matchingHooks returns the commands registered for event whose pattern
+// matches path, sorted by pattern. Patterns use shell globbing, so "db.*"
+// matches "db.server1" and "db.server1.host". Writes to reserved keys never
+// trigger hooks.
+func (hi *HierarchicalInventory) matchingHooks(event, path string) []string {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	if !hi.hooksEnabled || strings.HasPrefix(path, "_") {
+		return nil
+	}
+	hooks, ok := hi.data[HooksKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var patterns []string
+	for key := range hooks {
+		hookEvent, pattern, found := strings.Cut(key, ":")
+		if !found || hookEvent != event {
+			continue
+		}
+		if matched, err := filepath.Match(pattern, path); err == nil && matched {
+			patterns = append(patterns, key)
+		}
+	}
+	sort.Strings(patterns)
+
+	var commands []string
+	for _, key := range patterns {
+		if command, ok := hooks[key].(string); ok && command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// runHooks runs the hooks of event matching path, passing the path and value
+// in TSUKUYO_HOOK_PATH and TSUKUYO_HOOK_VALUE. Strings are passed as is,
+// other values as JSON. The first failing hook stops the rest.
+func (hi *HierarchicalInventory) runHooks(event, path string, value interface{}) error {
+	commands := hi.matchingHooks(event, path)
+	if len(commands) == 0 {
+		return nil
+	}
+
+	hookValue, ok := value.(string)
+	if !ok && value != nil {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%s hook: %v", event, err)
+		}
+		hookValue = string(raw)
+	}
+	env := []string{
+		"TSUKUYO_HOOK_EVENT=" + event,
+		"TSUKUYO_HOOK_PATH=" + path,
+		"TSUKUYO_HOOK_VALUE=" + hookValue,
+	}
+
+	for _, command := range commands {
+		if err := runHook(command, env); err != nil {
+			return fmt.Errorf("%s hook '%s' failed for %s: %v", event, command, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package inventory
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+type recordedHook struct {
+	command string
+	env     []string
+}
+
+func stubRunHook(t *testing.T, fail string) *[]recordedHook {
+	t.Helper()
+	var calls []recordedHook
+	original := runHook
+	runHook = func(command string, env []string) error {
+		calls = append(calls, recordedHook{command: command, env: env})
+		if command == fail {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+	t.Cleanup(func() { runHook = original })
+	return &calls
+}
+
+func TestHierarchicalInventory_Hooks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set(HooksKey, map[string]interface{}{
+		"before-set:db.*":      "echo before",
+		"after-set:db.*":       "echo after",
+		"after-delete:node.*":  "notify.sh",
+		"before-set:servers.*": "exit 1",
+	}); err != nil {
+		t.Fatalf("Failed to set hooks: %v", err)
+	}
+
+	calls := stubRunHook(t, "exit 1")
+
+	// Hooks are disabled by default
+	if err := hi.Set("db.pg.host", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("Expected no hooks while disabled, got %v", *calls)
+	}
+
+	hi.SetHooksEnabled(true)
+	if err := hi.Set("db.pg", map[string]interface{}{"host": "10.0.0.2"}); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if len(*calls) != 2 || (*calls)[0].command != "echo before" || (*calls)[1].command != "echo after" {
+		t.Fatalf("Expected before and after hooks, got %v", *calls)
+	}
+	env := strings.Join((*calls)[0].env, "\n")
+	if !strings.Contains(env, "TSUKUYO_HOOK_PATH=db.pg") || !strings.Contains(env, `TSUKUYO_HOOK_VALUE={"host":"10.0.0.2"}`) {
+		t.Errorf("Unexpected hook environment: %v", (*calls)[0].env)
+	}
+
+	// A failing before hook aborts the write
+	*calls = nil
+	if err := hi.Set("servers.web1", "10.0.1.1"); err == nil {
+		t.Error("Expected failing before-set hook to abort the set")
+	}
+	if _, err := hi.Query("servers.web1"); err == nil {
+		t.Error("Expected servers.web1 not to be set")
+	}
+
+	// Delete hooks receive the deleted value
+	*calls = nil
+	if err := hi.Set("node.web1", "10.0.2.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if err := hi.Delete("node.web1"); err != nil {
+		t.Fatalf("Failed to delete value: %v", err)
+	}
+	if len(*calls) != 1 || (*calls)[0].command != "notify.sh" {
+		t.Fatalf("Expected after-delete hook, got %v", *calls)
+	}
+	if !strings.Contains(strings.Join((*calls)[0].env, "\n"), "TSUKUYO_HOOK_VALUE=10.0.2.1") {
+		t.Errorf("Unexpected hook environment: %v", (*calls)[0].env)
+	}
+
+	// Writes to reserved keys never trigger hooks
+	*calls = nil
+	if err := hi.Set(HooksKey+`["after-set:*"]`, "echo any"); err != nil {
+		t.Fatalf("Failed to set hook: %v", err)
+	}
+	if err := hi.Set(`_comments["db.pg"]`, "primary"); err != nil {
+		t.Fatalf("Failed to set comment: %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("Expected no hooks for reserved keys, got %v", *calls)
+	}
+}
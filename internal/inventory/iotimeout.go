@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultIOTimeout bounds each load or save of the inventory files unless
+// SetIOTimeout chooses another limit
+const DefaultIOTimeout = 30 * time.Second
+
+// ErrIOTimeout is returned when reading or writing an inventory file takes
+// longer than the I/O timeout, e.g. on a hung NFS mount
+var ErrIOTimeout = errors.New("inventory I/O timed out")
+
+// readFile and writeFile do the actual file I/O
+var (
+	readFile  = os.ReadFile
+	writeFile = writeFileAtomic
+)
+
+// SetIOTimeout sets how long a single load or save may take before failing
+// with ErrIOTimeout. Zero or a negative duration restores DefaultIOTimeout.
+func (hi *HierarchicalInventory) SetIOTimeout(timeout time.Duration) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.ioTimeout = timeout
+}
+
+// ioContext returns a context that expires after the inventory's I/O timeout.
+// Callers must already hold hi.mu.
+func (hi *HierarchicalInventory) ioContext() (context.Context, context.CancelFunc) {
+	timeout := hi.ioTimeout
+	if timeout <= 0 {
+		timeout = DefaultIOTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// readFileContext reads path, giving up with ErrIOTimeout once ctx is done.
+// A blocked read cannot be interrupted, so it is left to finish on its own.
+func readFileContext(ctx context.Context, path string) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := readFile(path)
+		done <- result{data, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("reading %s: %w", path, ErrIOTimeout)
+	}
+}
+
+// writeFileContext atomically writes data to path, giving up with
+// ErrIOTimeout once ctx is done. A write that is still blocked may complete
+// later, but because it goes through a temp file and rename the file is
+// never left half-written.
+func writeFileContext(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- writeFile(path, data, perm)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("writing %s: %w", path, ErrIOTimeout)
+	}
+}
@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHierarchicalInventory_IOTimeout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "hierarchical-inventory.json"), []byte(`{"servers":{"web1":"10.0.0.1"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write inventory: %v", err)
+	}
+
+	// A read that never returns, as on a hung NFS mount
+	release := make(chan struct{})
+	defer close(release)
+	originalRead, originalWrite := readFile, writeFile
+	defer func() { readFile, writeFile = originalRead, originalWrite }()
+	readFile = func(path string) ([]byte, error) {
+		<-release
+		return nil, errors.New("released")
+	}
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	hi.SetIOTimeout(20 * time.Millisecond)
+
+	if _, err := hi.Query("servers.web1"); !errors.Is(err, ErrIOTimeout) {
+		t.Fatalf("Expected ErrIOTimeout on a hung read, got %v", err)
+	}
+
+	// The timed out load is not mistaken for an empty inventory
+	readFile = originalRead
+	result, err := hi.Query("servers.web1")
+	if err != nil || result != "10.0.0.1" {
+		t.Fatalf("Expected the inventory to load once the disk responds, got %v (%v)", result, err)
+	}
+
+	writeFile = func(path string, data []byte, perm os.FileMode) error {
+		<-release
+		return nil
+	}
+	if err := hi.Set("servers.web2", "10.0.0.2"); !errors.Is(err, ErrIOTimeout) {
+		t.Errorf("Expected ErrIOTimeout on a hung write, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// UnmarshalJSON5 decodes JSON that may contain JSON5-style // and /* */
+// comments and trailing commas, as left behind when hand-editing inventory
+// files. Plain JSON is decoded directly; the relaxed syntax is only stripped
+// after a first parse failure.
+func UnmarshalJSON5(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+	if !looksLikeJSON5(data) {
+		return err
+	}
+	if relaxedErr := json.Unmarshal(stripJSON5(data), v); relaxedErr != nil {
+		return err
+	}
+	return nil
+}
+
+// looksLikeJSON5 reports whether data contains comments or a trailing comma
+func looksLikeJSON5(data []byte) bool {
+	return !bytes.Equal(stripJSON5(data), data)
+}
+
+// stripJSON5 removes comments and trailing commas outside of strings
+func stripJSON5(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out.WriteByte('\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+			out.WriteByte(' ')
+		case c == ',' && closesAfterComma(data[i+1:]):
+			// drop trailing comma
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
+
+// closesAfterComma reports whether the next significant character in rest,
+// skipping whitespace and comments, closes an object or array
+func closesAfterComma(rest []byte) bool {
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == '/' && i+1 < len(rest) && rest[i+1] == '/':
+			for i < len(rest) && rest[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(rest) && rest[i+1] == '*':
+			end := bytes.Index(rest[i+2:], []byte("*/"))
+			if end < 0 {
+				return false
+			}
+			i += end + 3
+		default:
+			return c == '}' || c == ']'
+		}
+	}
+	return false
+}
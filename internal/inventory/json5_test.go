@@ -0,0 +1,105 @@
+package inventory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalJSON5(t *testing.T) {
+	input := `{
+  // production databases
+  "db": {
+    "server1": {
+      "host": "db1.internal", /* primary */
+      "url": "postgres://user@host/db?x=1//not-a-comment",
+      "note": "keep /* this */ and, this,",
+      "tags": ["prod", "sql",],
+    },
+  },
+}
+`
+	var got map[string]interface{}
+	if err := UnmarshalJSON5([]byte(input), &got); err != nil {
+		t.Fatalf("UnmarshalJSON5 failed: %v", err)
+	}
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"server1": map[string]interface{}{
+				"host": "db1.internal",
+				"url":  "postgres://user@host/db?x=1//not-a-comment",
+				"note": "keep /* this */ and, this,",
+				"tags": []interface{}{"prod", "sql"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Broken JSON still reports the original error
+	if err := UnmarshalJSON5([]byte(`{"a": }`), &got); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+	if err := UnmarshalJSON5([]byte("{\"a\": } // comment"), &got); err == nil {
+		t.Error("Expected an error for invalid JSON with a comment")
+	}
+}
+
+func TestLoadInventoryWithComments(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	content := "{\n  // edited by hand\n  \"servers\": {\"web1\": {\"host\": \"10.0.0.1\",},},\n}\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "hierarchical-inventory.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write inventory: %v", err)
+	}
+
+	hi, err := NewHierarchicalInventory(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	if got, err := hi.Query("servers.web1.host"); err != nil || got != "10.0.0.1" {
+		t.Errorf("Expected host from the commented file, got %v (%v)", got, err)
+	}
+}
+
+func TestLoadInventorySyntaxError(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	content := "{\n  \"servers\": {\n    # web tier\n    \"web1\": {\"host\": \"10.0.0.1\"}\n  }\n}\n"
+	path := filepath.Join(tmpDir, "hierarchical-inventory.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write inventory: %v", err)
+	}
+
+	hi, err := NewHierarchicalInventory(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create inventory: %v", err)
+	}
+	_, err = hi.Query("servers")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 3 {
+		t.Fatalf("Expected a syntax error on line 3, got %v", err)
+	}
+	if err.Error() != "Syntax error in hierarchical-inventory.json at line 3: invalid character '#' looking for beginning of object key string" {
+		t.Errorf("Unexpected message: %v", err)
+	}
+
+	// The broken file must not be replaced by an empty inventory
+	if err := hi.Set("servers.web2.host", "10.0.0.2"); err == nil {
+		t.Error("Expected Set to fail while the file has a syntax error")
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Error("Expected the broken file to be left untouched")
+	}
+}
@@ -0,0 +1,119 @@
+package inventory
+
+import (
+	"os"
+	"os/user"
+	"sort"
+	"time"
+)
+
+// MetaKey is the reserved top-level key holding per-entry metadata. The
+// metadata of "<type>.<name>" lives at "_meta.<type>.<name>".
+const MetaKey = "_meta"
+
+// metaUser returns the name recorded as created_by
+var metaUser = func() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// metaNow returns the time recorded in created_at/updated_at
+var metaNow = time.Now
+
+// entrySegments returns the "<type>.<name>" prefix of a path, or false if the
+// path does not reach an entry or points into a reserved key
+func entrySegments(segments []QuerySegment) ([2]string, bool) {
+	if len(segments) < 2 || segments[0].Type != SegmentTypeKey || segments[1].Type != SegmentTypeKey {
+		return [2]string{}, false
+	}
+	if len(segments[0].Key) > 0 && segments[0].Key[0] == '_' {
+		return [2]string{}, false
+	}
+	return [2]string{segments[0].Key, segments[1].Key}, true
+}
+
+// touchMeta records a write to the entry a path belongs to. created_at and
+// created_by are set on the first write, updated_at on every write.
+func (hi *HierarchicalInventory) touchMeta(segments []QuerySegment) {
+	entry, ok := entrySegments(segments)
+	if !ok {
+		return
+	}
+
+	meta, _ := hi.data[MetaKey].(map[string]interface{})
+	if meta == nil {
+		meta = make(map[string]interface{})
+		hi.data[MetaKey] = meta
+	}
+	typeMeta, _ := meta[entry[0]].(map[string]interface{})
+	if typeMeta == nil {
+		typeMeta = make(map[string]interface{})
+		meta[entry[0]] = typeMeta
+	}
+	entryMeta, _ := typeMeta[entry[1]].(map[string]interface{})
+	if entryMeta == nil {
+		entryMeta = make(map[string]interface{})
+		typeMeta[entry[1]] = entryMeta
+	}
+
+	now := metaNow().UTC().Format(time.RFC3339)
+	if _, ok := entryMeta["created_at"]; !ok {
+		entryMeta["created_at"] = now
+		entryMeta["created_by"] = metaUser()
+	}
+	entryMeta["updated_at"] = now
+}
+
+// dropMeta removes the metadata of a deleted type or entry. Deleting a field
+// inside an entry counts as a write to that entry.
+func (hi *HierarchicalInventory) dropMeta(segments []QuerySegment) {
+	if len(segments) > 2 {
+		hi.touchMeta(segments)
+		return
+	}
+	meta, ok := hi.data[MetaKey].(map[string]interface{})
+	if !ok || segments[0].Type != SegmentTypeKey {
+		return
+	}
+	if len(segments) == 1 {
+		delete(meta, segments[0].Key)
+		return
+	}
+	if typeMeta, ok := meta[segments[0].Key].(map[string]interface{}); ok && segments[1].Type == SegmentTypeKey {
+		delete(typeMeta, segments[1].Key)
+	}
+}
+
+// ModifiedSince returns the "<type>.<name>" paths of the entries whose
+// updated_at is at or after since, sorted. An empty typeName checks every
+// type. Entries without metadata are never returned.
+func (hi *HierarchicalInventory) ModifiedSince(typeName string, since time.Time) ([]string, error) {
+	if err := hi.ensureDataLoaded(); err != nil {
+		return nil, err
+	}
+
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	meta, _ := hi.data[MetaKey].(map[string]interface{})
+	var paths []string
+	for metaType, value := range meta {
+		if typeName != "" && metaType != typeName {
+			continue
+		}
+		typeMeta, _ := value.(map[string]interface{})
+		for name, entry := range typeMeta {
+			entryMeta, _ := entry.(map[string]interface{})
+			updated, _ := entryMeta["updated_at"].(string)
+			updatedAt, err := time.Parse(time.RFC3339, updated)
+			if err != nil || updatedAt.Before(since) {
+				continue
+			}
+			paths = append(paths, metaType+"."+name)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,46 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MigratedKey is the reserved top-level key set once the legacy
+// *-inventory.json files have been merged into the hierarchical store
+const MigratedKey = "_migrated"
+
+// migrationNotice receives the one-time message printed when legacy files are migrated
+var migrationNotice io.Writer = os.Stderr
+
+// migrateLegacyFiles merges legacy *-inventory.json files into the default
+// inventory the first time it is loaded, that is when hierarchical-inventory.json
+// does not exist yet and _migrated is not set, and saves the result so later
+// runs read the hierarchical store directly. A read-only inventory only loads
+// the legacy files into memory.
+func (hi *HierarchicalInventory) migrateLegacyFiles(ctx context.Context) error {
+	if hi.namespace != "" {
+		return nil // legacy files belong to the default namespace only
+	}
+	if migrated, _ := hi.data[MigratedKey].(bool); migrated {
+		return nil
+	}
+	if _, err := os.Stat(hi.storeFile(".json")); err == nil {
+		return nil
+	}
+	if len(hi.legacyInventoryFiles()) == 0 {
+		return nil
+	}
+
+	if err := hi.loadFromMultipleFiles(ctx); err != nil {
+		return err
+	}
+	if hi.readOnly {
+		return nil
+	}
+
+	fmt.Fprintln(migrationNotice, "Migrating legacy inventory files…")
+	hi.data[MigratedKey] = true
+	return hi.saveData()
+}
@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHierarchicalInventory_AutoMigrateLegacyFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var notice bytes.Buffer
+	originalNotice := migrationNotice
+	migrationNotice = &notice
+	defer func() { migrationNotice = originalNotice }()
+
+	legacy := `{"main": {"host": "pg.example.com"}}`
+	if err := os.WriteFile(filepath.Join(tempDir, "cache-inventory.json"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy inventory: %v", err)
+	}
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if result, err := hi.Query("cache.main.host"); err != nil || result != "pg.example.com" {
+		t.Fatalf("Expected migrated value, got %v, %v", result, err)
+	}
+	if notice.String() != "Migrating legacy inventory files…\n" {
+		t.Errorf("Unexpected migration notice: %q", notice.String())
+	}
+
+	// The merged data and the _migrated flag are saved to the hierarchical store
+	data, err := os.ReadFile(filepath.Join(tempDir, "hierarchical-inventory.json"))
+	if err != nil {
+		t.Fatalf("Expected hierarchical-inventory.json to be written: %v", err)
+	}
+	if !bytes.Contains(data, []byte(`"_migrated": true`)) || !bytes.Contains(data, []byte("pg.example.com")) {
+		t.Errorf("Unexpected hierarchical store: %s", data)
+	}
+
+	// Later runs do not migrate again, even if the legacy file changes
+	notice.Reset()
+	if err := os.WriteFile(filepath.Join(tempDir, "cache-inventory.json"), []byte(`{"main": {"host": "changed"}}`), 0644); err != nil {
+		t.Fatalf("Failed to rewrite legacy inventory: %v", err)
+	}
+	hi, _ = NewHierarchicalInventory(tempDir)
+	if result, _ := hi.Query("cache.main.host"); result != "pg.example.com" {
+		t.Errorf("Expected legacy file to be ignored after migration, got %v", result)
+	}
+	if notice.Len() != 0 {
+		t.Errorf("Expected no notice on later runs, got %q", notice.String())
+	}
+}
+
+func TestHierarchicalInventory_AutoMigrateReadOnly(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "db-inventory.json"), []byte(`{"main": "pg"}`), 0644); err != nil {
+		t.Fatalf("Failed to write legacy inventory: %v", err)
+	}
+
+	hi, _ := NewHierarchicalInventory(tempDir)
+	hi.SetReadOnly(true)
+	if result, err := hi.Query("db.main"); err != nil || result != "pg" {
+		t.Fatalf("Expected legacy value in memory, got %v, %v", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "hierarchical-inventory.json")); !os.IsNotExist(err) {
+		t.Error("Expected read-only inventory not to write hierarchical-inventory.json")
+	}
+}
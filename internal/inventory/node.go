@@ -0,0 +1,42 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// NodeInventoryEntry represents an SSH node entry in the inventory.
+type NodeInventoryEntry struct {
+	Name           string   `json:"name,omitempty"`
+	Host           string   `json:"host"`
+	Type           string   `json:"type,omitempty"` // e.g., "ssh"
+	User           string   `json:"user,omitempty"`
+	Port           int      `json:"port,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	SSHFingerprint string   `json:"ssh_fingerprint,omitempty"`
+	OS             string   `json:"os,omitempty"`             // e.g., "linux", "darwin", "windows"
+	OSVersion      string   `json:"os_version,omitempty"`     // e.g., kernel release "6.1.0-18-amd64"
+	ForwardAgent   bool     `json:"forward_agent,omitempty"`  // Always connect with ssh -A
+	LastConnected  string   `json:"last_connected,omitempty"` // RFC3339 time of the last successful connection
+}
+
+// ParseNodeEntry converts a stored node entry into a NodeInventoryEntry.
+// Like ParseDbEntry it accepts both the struct and its generic JSON form.
+func ParseNodeEntry(data interface{}) (NodeInventoryEntry, error) {
+	switch v := data.(type) {
+	case NodeInventoryEntry:
+		return v, nil
+	case *NodeInventoryEntry:
+		return *v, nil
+	}
+
+	var entry NodeInventoryEntry
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entry, fmt.Errorf("invalid node entry: %v", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,33 @@
+package inventory
+
+import (
+	"testing"
+)
+
+func TestParseNodeEntry(t *testing.T) {
+	generic := map[string]interface{}{
+		"name":       "web1",
+		"host":       "10.0.0.5",
+		"user":       "deploy",
+		"port":       float64(2222),
+		"tags":       []interface{}{"web"},
+		"os":         "linux",
+		"os_version": "6.1.0-18-amd64",
+	}
+	entry, err := ParseNodeEntry(generic)
+	if err != nil {
+		t.Fatalf("Failed to parse generic node entry: %v", err)
+	}
+	if entry.Host != "10.0.0.5" || entry.Port != 2222 || entry.OS != "linux" || entry.OSVersion != "6.1.0-18-amd64" {
+		t.Errorf("Unexpected node entry: %+v", entry)
+	}
+
+	entry, err = ParseNodeEntry(&NodeInventoryEntry{Host: "mac1", OS: "darwin"})
+	if err != nil || entry.OS != "darwin" {
+		t.Errorf("Expected struct pointer to parse, got %+v, %v", entry, err)
+	}
+
+	if _, err := ParseNodeEntry(map[string]interface{}{"port": "not-a-number"}); err == nil {
+		t.Error("Expected error for invalid port type")
+	}
+}
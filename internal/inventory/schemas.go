@@ -0,0 +1,116 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemasKey is the reserved top-level key holding JSON Schema documents,
+// one per inventory type
+const SchemasKey = "_schemas"
+
+// CompileSchema compiles a JSON Schema document given in its generic JSON form
+func CompileSchema(doc interface{}) (*jsonschema.Schema, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	schema, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return schema, nil
+}
+
+// schemaFor returns the compiled schema stored for a type, or nil if none is stored
+func (hi *HierarchicalInventory) schemaFor(typeName string) (*jsonschema.Schema, error) {
+	schemas, ok := hi.data[SchemasKey].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	doc, ok := schemas[typeName]
+	if !ok {
+		return nil, nil
+	}
+	return CompileSchema(doc)
+}
+
+// validateSchema checks an entry-level value against the schema stored for
+// its type. Like validateEntry, only "<type>.<name>" paths are checked.
+// Setting "_schemas.<type>" itself checks that the document compiles.
+func (hi *HierarchicalInventory) validateSchema(segments []QuerySegment, value interface{}) error {
+	if len(segments) != 2 || segments[0].Type != SegmentTypeKey || segments[1].Type != SegmentTypeKey {
+		return nil
+	}
+
+	normalized, err := NormalizeValue(value)
+	if err != nil {
+		return err
+	}
+
+	if segments[0].Key == SchemasKey {
+		_, err := CompileSchema(normalized)
+		return err
+	}
+
+	schema, err := hi.schemaFor(segments[0].Key)
+	if err != nil || schema == nil {
+		return err
+	}
+
+	if err := schema.Validate(normalized); err != nil {
+		return fmt.Errorf("%s entry '%s' does not match schema: %v", segments[0].Key, segments[1].Key, err)
+	}
+	return nil
+}
+
+// ValidateType validates every existing entry of a type against its stored
+// schema and returns the failures sorted by path
+func (hi *HierarchicalInventory) ValidateType(typeName string) ([]*PathError, error) {
+	if err := hi.ensureDataLoaded(); err != nil {
+		return nil, err
+	}
+
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	schema, err := hi.schemaFor(typeName)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("no schema defined for type '%s'", typeName)
+	}
+
+	entries, ok := hi.data[typeName].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failures []*PathError
+	for _, name := range names {
+		normalized, err := NormalizeValue(entries[name])
+		if err == nil {
+			err = schema.Validate(normalized)
+		}
+		if err != nil {
+			failures = append(failures, &PathError{Path: typeName + "." + name, Err: err})
+		}
+	}
+	return failures, nil
+}
@@ -0,0 +1,68 @@
+package inventory
+
+import (
+	"os"
+	"testing"
+)
+
+func TestHierarchicalInventory_SchemaValidation(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+
+	// Entries set before a schema exists are not checked
+	if err := hi.Set("node.legacy", map[string]interface{}{"port": 22}); err != nil {
+		t.Fatalf("Failed to set entry without schema: %v", err)
+	}
+
+	// Invalid schema documents are rejected
+	if err := hi.Set("_schemas.node", map[string]interface{}{"type": 42}); err == nil {
+		t.Error("Expected invalid schema to be rejected")
+	}
+
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"host"},
+		"properties": map[string]interface{}{
+			"host": map[string]interface{}{"type": "string"},
+			"port": map[string]interface{}{"type": "integer"},
+		},
+	}
+	if err := hi.Set("_schemas.node", schema); err != nil {
+		t.Fatalf("Failed to set schema: %v", err)
+	}
+
+	if err := hi.Set("node.web1", map[string]interface{}{"host": "web1", "port": 22}); err != nil {
+		t.Errorf("Expected valid entry to pass schema validation: %v", err)
+	}
+	if err := hi.Set("node.web2", map[string]interface{}{"port": 22}); err == nil {
+		t.Error("Expected entry missing 'host' to fail schema validation")
+	}
+	if _, err := hi.Query("node.web2"); err == nil {
+		t.Error("Rejected entry should not be stored")
+	}
+
+	// Partial updates below the entry level are not validated
+	if err := hi.Set("node.web1.port", "ssh"); err != nil {
+		t.Errorf("Expected partial update to be allowed: %v", err)
+	}
+
+	failures, err := hi.ValidateType("node")
+	if err != nil {
+		t.Fatalf("ValidateType failed: %v", err)
+	}
+	if len(failures) != 2 || failures[0].Path != "node.legacy" || failures[1].Path != "node.web1" {
+		t.Errorf("Expected node.legacy and node.web1 to fail validation, got %v", failures)
+	}
+
+	if _, err := hi.ValidateType("db"); err == nil {
+		t.Error("Expected error for type without schema")
+	}
+}
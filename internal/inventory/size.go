@@ -0,0 +1,41 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultMaxSize is the inventory file size above which every save warns,
+// unless SetMaxSize chooses another limit
+const DefaultMaxSize int64 = 10 << 20
+
+// sizeWarnings receives the warning printed when a saved inventory file is
+// larger than the size limit
+var sizeWarnings io.Writer = os.Stderr
+
+// SetMaxSize sets the file size in bytes above which saves warn that the
+// inventory is getting large. Zero restores DefaultMaxSize and a negative
+// limit disables the warning.
+func (hi *HierarchicalInventory) SetMaxSize(limit int64) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.maxSize = limit
+}
+
+// warnIfOversized prints a warning when the file at path exceeds the size
+// limit. Callers must already hold hi.mu.
+func (hi *HierarchicalInventory) warnIfOversized(path string) {
+	limit := hi.maxSize
+	if limit == 0 {
+		limit = DefaultMaxSize
+	}
+	if limit < 0 {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() <= limit {
+		return
+	}
+	fmt.Fprintf(sizeWarnings, "Warning: Inventory file is %.1fMB. Consider archiving old entries.\n", float64(info.Size())/(1<<20))
+}
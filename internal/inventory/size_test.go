@@ -0,0 +1,49 @@
+package inventory
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHierarchicalInventory_SizeWarning(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var warnings bytes.Buffer
+	originalWarnings := sizeWarnings
+	sizeWarnings = &warnings
+	defer func() { sizeWarnings = originalWarnings }()
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set("servers.web1", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("Expected no warning below the default limit, got %q", warnings.String())
+	}
+
+	hi.SetMaxSize(1 << 20)
+	if err := hi.Set("servers.blob", strings.Repeat("x", 3<<19)); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if warnings.String() != "Warning: Inventory file is 1.5MB. Consider archiving old entries.\n" {
+		t.Errorf("Unexpected warning: %q", warnings.String())
+	}
+
+	warnings.Reset()
+	hi.SetMaxSize(-1)
+	if err := hi.Set("servers.web2", "10.0.0.2"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("Expected a negative limit to disable the warning, got %q", warnings.String())
+	}
+}
@@ -0,0 +1,182 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// TypeValidator validates a single entry stored directly under a type key,
+// e.g. the value of "db.server1" for the "db" type
+type TypeValidator func(name string, entry interface{}) error
+
+// RegisterTypeValidator registers a validator that is run whenever Set is
+// called on a "<typeName>.<name>" path. The fields the validator requires may
+// be listed so that help output can describe the type.
+func (hi *HierarchicalInventory) RegisterTypeValidator(typeName string, validator TypeValidator, requiredFields ...string) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+
+	if hi.validators == nil {
+		hi.validators = make(map[string]TypeValidator)
+	}
+	hi.validators[typeName] = validator
+	if hi.requiredFields == nil {
+		hi.requiredFields = make(map[string][]string)
+	}
+	hi.requiredFields[typeName] = requiredFields
+}
+
+// RegisteredTypes returns the sorted names of the types that have a validator
+// or a stored schema
+func (hi *HierarchicalInventory) RegisteredTypes() []string {
+	if err := hi.ensureDataLoaded(); err != nil {
+		return nil
+	}
+
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for typeName := range hi.validators {
+		seen[typeName] = true
+	}
+	if schemas, ok := hi.data[SchemasKey].(map[string]interface{}); ok {
+		for typeName := range schemas {
+			seen[typeName] = true
+		}
+	}
+	types := make([]string, 0, len(seen))
+	for typeName := range seen {
+		types = append(types, typeName)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RequiredFields returns the fields an entry of typeName must have: those
+// listed when its validator was registered, otherwise the "required" list of
+// its stored schema
+func (hi *HierarchicalInventory) RequiredFields(typeName string) []string {
+	if err := hi.ensureDataLoaded(); err != nil {
+		return nil
+	}
+
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+
+	if fields := hi.requiredFields[typeName]; len(fields) > 0 {
+		return fields
+	}
+	schemas, _ := hi.data[SchemasKey].(map[string]interface{})
+	doc, _ := schemas[typeName].(map[string]interface{})
+	required, _ := doc["required"].([]interface{})
+	var fields []string
+	for _, field := range required {
+		if name, ok := field.(string); ok {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// validateEntry runs the registered type validator for an entry-level path.
+// Paths that are not exactly two keys deep are not validated, so partial
+// updates such as "db.server1.host" are still allowed.
+func (hi *HierarchicalInventory) validateEntry(segments []QuerySegment, value interface{}) error {
+	if len(segments) != 2 || segments[0].Type != SegmentTypeKey || segments[1].Type != SegmentTypeKey {
+		return nil
+	}
+
+	validator, ok := hi.validators[segments[0].Key]
+	if !ok {
+		return nil
+	}
+
+	// Validators work on the generic JSON representation, so structs are
+	// normalized the same way they would be after a save and reload
+	normalized, err := NormalizeValue(value)
+	if err != nil {
+		return err
+	}
+
+	if err := validator(segments[1].Key, normalized); err != nil {
+		return fmt.Errorf("invalid %s entry '%s': %v", segments[0].Key, segments[1].Key, err)
+	}
+	return nil
+}
+
+// NormalizeValue converts a value, such as a DbInventoryEntry, into its generic JSON form
+// (map[string]interface{}, []interface{}, float64, string, bool or nil)
+func NormalizeValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// DbRequiredFields are the fields every db entry must have
+var DbRequiredFields = []string{"host", "type", "remote_port"}
+
+// ValidateDbEntry validates that a DB entry follows the correct structure
+func ValidateDbEntry(name string, entry interface{}) error {
+	switch entry.(type) {
+	case DbInventoryEntry, *DbInventoryEntry:
+		normalized, err := NormalizeValue(entry)
+		if err != nil {
+			return err
+		}
+		entry = normalized
+	}
+	entryMap, ok := entry.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("entry is not a map/object")
+	}
+
+	// Check required fields
+	for _, field := range DbRequiredFields {
+		if _, exists := entryMap[field]; !exists {
+			return fmt.Errorf("missing required field '%s'", field)
+		}
+	}
+
+	// Validate field types
+	if _, ok := entryMap["host"].(string); !ok {
+		return fmt.Errorf("field 'host' must be a string")
+	}
+	if _, ok := entryMap["type"].(string); !ok {
+		return fmt.Errorf("field 'type' must be a string")
+	}
+
+	// remote_port can be stored as float64 in JSON, or int64 by 'inventory set'
+	if _, ok := filterNumber(entryMap["remote_port"]); !ok {
+		return fmt.Errorf("field 'remote_port' must be a number, got %T", entryMap["remote_port"])
+	}
+
+	// Optional fields validation
+	if localPort, exists := entryMap["local_port"]; exists {
+		if _, ok := filterNumber(localPort); !ok {
+			return fmt.Errorf("field 'local_port' must be a number, got %T", localPort)
+		}
+	}
+
+	if tags, exists := entryMap["tags"]; exists {
+		if _, ok := tags.([]interface{}); !ok {
+			return fmt.Errorf("field 'tags' must be an array")
+		}
+	}
+
+	if env, exists := entryMap["environment"]; exists {
+		if _, ok := env.(string); !ok {
+			return fmt.Errorf("field 'environment' must be a string")
+		}
+	}
+
+	return nil
+}
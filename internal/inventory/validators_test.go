@@ -0,0 +1,176 @@
+package inventory
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestValidateDbEntry(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       interface{}
+		expectError bool
+	}{
+		{
+			name: "valid entry",
+			entry: map[string]interface{}{
+				"host":        "test.com",
+				"type":        "postgres",
+				"remote_port": float64(5432),
+				"local_port":  float64(5433),
+				"tags":        []interface{}{"dev", "test"},
+			},
+			expectError: false,
+		},
+		{
+			name: "ports stored as int64 by inventory set",
+			entry: map[string]interface{}{
+				"host":        "test.com",
+				"type":        "postgres",
+				"remote_port": int64(5432),
+				"local_port":  int64(5433),
+			},
+			expectError: false,
+		},
+		{
+			name:        "not a map",
+			entry:       "invalid",
+			expectError: true,
+		},
+		{
+			name: "missing host",
+			entry: map[string]interface{}{
+				"type":        "postgres",
+				"remote_port": float64(5432),
+			},
+			expectError: true,
+		},
+		{
+			name: "missing type",
+			entry: map[string]interface{}{
+				"host":        "test.com",
+				"remote_port": float64(5432),
+			},
+			expectError: true,
+		},
+		{
+			name: "missing remote_port",
+			entry: map[string]interface{}{
+				"host": "test.com",
+				"type": "postgres",
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid host type",
+			entry: map[string]interface{}{
+				"host":        123,
+				"type":        "postgres",
+				"remote_port": float64(5432),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid tags type",
+			entry: map[string]interface{}{
+				"host":        "test.com",
+				"type":        "postgres",
+				"remote_port": float64(5432),
+				"tags":        "invalid",
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid environment type",
+			entry: map[string]interface{}{
+				"host":        "test.com",
+				"type":        "postgres",
+				"remote_port": float64(5432),
+				"environment": float64(1),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDbEntry("test", tt.entry)
+			if (err != nil) != tt.expectError {
+				t.Errorf("ValidateDbEntry() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestHierarchicalInventory_RegisterTypeValidator(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	hi.RegisterTypeValidator("db", ValidateDbEntry)
+
+	// Invalid entry-level values are rejected
+	if err := hi.Set("db.broken", map[string]interface{}{"host": "x"}); err == nil {
+		t.Error("Expected error when setting an invalid db entry")
+	}
+	if _, err := hi.Query("db.broken"); err == nil {
+		t.Error("Invalid db entry should not have been stored")
+	}
+
+	// Structs are validated through their JSON representation
+	type entry struct {
+		Host       string `json:"host"`
+		Type       string `json:"type"`
+		RemotePort int    `json:"remote_port"`
+	}
+	if err := hi.Set("db.valid", entry{Host: "db.example.com", Type: "postgres", RemotePort: 5432}); err != nil {
+		t.Errorf("Expected valid db entry to be stored, got %v", err)
+	}
+
+	// Partial updates below the entry level are not validated
+	if err := hi.Set("db.partial.host", "db.example.com"); err != nil {
+		t.Errorf("Expected partial update to succeed, got %v", err)
+	}
+
+	// Other types are unaffected
+	if err := hi.Set("node.web1", "anything"); err != nil {
+		t.Errorf("Expected unvalidated type to accept any value, got %v", err)
+	}
+}
+
+func TestHierarchicalInventory_RequiredFields(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	hi.RegisterTypeValidator("db", ValidateDbEntry, DbRequiredFields...)
+	if err := hi.Set(SchemasKey+".node", map[string]interface{}{"type": "object", "required": []interface{}{"host", "user"}}); err != nil {
+		t.Fatalf("Failed to set schema: %v", err)
+	}
+
+	if got := hi.RegisteredTypes(); !reflect.DeepEqual(got, []string{"db", "node"}) {
+		t.Errorf("Expected registered types [db node], got %v", got)
+	}
+	if got := hi.RequiredFields("db"); !reflect.DeepEqual(got, []string{"host", "type", "remote_port"}) {
+		t.Errorf("Unexpected db required fields: %v", got)
+	}
+	if got := hi.RequiredFields("node"); !reflect.DeepEqual(got, []string{"host", "user"}) {
+		t.Errorf("Expected schema required fields for node, got %v", got)
+	}
+	if got := hi.RequiredFields("servers"); len(got) != 0 {
+		t.Errorf("Expected no required fields for an unregistered type, got %v", got)
+	}
+}
@@ -0,0 +1,165 @@
+package inventory
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WebhooksKey is the reserved top-level key holding the webhook that is
+// notified of inventory changes
+const WebhooksKey = "_webhooks"
+
+// Webhook events
+const (
+	WebhookEventSet    = "set"
+	WebhookEventDelete = "delete"
+	WebhookEventTest   = "test"
+)
+
+// webhookTimeout bounds each webhook request so a slow endpoint cannot hang
+// inventory writes
+const webhookTimeout = 5 * time.Second
+
+// WebhookConfig is the value stored under _webhooks. Events defaults to set
+// and delete; Paths optionally restricts notifications to paths matching one
+// of the glob patterns, as in _hooks.
+type WebhookConfig struct {
+	URL      string   `json:"url"`
+	Events   []string `json:"events,omitempty"`
+	Paths    []string `json:"paths,omitempty"`
+	Insecure bool     `json:"insecure,omitempty"`
+}
+
+// WebhookPayload is the JSON body posted for each change
+type WebhookPayload struct {
+	Event    string      `json:"event"`
+	Path     string      `json:"path"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+	TS       string      `json:"ts"`
+}
+
+// webhookWarnings receives the warning printed when a webhook cannot be
+// delivered. Delivery failures never fail the write that triggered them.
+var webhookWarnings io.Writer = os.Stderr
+
+// postWebhook sends body to url as JSON
+var postWebhook = func(url string, insecure bool, body []byte) error {
+	client := &http.Client{Timeout: webhookTimeout}
+	if insecure {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookConfig returns the stored webhook, or false if none is configured
+func (hi *HierarchicalInventory) webhookConfig() (WebhookConfig, bool) {
+	hi.mu.RLock()
+	raw, ok := hi.data[WebhooksKey]
+	hi.mu.RUnlock()
+	if !ok {
+		return WebhookConfig{}, false
+	}
+
+	var config WebhookConfig
+	data, err := json.Marshal(raw)
+	if err != nil || json.Unmarshal(data, &config) != nil || config.URL == "" {
+		return WebhookConfig{}, false
+	}
+	if len(config.Events) == 0 {
+		config.Events = []string{WebhookEventSet, WebhookEventDelete}
+	}
+	return config, true
+}
+
+// wants reports whether the webhook subscribes to event on path
+func (config WebhookConfig) wants(event, path string) bool {
+	subscribed := false
+	for _, e := range config.Events {
+		if e == event {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		return false
+	}
+	if len(config.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range config.Paths {
+		if matched, err := filepath.Match(pattern, path); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// currentValue returns the value at path, or nil if it does not exist
+func (hi *HierarchicalInventory) currentValue(path string) interface{} {
+	hi.mu.RLock()
+	defer hi.mu.RUnlock()
+	value, _ := hi.queryValue(path)
+	return value
+}
+
+// notifyWebhook posts a change to the configured webhook. Like hooks,
+// webhooks only fire when hooks are enabled and never for reserved keys.
+func (hi *HierarchicalInventory) notifyWebhook(event, path string, oldValue, newValue interface{}) {
+	hi.mu.RLock()
+	enabled := hi.hooksEnabled
+	hi.mu.RUnlock()
+	if !enabled || strings.HasPrefix(path, "_") {
+		return
+	}
+
+	config, ok := hi.webhookConfig()
+	if !ok || !config.wants(event, path) {
+		return
+	}
+	if err := sendWebhook(config, event, path, oldValue, newValue); err != nil {
+		fmt.Fprintf(webhookWarnings, "Warning: webhook %s failed for %s: %v\n", event, path, err)
+	}
+}
+
+// SendTestWebhook posts a "test" event to the configured webhook
+func (hi *HierarchicalInventory) SendTestWebhook() error {
+	if err := hi.ensureDataLoaded(); err != nil {
+		return err
+	}
+	config, ok := hi.webhookConfig()
+	if !ok {
+		return fmt.Errorf("no webhook configured")
+	}
+	return sendWebhook(config, WebhookEventTest, "", nil, nil)
+}
+
+func sendWebhook(config WebhookConfig, event, path string, oldValue, newValue interface{}) error {
+	body, err := json.Marshal(WebhookPayload{
+		Event:    event,
+		Path:     path,
+		OldValue: oldValue,
+		NewValue: newValue,
+		TS:       metaNow().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	return postWebhook(config.URL, config.Insecure, body)
+}
@@ -0,0 +1,157 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHierarchicalInventory_Webhooks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalNow := metaNow
+	defer func() { metaNow = originalNow }()
+	metaNow = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	var received []WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Invalid webhook body: %v", err)
+		}
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.Set(WebhooksKey, WebhookConfig{URL: server.URL, Paths: []string{"db.*"}}); err != nil {
+		t.Fatalf("Failed to set webhook: %v", err)
+	}
+	if err := hi.Set("db.pg.host", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("Expected no webhooks while hooks are disabled, got %v", received)
+	}
+
+	hi.SetHooksEnabled(true)
+	if err := hi.Set("db.pg.host", "10.0.0.2"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if err := hi.SetBulk(map[string]interface{}{"db.pg.port": 5432, "node.web1.host": "10.0.1.1"}); err != nil {
+		t.Fatalf("Failed to set values: %v", err)
+	}
+	if err := hi.Delete("db.pg"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 webhooks for db paths, got %+v", received)
+	}
+	first := received[0]
+	if first.Event != "set" || first.Path != "db.pg.host" || first.OldValue != "10.0.0.1" || first.NewValue != "10.0.0.2" || first.TS != "2024-01-01T00:00:00Z" {
+		t.Errorf("Unexpected set payload: %+v", first)
+	}
+	if received[1].Path != "db.pg.port" || received[1].OldValue != nil {
+		t.Errorf("Unexpected bulk payload: %+v", received[1])
+	}
+	deleted := received[2]
+	if deleted.Event != "delete" || deleted.Path != "db.pg" || deleted.NewValue != nil {
+		t.Errorf("Unexpected delete payload: %+v", deleted)
+	}
+	if old, ok := deleted.OldValue.(map[string]interface{}); !ok || old["host"] != "10.0.0.2" {
+		t.Errorf("Expected the deleted value as old_value, got %v", deleted.OldValue)
+	}
+
+	received = nil
+	if err := hi.SendTestWebhook(); err != nil {
+		t.Fatalf("SendTestWebhook failed: %v", err)
+	}
+	if len(received) != 1 || received[0].Event != "test" {
+		t.Errorf("Expected a test event, got %+v", received)
+	}
+}
+
+func TestHierarchicalInventory_WebhookFailureIsAWarning(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var warnings bytes.Buffer
+	originalWarnings := webhookWarnings
+	webhookWarnings = &warnings
+	defer func() { webhookWarnings = originalWarnings }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	hi.SetHooksEnabled(true)
+	if err := hi.Set(WebhooksKey, WebhookConfig{URL: server.URL, Events: []string{"set"}}); err != nil {
+		t.Fatalf("Failed to set webhook: %v", err)
+	}
+
+	if err := hi.Set("servers.web1", "10.0.0.1"); err != nil {
+		t.Fatalf("Expected the write to succeed despite the webhook, got %v", err)
+	}
+	if !strings.Contains(warnings.String(), "Warning: webhook set failed for servers.web1: webhook returned 500") {
+		t.Errorf("Unexpected warning: %q", warnings.String())
+	}
+	if result, _ := hi.Query("servers.web1"); result != "10.0.0.1" {
+		t.Errorf("Expected value to be saved, got %v", result)
+	}
+}
+
+func TestSendTestWebhookInsecure(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tsukuyo-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	hi, err := NewHierarchicalInventory(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create hierarchical inventory: %v", err)
+	}
+	if err := hi.SendTestWebhook(); err == nil {
+		t.Error("Expected an error without a webhook")
+	}
+
+	if err := hi.Set(WebhooksKey, WebhookConfig{URL: server.URL}); err != nil {
+		t.Fatalf("Failed to set webhook: %v", err)
+	}
+	if err := hi.SendTestWebhook(); err == nil {
+		t.Error("Expected the self-signed certificate to be rejected")
+	}
+
+	if err := hi.Set(WebhooksKey, WebhookConfig{URL: server.URL, Insecure: true}); err != nil {
+		t.Fatalf("Failed to set webhook: %v", err)
+	}
+	if err := hi.SendTestWebhook(); err != nil {
+		t.Errorf("Expected --insecure to skip verification, got %v", err)
+	}
+}